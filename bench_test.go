@@ -0,0 +1,92 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+)
+
+// mkWideTree returns a command tree with about n commands, shaped like a
+// plugin aggregation: the root has n/5 plugins with four subcommands each.
+func mkWideTree(n int) *cobra.Command {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "tool", Short: "A tool with many plugins"}
+	root.PersistentFlags().Bool("verbose", false, "talk more")
+	for p := range n / 5 {
+		plugin := &cobra.Command{Use: fmt.Sprintf("plugin%d", p), Short: "A plugin"}
+		plugin.PersistentFlags().String("config", "", "read the configuration from `FILE`")
+		for _, verb := range []string{"get", "set", "list", "delete"} {
+			cmd := &cobra.Command{
+				Use:     verb + " NAME [VALUE]",
+				Short:   "The " + verb + " subcommand",
+				Long:    "This is the " + verb + " subcommand of the plugin.\n\nIt does what its name says.",
+				Example: "tool plugin " + verb + " thing",
+				Run:     run,
+			}
+			cmd.Flags().StringP("output", "o", "text", "output format")
+			cmd.Flags().Int("limit", 10, "show at most this many")
+			plugin.AddCommand(cmd)
+		}
+		root.AddCommand(plugin)
+	}
+	return root
+}
+
+func benchmarkGenerateDocs(b *testing.B, n int, opts cobraman.Options) {
+	b.Helper()
+	root := mkWideTree(n)
+	dir := b.TempDir()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		o := opts
+		if err := cobraman.GenerateDocs(root, &o, dir, "troff"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateDocs(b *testing.B) {
+	for _, n := range []int{300, 1000, 5000} {
+		b.Run(fmt.Sprintf("%dcmds", n), func(b *testing.B) {
+			benchmarkGenerateDocs(b, n, cobraman.Options{})
+		})
+		b.Run(fmt.Sprintf("%dcmds/parallel", n), func(b *testing.B) {
+			benchmarkGenerateDocs(b, n, cobraman.Options{Parallel: 8})
+		})
+	}
+}
+
+func BenchmarkBuildDocData(b *testing.B) {
+	root := &cobra.Command{Use: "tool"}
+	for i := range 10 {
+		root.PersistentFlags().String(fmt.Sprintf("global%d", i), "", "a global flag")
+	}
+	cmd := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	for i := range 30 {
+		cmd.Flags().Int(fmt.Sprintf("flag%d", i), i, "a flag of the `NUMBER` kind")
+	}
+	root.AddCommand(cmd)
+
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := cobraman.BuildDocData(cmd, &cobraman.Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
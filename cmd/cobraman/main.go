@@ -0,0 +1,218 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command cobraman generates the documentation of a cobra command tree
+// defined in another package.  It is meant for go:generate directives, so a
+// project does not need its own main package just for generating its docs:
+//
+//	//go:generate go run github.com/carlwr/cobraman/cmd/cobraman --cmd example.com/app/cmd.NewRootCmd() --format troff --format markdown --output-dir ../docs
+//
+// --cmd names the package and a Go expression, evaluated in that package,
+// that returns the root *cobra.Command: a call of its constructor as above,
+// or a variable such as example.com/app/cmd.RootCmd.  The command builds and
+// runs a program generating the docs in a temporary directory below the
+// current one, so it is part of the same module and may use its internal
+// packages.  The module therefore must require github.com/carlwr/cobraman.
+//
+// With more than one --format, the docs of each format are written to a
+// subdirectory of the output directory named after the format.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/spf13/cobra"
+)
+
+// ErrInvalidTarget is returned when --cmd is not an import path followed by
+// a Go expression.
+var ErrInvalidTarget = errors.New("--cmd must be <import path>.<expression>")
+
+// ErrUnknownFormat is returned when --format names a template that does not
+// exist.
+var ErrUnknownFormat = errors.New("unknown format")
+
+func main() {
+	if err := newCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// config holds the flags of the command.
+type config struct {
+	target       string
+	formats      []string
+	outputDir    string
+	section      string
+	leftFooter   string
+	centerHeader string
+	author       string
+}
+
+func newCommand() *cobra.Command {
+	cfg := &config{}
+	cmd := &cobra.Command{
+		Use:   "cobraman --cmd <import path>.<expression> [flags]",
+		Short: "Generate the documentation of a cobra command tree",
+		Long: `Generate the documentation of a cobra command tree defined in another package,
+e.g. from a go:generate directive.
+
+The formats are:
+` + formatList(),
+		Example: `  cobraman --cmd example.com/app/cmd.NewRootCmd() --format troff --output-dir man`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			return run(cfg)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&cfg.target, "cmd", "", "the package and expression returning the root `command`")
+	f.StringSliceVar(&cfg.formats, "format", []string{"troff"}, "the `template` to generate docs with")
+	f.StringVarP(&cfg.outputDir, "output-dir", "o", ".", "the `directory` to write the docs to")
+	f.StringVar(&cfg.section, "section", "", "the man page section (default 1)")
+	f.StringVar(&cfg.leftFooter, "left-footer", "", "the left footer, e.g. the name and version of the program")
+	f.StringVar(&cfg.centerHeader, "center-header", "", "the center header")
+	f.StringVar(&cfg.author, "author", "", "the AUTHOR section")
+	_ = cmd.MarkFlagRequired("cmd")
+	return cmd
+}
+
+// formatList returns the list of the built-in templates for the help text.
+func formatList() string {
+	var b strings.Builder
+	for _, info := range templ.TemplateInfos() {
+		if info.BuiltIn {
+			fmt.Fprintf(&b, "  %-10s %s\n", info.Name, info.Description)
+		}
+	}
+	return b.String()
+}
+
+// run writes the generator program for cfg to a temporary directory below
+// the current one and runs it with go run.
+func run(cfg *config) error {
+	src, err := generatorSource(cfg)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp(".", ".cobraman-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, src, 0o600); err != nil {
+		return err
+	}
+
+	goRun := exec.Command("go", "run", file)
+	goRun.Stdout, goRun.Stderr = os.Stdout, os.Stderr
+	return goRun.Run()
+}
+
+// splitTarget splits the value of --cmd, e.g.
+// "example.com/app/cmd.NewRootCmd()", into the import path and the
+// expression.  The expression may contain slashes, e.g. in string arguments,
+// but not the package name.
+func splitTarget(target string) (pkg, expr string, err error) {
+	head := target
+	if i := strings.IndexAny(target, "(["); i >= 0 {
+		head = target[:i]
+	}
+	slash := strings.LastIndex(head, "/")
+	dot := strings.Index(target[slash+1:], ".")
+	if dot <= 0 || slash+1+dot == len(target)-1 {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidTarget, target)
+	}
+	return target[:slash+1+dot], target[slash+1+dot+1:], nil
+}
+
+// generatorSource returns the source of the program generating the docs
+// described by cfg.
+func generatorSource(cfg *config) ([]byte, error) {
+	pkg, expr, err := splitTarget(cfg.target)
+	if err != nil {
+		return nil, err
+	}
+	outputDir, err := filepath.Abs(cfg.outputDir)
+	if err != nil {
+		return nil, err
+	}
+	var formatDirs []string
+	for _, format := range cfg.formats {
+		if _, ok := templ.GetTemplateInfo(format); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+		}
+		dir := outputDir
+		if len(cfg.formats) > 1 {
+			dir = filepath.Join(outputDir, format)
+		}
+		formatDirs = append(formatDirs, format, dir)
+	}
+
+	var src strings.Builder
+	err = generatorTemplate.Execute(&src, map[string]interface{}{
+		"Package":    pkg,
+		"Expr":       expr,
+		"FormatDirs": formatDirs,
+		"Options": map[string]string{
+			"Section":      cfg.section,
+			"LeftFooter":   cfg.leftFooter,
+			"CenterHeader": cfg.centerHeader,
+			"Author":       cfg.author,
+		},
+	})
+	return []byte(src.String()), err
+}
+
+var generatorTemplate = template.Must(template.New("generator").Parse(`// Code generated by github.com/carlwr/cobraman/cmd/cobraman. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/carlwr/cobraman"
+	target {{ printf "%q" .Package }}
+)
+
+func main() {
+	root := target.{{ .Expr }}
+	formatDirs := []string{ {{- range .FormatDirs }}{{ printf "%q" . }}, {{ end -}} }
+	for i := 0; i < len(formatDirs); i += 2 {
+		opts := cobraman.Options{
+			Section:      {{ printf "%q" .Options.Section }},
+			LeftFooter:   {{ printf "%q" .Options.LeftFooter }},
+			CenterHeader: {{ printf "%q" .Options.CenterHeader }},
+			Author:       {{ printf "%q" .Options.Author }},
+		}
+		if err := os.MkdirAll(formatDirs[i+1], 0o755); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := cobraman.GenerateDocs(root, &opts, formatDirs[i+1], formatDirs[i]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+`))
@@ -0,0 +1,69 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTarget(t *testing.T) {
+	cases := []struct{ target, pkg, expr string }{
+		{"example.com/app/cmd.NewRootCmd()", "example.com/app/cmd", "NewRootCmd()"},
+		{"example.com/app/cmd.RootCmd", "example.com/app/cmd", "RootCmd"},
+		{"example.com/app/cmd.New(example.com/x)", "example.com/app/cmd", "New(example.com/x)"},
+		{"app.New()", "app", "New()"},
+	}
+	for _, c := range cases {
+		pkg, expr, err := splitTarget(c.target)
+		require.NoError(t, err, c.target)
+		assert.Equal(t, c.pkg, pkg)
+		assert.Equal(t, c.expr, expr)
+	}
+
+	for _, target := range []string{"", "example.com/app/cmd", "example.com/app/cmd.", ".New()"} {
+		_, _, err := splitTarget(target)
+		assert.ErrorIs(t, err, ErrInvalidTarget, target)
+	}
+}
+
+func TestGeneratorSource(t *testing.T) {
+	cfg := &config{
+		target:    "example.com/app/cmd.NewRootCmd()",
+		formats:   []string{"troff", "markdown"},
+		outputDir: "/docs",
+		author:    `Jane "JD" Doe`,
+	}
+	src, err := generatorSource(cfg)
+	require.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "main.go", src, 0)
+	require.NoError(t, err, string(src))
+	assert.Contains(t, string(src), `target "example.com/app/cmd"`)
+	assert.Contains(t, string(src), "root := target.NewRootCmd()")
+	assert.Contains(t, string(src), `"troff", "/docs/troff", "markdown", "/docs/markdown"`)
+	assert.Contains(t, string(src), `"Jane \"JD\" Doe"`)
+
+	cfg.formats = []string{"troff"}
+	src, err = generatorSource(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `"troff", "/docs",`)
+
+	cfg.formats = []string{"nope"}
+	_, err = generatorSource(cfg)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
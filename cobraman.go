@@ -16,14 +16,24 @@
 package cobraman
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"reflect"
-	"runtime"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/carlwr/cobraman/internal/templ"
 	"github.com/spf13/cobra"
@@ -33,12 +43,47 @@ import (
 // ErrMissingCommandName is returned with no command is provided.
 var ErrMissingCommandName = errors.New("you need a command name to have a man page")
 
+// ErrAnnotationTemplate is returned when the value of an annotation cannot
+// be expanded as a template, see Options.ExpandAnnotations.
+var ErrAnnotationTemplate = errors.New("invalid annotation template")
+
+// ErrInvalidOptions is returned for Options that are not valid, such as
+// MonthNames that do not hold 12 names or FormatOverrides naming an unknown
+// section.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// ErrInvalidTemplate is returned when the template to generate the pages
+// with, registered with RegisterTemplate, fails to parse.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// PageError describes a page that could not be generated.  It is returned,
+// joined with the errors of other failed pages, by GenerateDocs and its
+// variants when Options.ContinueOnError is set.
+type PageError struct {
+	// CommandPath is the space separated path of the command (e.g. "git commit")
+	CommandPath string
+	Err         error
+}
+
+func (e *PageError) Error() string { return e.CommandPath + ": " + e.Err.Error() }
+
+func (e *PageError) Unwrap() error { return e.Err }
+
 // Options is used configure how GenerateManPages will
 // do its job.
 type Options struct {
-	// What section to generate the pages for (1 is the default if not set)
+	// What section to generate the pages for (1 is the default if not set).
+	// Sections with a suffix, such as "3pm", "1m" or "8c", are used verbatim
+	// for the file suffix and the header of the pages.
 	Section string
 
+	// SectionFunc, if set, returns the section of the page of cmd, e.g. "8"
+	// for the commands below "tool admin", overriding Section for that page,
+	// its file suffix and the SEE ALSO entries referring to it.  An empty
+	// result leaves the page in Section.  cmd is nil if the page was not
+	// generated from a cobra.Command.
+	SectionFunc func(cmd *cobra.Command) string
+
 	// CenterFooter used across all pages (defaults to current month and year)
 	// If you just want to set the date used in the center footer use Date
 	CenterFooter string
@@ -47,7 +92,23 @@ type Options struct {
 	// Will default to Now
 	Date *time.Time
 
-	// LeftFooter used across all pages
+	// Now, if set, is called instead of time.Now for the current time, e.g.
+	// the date of the pages when Date is not set, so tests and reproducible
+	// builds can fix it in one place.
+	Now func() time.Time
+
+	// MonthNames, if set, holds the names of the months from January to
+	// December, e.g. in the language of the pages, and replaces the English
+	// month names in the date of the pages.  The default CenterFooter then
+	// shows the full name of the month instead of its English abbreviation.
+	MonthNames []string
+
+	// ISODate writes the date of the pages fully numeric, e.g. "2018-06-21",
+	// instead of with a month name.
+	ISODate bool
+
+	// LeftFooter used across all pages, typically the name and version of
+	// the software.  The mdoc template uses it as the argument of .Os.
 	LeftFooter string
 
 	// CenterHeader used across all pages
@@ -56,27 +117,65 @@ type Options struct {
 	// Files if set with content will create a FILES section for all
 	// pages.  If you want this section only for a single command add
 	// it as an annotation: cmd.Annotations["man-files-section"]
-	// The field will be sanitized for troff output. However, if
-	// it starts with a '.' we assume it is valid troff and pass it through.
+	// The field is escaped for troff output, including lines starting
+	// with a '.', which are printed as text rather than run as requests.
 	Files string
 
 	// Bugs if set with content will create a BUGS section for all
 	// pages.  If you want this section only for a single command add
 	// it as an annotation: cmd.Annotations["man-bugs-section"]
-	// The field will be sanitized for troff output. However, if
-	// it starts with a '.' we assume it is valid troff and pass it through.
+	// The field is escaped for troff output, including lines starting
+	// with a '.', which are printed as text rather than run as requests.
 	Bugs string
 
+	// ExitStatus if set with content will create an EXIT STATUS section,
+	// named RETURN VALUES for BSD style pages of library sections, for all
+	// pages.  If you want this section only for a single command add it as
+	// an annotation: cmd.Annotations["man-exit-status-section"]
+	ExitStatus string
+
+	// ManStyle selects the conventions of GNU or BSD systems for the parts
+	// of man pages in which they differ, see ManStyle.
+	ManStyle ManStyle
+
 	// Environment if set with content will create a ENVIRONMENT section for all
 	// pages.  If you want this section only for a single command add
 	// it as an annotation: cmd.Annotations["man-environment-section"]
-	// The field will be sanitized for troff output. However, if
-	// it starts with a '.' we assume it is valid troff and pass it through.
+	// The field is escaped for troff output, including lines starting
+	// with a '.', which are printed as text rather than run as requests.
 	Environment string
 
+	// EnvironmentEntries and FilesEntries, if set, list the environment
+	// variables and files of all pages, each with its description, in the
+	// ENVIRONMENT and FILES sections, after Environment and Files.  The
+	// built-in templates render them as tagged lists, e.g. with the Ev and
+	// Pa macros of mdoc.
+	EnvironmentEntries []SectionEntry
+	FilesEntries       []SectionEntry
+
 	// Author if set will create a Author section with this content.
 	Author string
 
+	// DocOverrides, if set, holds documentation of commands, keyed by their
+	// command path (e.g. "git commit"), that replaces what the commands
+	// define, e.g. as read by LoadDocOverrides from sidecar files edited
+	// without touching the Go code.
+	DocOverrides map[string]DocOverride
+
+	// DocFileRoot is the directory that the markdown files named by the
+	// man-description-file, man-environment-file, man-files-file and
+	// man-bugs-file annotations of commands are relative to, e.g.
+	// "docs".  It defaults to the current directory.  The content of such a
+	// file replaces the description or section of the command, and is
+	// converted from markdown for pages that are not markdown.
+	DocFileRoot string
+
+	// History, if set, holds the changes of the releases of the software,
+	// e.g. as read by ParseChangelog.  The page of each command gets a
+	// HISTORY section listing the entries about it, so the pages keep in
+	// sync with the release notes.
+	History []HistoryEntry
+
 	// Private fields
 
 	// fileCmdSeparator defines what character to use to separate the
@@ -87,10 +186,384 @@ type Options struct {
 	// for man templates and .md for the MarkdownTemplate template.
 	fileSuffix string
 
+	// suffixIsSection is set if the file suffix is the section of the page,
+	// as for man templates.
+	suffixIsSection bool
+
+	// sectionDirs is set if the pages are written to the man directory of
+	// the section of each page, e.g. "man3" for "3pm", as for localized man
+	// pages.
+	sectionDirs bool
+
+	// dateIsNow is set if Date was not set and defaulted to the time of
+	// generation.
+	dateIsNow bool
+
+	// suiteRoots are the roots generated together by GenerateSuite.
+	suiteRoots []CommandModel
+
+	// warnings collects the warnings of GenerateDocsWithWarnings.
+	warnings *warningCollector
+
 	// CustomData allows passing custom data into the template
 	CustomData map[string]interface{}
+
+	// CustomDataFunc, if set, is called for each page and the returned values
+	// are added to CustomData for that page, overriding values with the same
+	// key.  cmd is nil if the page was not generated from a cobra.Command.
+	CustomDataFunc func(cmd *cobra.Command) map[string]interface{}
+
+	// PostProcess, if set, is applied to each rendered page before it is
+	// written.  It receives the command the page documents (nil if the page
+	// was not generated from a cobra.Command) and returns the content to write.
+	PostProcess func(cmd *cobra.Command, content []byte) ([]byte, error)
+
+	// PrepareData, if set, is called for each page after the page data has
+	// been collected and before the template is executed, allowing any field
+	// of data to be changed.  cmd is nil if the page was not generated from a
+	// cobra.Command.  A returned error aborts the generation of the page.
+	PrepareData func(cmd *cobra.Command, data *DocData) error
+
+	// BeforeCommand and AfterCommand, if set, are called by GenerateDocs
+	// before and after writing the page of each command to filename, e.g. to
+	// produce side artifacts such as recordings of its help output next to
+	// the page.  AfterCommand is only called once the page has been written
+	// successfully.  cmd is nil if the page was not generated from a
+	// cobra.Command.  A returned error fails the page.
+	BeforeCommand func(cmd *cobra.Command, filename string) error
+	AfterCommand  func(cmd *cobra.Command, filename string) error
+
+	// Parallel is the number of pages GenerateDocs generates concurrently.
+	// Pages are generated one at a time if it is 0 or 1.  When generating
+	// concurrently, the hooks of these options may also be called concurrently.
+	Parallel int
+
+	// FileMode and DirMode, if set, are the permissions of the files and of
+	// the directories, such as those of Locales and Profiles, that
+	// GenerateDocs creates, e.g. 0o644 and 0o755 for packaging.  Unlike the
+	// default permissions, they are not restricted by the umask.
+	FileMode os.FileMode
+	DirMode  os.FileMode
+
+	// FS, if set, is the file system GenerateDocs writes to instead of the
+	// one of the operating system, e.g. a MemFS.
+	FS FS
+
+	// Filter, if set, decides which commands GenerateDocs generates pages
+	// for, given their command path (e.g. "git commit").  The children of a
+	// command that is filtered out are still considered.
+	Filter func(commandPath string) bool
+
+	// NoArgsFunc, if set, can override whether the command with the given
+	// path (e.g. "git commit") is documented as accepting no positional
+	// arguments: the returned noArgs is used if ok is true.  Otherwise the
+	// man-args annotation of the command decides, "none" meaning that it
+	// accepts none, and then its Args validator.
+	NoArgsFunc func(commandPath string) (noArgs bool, ok bool)
+
+	// FormatDefault, if set, is called for every flag and returns the default
+	// value the templates show for it, or "" to show none.  The Default of
+	// the flag passed holds what is shown otherwise: its DefValue, unless that
+	// is the zero value of its type, such as "" or false.  HumanizeDefault
+	// renders durations and sizes in a human-readable way.
+	FormatDefault func(flag Flag) string
+
+	// RedactDefault, if set, is called for every flag with its default value
+	// and returns the value to document instead, so no page leaks e.g. a
+	// token, an internal URL or a path of the machine generating the pages,
+	// see RedactHomeDir.  It replaces both the DefValue and the Default of
+	// the flag, before FormatDefault is called.  Returning "" documents no
+	// default.
+	RedactDefault func(flag Flag, value string) string
+
+	// FlagEnv, if set, is called for every flag without a man-flag-env
+	// annotation and returns the environment variable backing it, or "" if
+	// there is none, e.g. to document the variables that viper's
+	// AutomaticEnv binds.  The variable is shown with the flag in OPTIONS and
+	// listed in the ENVIRONMENT section.
+	FlagEnv func(flag Flag) string
+
+	// IncludeDeprecated documents the deprecated flags, which are otherwise
+	// left out like hidden flags, in a subsection of OPTIONS of their own,
+	// along with their deprecation message and the flag superseding them if
+	// the message names one, as in "use --output instead".
+	IncludeDeprecated bool
+
+	// CollapseInheritedFlags leaves the flags a command inherits from its
+	// parents out of its page, which instead refers to the page of its
+	// parent.  This shrinks the pages of CLIs with many global flags, which
+	// are otherwise repeated on every page.
+	CollapseInheritedFlags bool
+
+	// MergePersistentFlags makes AllFlags include the persistent flags of the
+	// command and of its parents even if cobra has not yet merged them into
+	// the flags of the command, as it does when the command is executed.
+	// CollapseInheritedFlags implies it.
+	MergePersistentFlags bool
+
+	// IncludeHidden documents hidden commands and flags, e.g. for a complete
+	// set of pages for the developers of a CLI.
+	IncludeHidden bool
+
+	// AnnotationPrefix, if set, selects a namespace of annotations: the
+	// man-environment-section, man-files-section, man-bugs-section,
+	// man-exit-status-section and man-examples-section annotations of a
+	// command are taken with this prefix if they are set, e.g.
+	// "internal.man-files-section" for the prefix "internal.", and without it
+	// otherwise.
+	AnnotationPrefix string
+
+	// Profiles, if set, makes GenerateDocs generate the pages once for each
+	// profile, into a subdirectory of the directory named after the profile,
+	// with the choices of the profile applied to these options.  This way,
+	// one run can produce e.g. a public and an internal set of pages from the
+	// same command tree.
+	Profiles []Profile
+
+	// SuiteIndex, if set, is the name of a file GenerateSuite writes an
+	// index of the pages to, in markdown, e.g. "index.md" or "README.md".
+	SuiteIndex string
+
+	// Synopsis selects how the SYNOPSIS of the built-in templates is
+	// rendered, see SynopsisMode.
+	Synopsis SynopsisMode
+
+	// SeeAlsoPolicy selects which related commands are listed in the SEE
+	// ALSO section of each page.
+	SeeAlsoPolicy SeeAlsoPolicy
+
+	// FormatOverrides, if set, selects the optional sections of the pages
+	// of a template, keyed by the name of the template, e.g. to leave AUTHOR
+	// and BUGS out of the markdown pages of a website but keep them in the
+	// man pages generated with the same options.
+	FormatOverrides map[string]FormatOverride
+
+	// Encoding selects how characters that are not ASCII are written to man
+	// pages.  By default they are written as UTF-8 without further notice.
+	// EncodingUTF8 additionally declares the encoding in the first line of
+	// the page, as recognized by groff's preconv and by mandoc, and
+	// EncodingASCII writes them as groff \[uXXXX] escapes instead.  Pages
+	// that are not man pages, e.g. markdown, are always written as UTF-8.
+	Encoding string
+
+	// Typography makes man pages use the roff escapes for typographic
+	// characters in the text, such as \(lq and \(rq for curly double quotes
+	// and \(em for em dashes, so they render on any output device.  Pages
+	// that are not man pages are left untouched.
+	Typography bool
+
+	// HyphensAsMinus makes man pages print every '-' in text as a minus
+	// sign, as cobraman did before hyphens within words, e.g. in
+	// "well-known", were kept as hyphens.  The dashes of options are always
+	// minus signs.
+	HyphensAsMinus bool
+
+	// LineWidth, if positive, wraps the lines of running text in the source
+	// of man and markdown pages at this many characters, so the generated
+	// files are easier to read and to diff.  The rendered pages are not
+	// affected.
+	LineWidth int
+
+	// SentencePerLine starts every sentence of running text on a new line in
+	// the source of man and markdown pages, as recommended for roff.  It can
+	// be combined with LineWidth.
+	SentencePerLine bool
+
+	// Locale is the language the pages are written in, e.g. "de" or
+	// "pt_BR".  It is passed to Translate.
+	Locale string
+
+	// Translate, if set, translates the texts of the commands, i.e. their
+	// short and long descriptions and the usage of their flags, into the
+	// language of locale, so one command tree can be documented in several
+	// languages.  It returns text unchanged if it has no translation.
+	Translate func(locale, text string) string
+
+	// Locales, if set, makes GenerateDocs generate the pages once for each of
+	// these locales, with Locale set to it, in the standard layout for
+	// localized documentation: man pages are written to
+	// <directory>/<locale>/man<section>/ and the pages of other templates,
+	// such as markdown, to <directory> with the locale before the file
+	// extension, e.g. "tool_sub.de.md".  The empty locale stands for the
+	// untranslated pages, which are written to <directory>/man<section>/ and
+	// without a locale in their file name.  The directory of a section with
+	// a suffix is named after its number, e.g. man3 for "3pm".
+	Locales []string
+
+	// ContinueOnError makes GenerateDocs generate the remaining pages when a
+	// page fails, instead of stopping at the first failure.  The returned
+	// error then joins a PageError for every failed page.
+	ContinueOnError bool
+
+	// Provenance adds a comment to the top of every page recording the
+	// command it documents, the version of cobraman that generated it, and
+	// where its date comes from, to help tracing an installed page back to
+	// the command and build that produced it.  The comment is written to man
+	// pages, markdown and HTML; pages of other templates are left untouched.
+	Provenance bool
+
+	// Metadata, if set, is written as YAML front matter to the top of
+	// markdown pages, e.g. for the weight, category or edit URL a static site
+	// generator reads.  MetadataFunc, if set, is called for each page and the
+	// returned values are added to Metadata for that page, overriding values
+	// with the same key.  cmd is nil if the page was not generated from a
+	// cobra.Command.
+	Metadata     map[string]interface{}
+	MetadataFunc func(cmd *cobra.Command) map[string]interface{}
+
+	// MarkdownTOC adds a table of contents linking the sections of each page
+	// to the top of markdown pages, with the anchors GitHub generates for
+	// headings, for long pages on documentation sites.
+	MarkdownTOC bool
+
+	// HTMLLayout fits HTML pages into an existing site, see HTMLLayout.
+	HTMLLayout HTMLLayout
+
+	// ExampleTitles renders examples made of blocks separated by blank
+	// lines, each starting with a comment line such as "# List all pods",
+	// as titled subsections of the EXAMPLES section, e.g. .SS in man pages,
+	// so long example sections gain a navigable structure.
+	ExampleTitles bool
+
+	// Strict refuses to generate the pages of commands with documentation
+	// that GenerateDocsWithWarnings would warn about, e.g. a command without
+	// a short description, returning an error wrapping ErrIncompleteDocs
+	// instead.  This lets CI enforce complete documentation.
+	Strict bool
+
+	// ExpandAnnotations executes the values of the man-environment-section,
+	// man-files-section, man-bugs-section, man-exit-status-section and
+	// man-examples-section annotations as templates with the page data, the
+	// DocData, before they are used.  This allows boilerplate shared by many
+	// commands to refer to e.g. {{ .CommandPath }} or {{ .CustomData.config }}.
+	ExpandAnnotations bool
+}
+
+// SynopsisMode is the way the SYNOPSIS of a page is rendered.
+type SynopsisMode int
+
+const (
+	// SynopsisFlags lists every flag of the command, followed by a generic
+	// placeholder for its arguments.  This is the default.
+	SynopsisFlags SynopsisMode = iota
+	// SynopsisUseLine renders the usage line of the command, e.g.
+	// "kubectl get RESOURCE [NAME] [flags]" for a cobra.Command with Use
+	// "get RESOURCE [NAME]", so the synopsis matches the --help output.
+	SynopsisUseLine
+)
+
+// Profile is a named set of choices for generating pages, see
+// Options.Profiles.  Its fields replace those of the Options with the same
+// name; the sections replace them only if they are set.
+type Profile struct {
+	// Name names the profile and the subdirectory its pages are written to,
+	// e.g. "public" or "internal"
+	Name string
+
+	IncludeHidden     bool
+	IncludeDeprecated bool
+	AnnotationPrefix  string
+
+	Environment string
+	Files       string
+	Bugs        string
+}
+
+// FormatOverride selects the optional sections of the pages of a template,
+// and the case of their headings, see Options.FormatOverrides.  The sections
+// are named by their man page heading: "OPTIONS", "COMMANDS",
+// "ENVIRONMENT", "FILES", "BUGS", "EXAMPLES", "HISTORY", "AUTHOR" and
+// "SEE ALSO".
+type FormatOverride struct {
+	// Include, if set, are the only optional sections included
+	Include []string
+	// Exclude are optional sections left out
+	Exclude []string
+	// HeadingCase is the case of the headings of the pages
+	HeadingCase HeadingCase
+}
+
+// HeadingCase is the case of the headings of pages, see FormatOverride.
+type HeadingCase int
+
+const (
+	// HeadingDefault leaves the headings as the template writes them, in
+	// upper case in man pages and in title case in markdown and HTML.
+	HeadingDefault HeadingCase = iota
+	// HeadingUpper writes headings in upper case, e.g. "SEE ALSO".
+	HeadingUpper
+	// HeadingTitle capitalizes every word of headings, e.g. "See Also".
+	HeadingTitle
+	// HeadingSentence capitalizes the first word of headings only, e.g.
+	// "See also".
+	HeadingSentence
+)
+
+// format returns text in case c.
+func (c HeadingCase) format(text string) string {
+	switch c {
+	case HeadingUpper:
+		return strings.ToUpper(text)
+	case HeadingTitle, HeadingSentence:
+		words := strings.Fields(strings.ToLower(text))
+		for i, word := range words {
+			if i == 0 || c == HeadingTitle {
+				r, size := utf8.DecodeRuneInString(word)
+				words[i] = string(unicode.ToUpper(r)) + word[size:]
+			}
+		}
+		return strings.Join(words, " ")
+	}
+	return text
+}
+
+// optionalSections are the sections a FormatOverride can select.
+var optionalSections = []string{"OPTIONS", "COMMANDS", "EXIT STATUS", "ENVIRONMENT", "FILES", "BUGS", "EXAMPLES", "HISTORY", "AUTHOR", "SEE ALSO"}
+
+// ManStyle is a set of conventions for man pages, see Options.ManStyle.
+type ManStyle int
+
+const (
+	// ManStyleGNU follows man-pages(7) of GNU/Linux systems: EXIT STATUS
+	// follows the options, EXAMPLES and AUTHOR come late and SEE ALSO last,
+	// and .Os of mdoc pages names the software of LeftFooter.  This is the
+	// default.
+	ManStyleGNU ManStyle = iota
+	// ManStyleBSD follows mdoc(7) of the BSDs: EXIT STATUS, or RETURN VALUES
+	// in sections 2, 3 and 9, follows FILES, SEE ALSO is followed by
+	// HISTORY, AUTHOR and BUGS, and .Os of mdoc pages is left for the
+	// system to fill in.
+	ManStyleBSD
+)
+
+// includes reports whether o includes section.
+func (o *FormatOverride) includes(section string) bool {
+	if o == nil {
+		return true
+	}
+	if o.Include != nil && !slices.Contains(o.Include, section) {
+		return false
+	}
+	return !slices.Contains(o.Exclude, section)
 }
 
+// SeeAlsoPolicy is the set of related commands DocData.SeeAlsos lists.
+type SeeAlsoPolicy int
+
+const (
+	// SeeAlsoAll lists the parent, the siblings and the children of the
+	// command.  This is the default.
+	SeeAlsoAll SeeAlsoPolicy = iota
+	// SeeAlsoParentAndChildren lists the parent and the children, leaving
+	// out the siblings, which can be many more than the rest of the page.
+	SeeAlsoParentAndChildren
+	// SeeAlsoParent lists only the parent.
+	SeeAlsoParent
+	// SeeAlsoNone lists no related commands, so there is no SEE ALSO
+	// section.
+	SeeAlsoNone
+)
+
 // Build man pages for the provided cobra.Command
 // and all of its children.
 func GenerateDocs(cmd *cobra.Command, opts *Options, directory string, templateName string) error {
@@ -102,100 +575,684 @@ func GenerateDocs(cmd *cobra.Command, opts *Options, directory string, templateN
 //
 // The returned path is relative if the provided directory is relative.
 //
-// If an error occured, the returned path may be the empty string. It is never the empty string if the returned error value is nil.
+// If an error occured, the returned path may be the empty string. It is never the empty string if the returned error value is nil, unless the page for cmd was excluded by Options.Filter.
 func GenerateDocsF(cmd *cobra.Command, opts *Options, directory string, templateName string) (string, error) {
-	var err error
+	return generateDocsF(NewCobraModel(cmd), opts, directory, templateName, nil)
+}
+
+// GenerateDocsFiles is like GenerateDocs but additionally returns the paths of
+// all files that were generated, with the files of child commands before the
+// file of their parent.  The paths are relative if directory is relative.
+func GenerateDocsFiles(cmd *cobra.Command, opts *Options, directory string, templateName string) ([]string, error) {
+	var files []string
+	_, err := generateDocsF(NewCobraModel(cmd), opts, directory, templateName, &files)
+	return files, err
+}
 
+// GenerateModelDocs is like GenerateDocs but documents a CommandModel, which
+// allows documenting command line interfaces not built with cobra.
+func GenerateModelDocs(m CommandModel, opts *Options, directory string, templateName string) error {
+	_, err := generateDocsF(m, opts, directory, templateName, nil)
+	return err
+}
+
+// generateDocsF generates the documentation for m and its children and
+// returns the path of the page for m.  If files is non-nil, the paths of the
+// generated files are appended to it.
+func generateDocsF(m CommandModel, opts *Options, directory string, templateName string, files *[]string) (string, error) {
 	// Set defaults
-	validate(opts, templateName)
+	if err := validate(opts, templateName); err != nil {
+		return "", err
+	}
 	if directory == "" {
 		directory = "."
 	}
+	if len(opts.Profiles) > 0 {
+		return generateProfiles(m, opts, directory, templateName, files)
+	}
+	if len(opts.Locales) == 0 {
+		return generatePages(m, opts, directory, templateName, files)
+	}
 
-	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
-			continue
+	_, ext, _, _ := templ.GetTemplate(templateName)
+	var (
+		mainPage  string
+		localeErr []error
+	)
+	for i, locale := range opts.Locales {
+		localeOpts, localeDir := localize(opts, locale, directory, ext == "use_section")
+		if err := mkdir(localeDir, opts); err != nil {
+			return "", err
+		}
+		page, err := generatePages(m, localeOpts, localeDir, templateName, files)
+		if err != nil {
+			if !opts.ContinueOnError {
+				return "", err
+			}
+			localeErr = append(localeErr, err)
+		}
+		if i == 0 {
+			mainPage = page
 		}
-		if err := GenerateDocs(c, opts, directory, templateName); err != nil {
+	}
+	if len(localeErr) > 0 {
+		return "", errors.Join(localeErr...)
+	}
+	return mainPage, nil
+}
+
+// generateProfiles generates the pages of each of Options.Profiles into its
+// subdirectory of directory, and returns the path of the main page of the
+// first profile.
+func generateProfiles(m CommandModel, opts *Options, directory string, templateName string, files *[]string) (string, error) {
+	var (
+		mainPage   string
+		profileErr []error
+	)
+	for i, profile := range opts.Profiles {
+		profileDir := filepath.Join(directory, profile.Name)
+		if err := mkdir(profileDir, opts); err != nil {
 			return "", err
 		}
+		page, err := generateDocsF(m, profile.apply(opts), profileDir, templateName, files)
+		if err != nil {
+			if !opts.ContinueOnError {
+				return "", err
+			}
+			profileErr = append(profileErr, err)
+		}
+		if i == 0 {
+			mainPage = page
+		}
+	}
+	if len(profileErr) > 0 {
+		return "", errors.Join(profileErr...)
 	}
+	return mainPage, nil
+}
 
-	// Generate file name and open the file
-	basename := strings.ReplaceAll(cmd.CommandPath(), " ", opts.fileCmdSeparator)
-	if basename == "" {
-		return "", ErrMissingCommandName
+// apply returns a copy of opts with the choices of p.
+func (p Profile) apply(opts *Options) *Options {
+	profileOpts := *opts
+	profileOpts.Profiles = nil
+	profileOpts.IncludeHidden = p.IncludeHidden
+	profileOpts.IncludeDeprecated = p.IncludeDeprecated
+	profileOpts.AnnotationPrefix = p.AnnotationPrefix
+	if p.Environment != "" {
+		profileOpts.Environment = p.Environment
+	}
+	if p.Files != "" {
+		profileOpts.Files = p.Files
+	}
+	if p.Bugs != "" {
+		profileOpts.Bugs = p.Bugs
+	}
+	return &profileOpts
+}
+
+// localize returns a copy of opts for generating the pages of locale, and
+// the directory they are written to, see Options.Locales.  isMan tells
+// whether the pages are man pages.
+func localize(opts *Options, locale string, directory string, isMan bool) (*Options, string) {
+	localeOpts := *opts
+	localeOpts.Locales = nil
+	localeOpts.Locale = locale
+	if isMan {
+		localeOpts.sectionDirs = true
+		return &localeOpts, filepath.Join(directory, locale)
+	}
+	if locale != "" {
+		localeOpts.fileSuffix = locale + "." + opts.fileSuffix
+	}
+	return &localeOpts, directory
+}
+
+// generatePages generates the pages for m and its children with validated
+// options, see generateDocsF.
+func generatePages(m CommandModel, opts *Options, directory string, templateName string, files *[]string) (string, error) {
+	if opts.IncludeHidden {
+		m = withHidden(m)
+	}
+	pages := collectPages(m, nil)
+	if opts.Filter != nil {
+		pages = slices.DeleteFunc(pages, func(p CommandModel) bool { return !opts.Filter(p.CommandPath()) })
+		if len(pages) == 0 {
+			return "", nil
+		}
 	}
-	filename := filepath.Join(directory, basename+"."+opts.fileSuffix)
-	f, err := os.Create(filename) //nolint:gosec // the file is constructed safely
+	g, err := newPageGenerator(opts, templateName)
 	if err != nil {
 		return "", err
 	}
+	filenames := make([]string, len(pages))
+	errs := make([]error, len(pages))
+
+	genPage := func(i int) {
+		filenames[i], errs[i] = g.writePage(pages[i], directory)
+	}
+
+	if opts.Parallel <= 1 {
+		for i := range pages {
+			if genPage(i); errs[i] != nil && !opts.ContinueOnError {
+				break
+			}
+		}
+	} else {
+		var (
+			wg     sync.WaitGroup
+			failed atomic.Bool
+			next   = make(chan int)
+		)
+		for range min(opts.Parallel, len(pages)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					if genPage(i); errs[i] != nil && !opts.ContinueOnError {
+						failed.Store(true)
+					}
+				}
+			}()
+		}
+		for i := range pages {
+			if failed.Load() {
+				break
+			}
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	var pageErrs []error
+	for i := range pages {
+		if errs[i] != nil {
+			if !opts.ContinueOnError {
+				return "", errs[i]
+			}
+			pageErrs = append(pageErrs, &PageError{CommandPath: pages[i].CommandPath(), Err: errs[i]})
+			continue
+		}
+		if filenames[i] != "" && files != nil {
+			*files = append(*files, filenames[i])
+		}
+	}
+	if len(pageErrs) > 0 {
+		return "", errors.Join(pageErrs...)
+	}
+	// m comes last in pages unless it is filtered out
+	if opts.Filter != nil && !opts.Filter(m.CommandPath()) {
+		return "", nil
+	}
+	return filenames[len(pages)-1], nil
+}
+
+// collectPages appends m and all of its children to pages, with the children
+// of a command before the command itself.
+func collectPages(m CommandModel, pages []CommandModel) []CommandModel {
+	for _, c := range m.Subcommands() {
+		pages = collectPages(c, pages)
+	}
+	return append(pages, m)
+}
+
+// writePage generates the page for m into a file in directory and returns
+// the path of the file.
+func (g *pageGenerator) writePage(m CommandModel, directory string) (string, error) {
+	// Generate file name
+	commandPath := m.CommandPath()
+	if commandPath == "" {
+		return "", ErrMissingCommandName
+	}
+	section := g.opts.sectionOf(m)
+	if g.opts.sectionDirs {
+		directory = filepath.Join(directory, manDir(section))
+		if err := mkdir(directory, g.opts); err != nil {
+			return "", err
+		}
+	}
+	filename := filepath.Join(directory, pageFileName(commandPath, section, g.opts))
+
+	cmd := cobraCommand(m)
+	if g.opts.BeforeCommand != nil {
+		if err := g.opts.BeforeCommand(cmd, filename); err != nil {
+			return "", err
+		}
+	}
+	if err := g.writeFile(m, filename); err != nil {
+		return "", err
+	}
+	if g.opts.AfterCommand != nil {
+		if err := g.opts.AfterCommand(cmd, filename); err != nil {
+			return "", err
+		}
+	}
+	return filename, nil
+}
+
+// writeFile generates the page for m into the file filename.
+func (g *pageGenerator) writeFile(m CommandModel, filename string) (err error) {
+	fsys := g.opts.fileSystem()
+	f, err := fsys.Create(filename)
+	if err != nil {
+		return err
+	}
 	defer func() {
-		err = f.Close()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
 	}()
+	if g.opts.FileMode != 0 {
+		if err := fsys.Chmod(filename, g.opts.FileMode); err != nil {
+			return err
+		}
+	}
 
 	// Generate the documentation
-	err = GenerateOnePage(cmd, opts, templateName, f)
+	return g.generateBuffered(m, f)
+}
+
+// mkdir creates directory, and any missing parents, with Options.DirMode.
+func mkdir(directory string, opts *Options) error {
+	fsys := opts.fileSystem()
+	if err := fsys.MkdirAll(directory, 0o755); err != nil {
+		return err
+	}
+	if opts.DirMode != 0 {
+		return fsys.Chmod(directory, opts.DirMode)
+	}
+	return nil
+}
+
+// generateBuffered is generatePage with the output buffered, as templates
+// write in many small pieces.
+func (g *pageGenerator) generateBuffered(m CommandModel, w io.Writer) error {
+	bw := writerPool.Get().(*bufio.Writer) //nolint:forcetypeassert // the pool only holds these
+	bw.Reset(w)
+	defer func() {
+		bw.Reset(nil)
+		writerPool.Put(bw)
+	}()
+	if err := g.generatePage(m, bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// pageBufferSize is the size of the buffers pages are written with.
+const pageBufferSize = 32 * 1024
+
+// writerPool holds the buffered writers of generateBuffered and bufferPool the
+// buffers pages are rendered to before they are post-processed, so they are
+// reused from page to page.
+var (
+	writerPool = sync.Pool{New: func() any { return bufio.NewWriterSize(nil, pageBufferSize) }}
+	bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+)
+
+// pageFileName returns the name of the file holding the page of the command
+// with the given path, in section.  opts must already have been validated.
+func pageFileName(commandPath string, section string, opts *Options) string {
+	suffix := opts.fileSuffix
+	if opts.suffixIsSection {
+		suffix = section
+	}
+	return strings.ReplaceAll(commandPath, " ", opts.fileCmdSeparator) + "." + suffix
+}
+
+// manDir returns the name of the directory holding the man pages of section:
+// "man" followed by the leading digits of the section, e.g. "man3" for "3pm",
+// or by the whole section if it does not start with a digit, e.g. "mann".
+func manDir(section string) string {
+	end := 0
+	for end < len(section) && section[end] >= '0' && section[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return "man" + section
+	}
+	return "man" + section[:end]
+}
 
-	return filename, err
+// sectionOf returns the section of the page of m, see Options.SectionFunc.
+func (opts *Options) sectionOf(m CommandModel) string {
+	if opts.SectionFunc != nil {
+		if section := opts.SectionFunc(cobraCommand(m)); section != "" {
+			return section
+		}
+	}
+	return opts.Section
 }
 
 // GenerateOnePage will generate one documentation page and output the result to w
 // TODO: document use of this function in README.
-//
-//nolint:funlen,gocognit,cyclop // method is readable
 func GenerateOnePage(cmd *cobra.Command, opts *Options, templateName string, w io.Writer) error {
+	return GenerateModelPage(NewCobraModel(cmd), opts, templateName, w)
+}
+
+// GenerateFlagSetPage generates a page documenting the flags of fs, for tools
+// that do not use cobra but define their flags with pflag.  A FlagSet does not
+// know the name of the program it belongs to, so this is passed as name.
+func GenerateFlagSetPage(name string, fs *pflag.FlagSet, opts *Options, templateName string, w io.Writer) error {
+	return GenerateModelPage(&flagSetModel{name: name, fs: fs}, opts, templateName, w)
+}
+
+// GenerateModelPage is like GenerateOnePage but documents a CommandModel.
+func GenerateModelPage(m CommandModel, opts *Options, templateName string, w io.Writer) error {
 	// Set defaults - these would already be set unless GenerateOnePage called directly
-	validate(opts, templateName)
+	if err := validate(opts, templateName); err != nil {
+		return err
+	}
+
+	g, err := newPageGenerator(opts, templateName)
+	if err != nil {
+		return err
+	}
+	return g.generateBuffered(m, w)
+}
+
+// withProvenance returns content with the comment of Options.Provenance for
+// the page of m added to its top.
+func (g *pageGenerator) withProvenance(m CommandModel, content []byte) []byte {
+	var start, prefix, end string
+	switch {
+	case g.isMan:
+		prefix = `.\" `
+	case g.ext == "md" || g.ext == "html":
+		start, end = "<!--\n", "-->\n"
+	default:
+		return content
+	}
+
+	date := g.opts.Date.Format(time.DateOnly) + " from Options.Date"
+	if g.opts.dateIsNow {
+		date = g.opts.Date.Format(time.DateOnly) + ", the time of generation"
+	}
+	var b bytes.Buffer
+	if g.ext == "md" && bytes.HasPrefix(content, []byte("---\n")) {
+		// front matter must stay at the top
+		if end := bytes.Index(content[4:], []byte("\n---\n")); end >= 0 {
+			b.Write(content[:end+9])
+			content = content[end+9:]
+		}
+	}
+	b.WriteString(start)
+	fmt.Fprintf(&b, "%sGenerated by %s %s for the command %q.\n", prefix, modulePath, moduleVersion(), m.CommandPath())
+	fmt.Fprintf(&b, "%sDated %s.\n", prefix, date)
+	b.WriteString(end)
+	b.Write(content)
+	return b.Bytes()
+}
 
-	values := manStruct{}
+// modulePath is the path of the cobraman module.
+const modulePath = "github.com/carlwr/cobraman"
+
+// moduleVersion returns the version of the cobraman module in the build
+// info of the program, "(devel)" if it is built from a checkout.
+var moduleVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(unknown)"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			if dep.Version == "" {
+				return "(devel)"
+			}
+			return dep.Version
+		}
+	}
+	return "(unknown)"
+})
+
+// pageGenerator generates pages with validated options.  What is the same for
+// all pages, such as the template, is prepared once, which matters when
+// documenting command trees with thousands of commands.  A pageGenerator does
+// not modify its options, so it can generate pages concurrently.
+type pageGenerator struct {
+	opts  *Options
+	cache *docCache
+	tmpl  *template.Template
+	ext   string
+	isMan bool
+	wrap  bool
+	toc   bool
+	// override selects the sections of the pages, see
+	// Options.FormatOverrides
+	override *FormatOverride
+	// continued tells if the page continues a combined page, and so leaves
+	// out what belongs at the top of the whole page, such as the front
+	// matter and table of contents of markdown, see GenerateCombinedPage
+	continued bool
+	// modelMu serializes reading the command model when pages are generated
+	// concurrently, as cobra builds the flag sets of commands lazily
+	modelMu sync.Mutex
+}
+
+// newPageGenerator returns a pageGenerator for options that have already
+// been validated.
+func newPageGenerator(opts *Options, templateName string) (*pageGenerator, error) {
+	_, ext, t, err := templ.GetTemplate(templateName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, templateName, err)
+	}
+	if opts.HyphensAsMinus {
+		if t, err = templ.WithHyphensAsMinus(t); err != nil {
+			return nil, err
+		}
+	}
+	g := &pageGenerator{
+		opts:  opts,
+		cache: newDocCache(),
+		tmpl:  t,
+		ext:   ext,
+		isMan: ext == "use_section",
+		wrap:  opts.LineWidth > 0 || opts.SentencePerLine,
+		toc:   opts.MarkdownTOC && ext == "md",
+	}
+	if opts.HTMLLayout.Template != "" && ext == "html" {
+		var err error
+		if g.tmpl, err = templ.WithLayout(t, opts.HTMLLayout.Template); err != nil {
+			return nil, fmt.Errorf("HTML layout: %w", err)
+		}
+	}
+	if o, ok := opts.FormatOverrides[templateName]; ok {
+		g.override = &o
+	}
+	return g, nil
+}
+
+// pageData returns the data of the page for m, and the warnings about it if
+// they are collected, see Options.Strict.
+func (g *pageGenerator) pageData(m CommandModel) (*DocData, []Warning, error) {
+	g.modelMu.Lock()
+	defer g.modelMu.Unlock()
+	values, err := buildDocData(m, g.opts, g.cache)
+	if err != nil {
+		return nil, nil, err
+	}
+	var warnings []Warning
+	if g.opts.warnings != nil || g.opts.Strict {
+		warnings = checkPage(m, values, g.opts)
+	}
+	return values, warnings, nil
+}
+
+// generatePage generates the page for m to w.
+func (g *pageGenerator) generatePage(m CommandModel, w io.Writer) error {
+	opts := g.opts
+	values, warnings, err := g.pageData(m)
+	if err != nil {
+		return err
+	}
+	if opts.Strict && len(warnings) > 0 {
+		return incompleteError(warnings)
+	}
+	if opts.warnings != nil {
+		opts.warnings.add(warnings)
+	}
+	if g.continued {
+		values.Metadata = nil
+	}
+	values.formatOverride = g.override
+	values.pageOpts = opts
+	if g.ext != "md" {
+		for _, field := range values.markdownFields {
+			*field = templ.MarkdownToText(*field)
+		}
+	}
+
+	toc := g.toc && !g.continued
+	provenance := opts.Provenance && !g.continued
+	if opts.PostProcess == nil && !g.wrap && !toc && !provenance && (!g.isMan || opts.Encoding == "" && !opts.Typography) {
+		return g.tmpl.Execute(w, values)
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer) //nolint:forcetypeassert // the pool only holds these
+	buf.Reset()
+	defer bufferPool.Put(buf)
+	if err := g.tmpl.Execute(buf, values); err != nil {
+		return err
+	}
+	content := buf.Bytes()
+	if toc {
+		content = []byte(templ.MarkdownTOC(string(content)))
+	}
+	if g.wrap {
+		switch {
+		case g.isMan:
+			content = []byte(templ.WrapRoff(string(content), opts.LineWidth, opts.SentencePerLine))
+		case g.ext == "md":
+			content = []byte(templ.WrapMarkdown(string(content), opts.LineWidth, opts.SentencePerLine))
+		}
+	}
+	if provenance {
+		content = g.withProvenance(m, content)
+	}
+	if g.isMan {
+		if opts.Typography {
+			content = []byte(typographyReplacer.Replace(string(content)))
+		}
+		if content, err = encodeManPage(content, opts.Encoding); err != nil {
+			return err
+		}
+	}
+	if opts.PostProcess != nil {
+		if content, err = opts.PostProcess(values.CobraCmd, content); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// BuildDocData collects the data that a documentation template for cmd is
+// executed with, including the result of Options.PrepareData.  It is useful
+// for running other templates or renderers on the same data as cobraman.
+func BuildDocData(cmd *cobra.Command, opts *Options) (*DocData, error) {
+	return BuildModelDocData(NewCobraModel(cmd), opts)
+}
+
+// BuildModelDocData is like BuildDocData but documents a CommandModel.
+func BuildModelDocData(m CommandModel, opts *Options) (*DocData, error) {
+	setDefaults(opts)
+	if err := checkOptions(opts); err != nil {
+		return nil, err
+	}
+	return buildDocData(m, opts, nil)
+}
+
+// noArgs reports whether m is documented as accepting no positional
+// arguments, see Options.NoArgsFunc.
+func noArgs(m CommandModel, opts *Options) bool {
+	if opts.NoArgsFunc != nil {
+		if noArgs, ok := opts.NoArgsFunc(m.CommandPath()); ok {
+			return noArgs
+		}
+	}
+	if noArgs, ok := argsAnnotation(m.Annotations()); ok {
+		return noArgs
+	}
+	return m.NoArgs()
+}
+
+// noArgsModel applies Options.NoArgsFunc to the subcommands passed to the
+// templates.
+type noArgsModel struct {
+	CommandModel
+	opts *Options
+}
+
+func (m noArgsModel) NoArgs() bool { return noArgs(m.CommandModel, m.opts) }
+
+//nolint:funlen,gocognit,cyclop // method is readable
+func buildDocData(m CommandModel, opts *Options, cache *docCache) (*DocData, error) {
+	values := &DocData{}
+	if opts.IncludeHidden {
+		m = withHidden(m)
+	}
 
 	// Header fields
 	values.LeftFooter = opts.LeftFooter
 	values.CenterHeader = opts.CenterHeader
-	values.Section = opts.Section
+	values.Section = opts.sectionOf(m)
 	values.Date = opts.Date
+	values.FormattedDate = formatDate(*opts.Date, "January 2006", opts)
 	values.CenterFooter = opts.CenterFooter
 	if opts.CenterFooter == "" {
 		// TODO: should this be part of template instead?
-		values.CenterFooter = values.Date.Format("Jan 2006")
+		values.CenterFooter = formatDate(*opts.Date, "Jan 2006", opts)
 	}
 
-	values.CobraCmd = cmd
-	values.ShortDescription = cmd.Short
-	values.UseLine = cmd.UseLine()
-	values.CommandPath = cmd.CommandPath()
-
-	// Use reflection to see if cobra.NoArgs was set
-	argFuncName := runtime.FuncForPC(reflect.ValueOf(cmd.Args).Pointer()).Name()
-	values.NoArgs = strings.HasSuffix(argFuncName, "cobra.NoArgs")
+	values.CobraCmd = cobraCommand(m)
+	values.ShortDescription = translate(m.Short(), opts)
+	values.UseLine = m.UseLine()
+	values.UseLineSynopsis = opts.Synopsis == SynopsisUseLine
+	values.CommandPath = m.CommandPath()
+	values.NoArgs = noArgs(m, opts)
 
-	if cmd.HasSubCommands() {
-		subCmdArr := make([]*cobra.Command, 0, len(cmd.Commands()))
-		for _, c := range cmd.Commands() {
-			if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
-				continue
+	subCmds := m.Subcommands()
+	if len(subCmds) > 0 {
+		if opts.NoArgsFunc != nil {
+			for i, c := range subCmds {
+				subCmds[i] = noArgsModel{c, opts}
 			}
-			subCmdArr = append(subCmdArr, c)
 		}
-		values.SubCommands = subCmdArr
+		values.SubCommands = subCmds
+		values.CommandGroups = commandGroups(m, subCmds)
 	}
 
 	// DESCRIPTION
-	description := cmd.Long
+	description := m.Long()
 	if description == "" {
-		description = cmd.Short
+		description = m.Short()
 	}
-	values.Description = description
+	values.Description = translate(description, opts)
 
 	// Flag arrays
-	values.AllFlags = genFlagArray(cmd.Flags())
-	values.InheritedFlags = genFlagArray(cmd.InheritedFlags())
-	values.NonInheritedFlags = genFlagArray(cmd.NonInheritedFlags())
+	values.AllFlags, values.InheritedFlags, values.NonInheritedFlags = genFlagArrays(m, opts, cache)
+	if opts.CollapseInheritedFlags && len(values.InheritedFlags) > 0 && m.Parent() != nil {
+		values.AllFlags = values.NonInheritedFlags
+		values.InheritedFlagsFrom = m.Parent().CommandPath()
+		values.InheritedFlagsSection = opts.sectionOf(m.Parent())
+	}
+	values.EnvFlags = envFlags(values.AllFlags)
+	values.FlagGroups = flagGroups(values.AllFlags)
+	if opts.IncludeDeprecated {
+		values.DeprecatedFlags = deprecatedFlags(m, opts)
+	}
+
+	annotations := m.Annotations()
+	values.Annotations = annotations
 
 	// ENVIRONMENT section
-	altEnvironmentSection := cmd.Annotations["man-environment-section"]
+	altEnvironmentSection := sectionAnnotation(annotations, "man-environment-section", opts)
 	if opts.Environment != "" || altEnvironmentSection != "" {
 		if altEnvironmentSection != "" {
 			values.Environment = altEnvironmentSection
@@ -205,7 +1262,7 @@ func GenerateOnePage(cmd *cobra.Command, opts *Options, templateName string, w i
 	}
 
 	// FILES section
-	altFilesSection := cmd.Annotations["man-files-section"]
+	altFilesSection := sectionAnnotation(annotations, "man-files-section", opts)
 	if opts.Files != "" || altFilesSection != "" {
 		if altFilesSection != "" {
 			values.Files = altFilesSection
@@ -214,8 +1271,15 @@ func GenerateOnePage(cmd *cobra.Command, opts *Options, templateName string, w i
 		}
 	}
 
+	// EXIT STATUS section
+	values.ExitStatus = opts.ExitStatus
+	if altExitStatusSection := sectionAnnotation(annotations, "man-exit-status-section", opts); altExitStatusSection != "" {
+		values.ExitStatus = altExitStatusSection
+	}
+	values.BSDStyle = opts.ManStyle == ManStyleBSD
+
 	// BUGS section
-	altBugsSection := cmd.Annotations["man-bugs-section"]
+	altBugsSection := sectionAnnotation(annotations, "man-bugs-section", opts)
 	if opts.Bugs != "" || altBugsSection != "" {
 		if altBugsSection != "" {
 			values.Bugs = altBugsSection
@@ -225,44 +1289,157 @@ func GenerateOnePage(cmd *cobra.Command, opts *Options, templateName string, w i
 	}
 
 	// EXAMPLES section
-	altExampleSection := cmd.Annotations["man-examples-section"]
-	if cmd.Example != "" || altExampleSection != "" {
+	altExampleSection := sectionAnnotation(annotations, "man-examples-section", opts)
+	if m.Example() != "" || altExampleSection != "" {
 		if altExampleSection != "" {
 			values.Examples = altExampleSection
 		} else {
-			values.Examples = cmd.Example
+			values.Examples = m.Example()
 		}
 	}
 
+	values.EnvironmentEntries = opts.EnvironmentEntries
+	values.FilesEntries = opts.FilesEntries
+
+	// HISTORY section
+	values.History = historyFor(values.CommandPath, opts.History)
+
+	if err := loadDocFiles(values, annotations, opts); err != nil {
+		return nil, err
+	}
+
 	// AUTHOR section
 	values.Author = opts.Author
 
+	// Sections of the registered providers
+	values.CustomSections = customSections(values.CobraCmd)
+
 	// SEE ALSO section
-	values.SeeAlsos = generateSeeAlsos(cmd, values.Section)
+	values.SeeAlsos = generateSeeAlsos(m, values.CommandGroups, subCmds, opts.sectionOf, opts.SeeAlsoPolicy, cache)
+	values.SeeAlsos = append(values.SeeAlsos, suiteSeeAlsos(m, opts)...)
 
 	// Custom Data
 	values.CustomData = opts.CustomData
+	if opts.CustomDataFunc != nil {
+		values.CustomData = make(map[string]interface{}, len(opts.CustomData))
+		for k, v := range opts.CustomData {
+			values.CustomData[k] = v
+		}
+		for k, v := range opts.CustomDataFunc(values.CobraCmd) {
+			values.CustomData[k] = v
+		}
+	}
 
-	// Get template and generate the documentation page
-	_, _, t := templ.GetTemplate(templateName)
+	// Metadata
+	values.Metadata = opts.Metadata
+	if opts.MetadataFunc != nil {
+		values.Metadata = make(map[string]interface{}, len(opts.Metadata))
+		for k, v := range opts.Metadata {
+			values.Metadata[k] = v
+		}
+		for k, v := range opts.MetadataFunc(values.CobraCmd) {
+			values.Metadata[k] = v
+		}
+	}
 
-	err := t.Execute(w, values)
-	if err != nil {
-		return err
+	values.HTMLLayout = opts.HTMLLayout
+
+	if opts.ExpandAnnotations {
+		if err := expandAnnotations(values, annotations, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	applyDocOverride(values, opts.DocOverrides[values.CommandPath])
+	if opts.ExampleTitles {
+		values.ExampleGroups = exampleGroups(values.Examples)
+	}
+
+	if opts.PrepareData != nil {
+		if err := opts.PrepareData(values.CobraCmd, values); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// sectionAnnotation returns the annotation of a command with the content of
+// a section, taking Options.AnnotationPrefix into account.
+func sectionAnnotation(annotations map[string]string, name string, opts *Options) string {
+	if opts.AnnotationPrefix != "" {
+		if text := annotations[opts.AnnotationPrefix+name]; text != "" {
+			return text
+		}
+	}
+	return annotations[name]
+}
+
+// expandAnnotations expands the sections of values that were taken from
+// annotations as templates with values, see Options.ExpandAnnotations.
+func expandAnnotations(values *DocData, annotations map[string]string, opts *Options) error {
+	sections := []struct {
+		annotation string
+		value      *string
+	}{
+		{"man-environment-section", &values.Environment},
+		{"man-files-section", &values.Files},
+		{"man-bugs-section", &values.Bugs},
+		{"man-exit-status-section", &values.ExitStatus},
+		{"man-examples-section", &values.Examples},
+	}
+	for _, section := range sections {
+		text := sectionAnnotation(annotations, section.annotation, opts)
+		if text == "" {
+			continue
+		}
+		expanded, err := templ.ExpandText(section.annotation, text, values)
+		if err != nil {
+			return fmt.Errorf("%w %s of %q: %w", ErrAnnotationTemplate, section.annotation, values.CommandPath, err)
+		}
+		*section.value = expanded
 	}
 	return nil
 }
 
-func validate(opts *Options, templateName string) {
+// formatDate formats date with layout, unless Options.ISODate or
+// Options.MonthNames ask for a date without English month names.
+func formatDate(date time.Time, layout string, opts *Options) string {
+	switch {
+	case opts.ISODate:
+		return date.Format("2006-01-02")
+	case opts.MonthNames != nil:
+		return opts.MonthNames[date.Month()-1] + " " + strconv.Itoa(date.Year())
+	}
+	return date.Format(layout)
+}
+
+// now returns the current time of Options.Now.
+func (opts *Options) now() time.Time {
+	if opts.Now != nil {
+		return opts.Now()
+	}
+	return time.Now()
+}
+
+func setDefaults(opts *Options) {
 	if opts.Section == "" {
 		opts.Section = "1"
 	}
 	if opts.Date == nil {
-		now := time.Now()
+		now := opts.now()
 		opts.Date = &now
+		opts.dateIsNow = true
 	}
+}
+
+func validate(opts *Options, templateName string) error {
+	setDefaults(opts)
 
-	sep, ext, t := templ.GetTemplate(templateName)
+	sep, ext, t, err := templ.GetTemplate(templateName)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, templateName, err)
+	}
 	if t == nil {
 		panic("template could not be found: " + templateName)
 	}
@@ -270,116 +1447,582 @@ func validate(opts *Options, templateName string) {
 	opts.fileSuffix = ext
 	if ext == "use_section" {
 		opts.fileSuffix = opts.Section
+		opts.suffixIsSection = true
 	}
+	return checkOptions(opts)
 }
 
-type manStruct struct {
-	Date             *time.Time
-	Section          string
-	CenterFooter     string
-	LeftFooter       string
-	CenterHeader     string
+// checkOptions returns an error wrapping ErrInvalidOptions if opts are not
+// valid.
+func checkOptions(opts *Options) error {
+	if opts.MonthNames != nil && len(opts.MonthNames) != 12 {
+		return fmt.Errorf("%w: MonthNames must hold the names of 12 months, not %d", ErrInvalidOptions, len(opts.MonthNames))
+	}
+	for name, o := range opts.FormatOverrides {
+		for _, section := range slices.Concat(o.Include, o.Exclude) {
+			if !slices.Contains(optionalSections, section) && !isCustomSection(section) {
+				return fmt.Errorf("%w: FormatOverrides[%q] names an unknown section: %s", ErrInvalidOptions, name, section)
+			}
+		}
+	}
+	return nil
+}
+
+// DocData is the data a documentation template is executed with.  See
+// docs/writing-a-template.md for a description of its fields.
+type DocData struct {
+	Date          *time.Time
+	FormattedDate string
+	Section       string
+	CenterFooter  string
+	LeftFooter    string
+	CenterHeader  string
+	// BSDStyle is set for pages following the conventions of ManStyleBSD
+	BSDStyle         bool
 	UseLine          string
+	UseLineSynopsis  bool
 	CommandPath      string
 	ShortDescription string
 	Description      string
 	NoArgs           bool
 
-	AllFlags          []manFlag
-	InheritedFlags    []manFlag
-	NonInheritedFlags []manFlag
-	SeeAlsos          []seeAlso
-	SubCommands       []*cobra.Command
+	AllFlags          []Flag
+	InheritedFlags    []Flag
+	NonInheritedFlags []Flag
+	EnvFlags          []Flag
+	DeprecatedFlags   []Flag
+	FlagGroups        []FlagGroup
+	// InheritedFlagsFrom is the command path of the parent whose page the
+	// inherited flags are left to, see Options.CollapseInheritedFlags, and
+	// InheritedFlagsSection is the section of that page
+	InheritedFlagsFrom    string
+	InheritedFlagsSection string
+	SeeAlsos              []SeeAlso
+	SubCommands           []CommandModel
+	CommandGroups         []CommandGroup
 
 	Author      string
 	Environment string
 	Files       string
 	Bugs        string
+	ExitStatus  string
 	Examples    string
+	// ExampleGroups are the titled blocks of Examples, see
+	// Options.ExampleTitles
+	ExampleGroups []ExampleGroup
+	History       []HistoryEntry
+
+	EnvironmentEntries []SectionEntry
+	FilesEntries       []SectionEntry
+	// CustomSections are the sections of RegisterSectionProvider
+	CustomSections []CustomSection
+
+	Annotations map[string]string
 
 	CobraCmd *cobra.Command
 
 	CustomData map[string]interface{}
+	// Metadata is the front matter of markdown pages, see Options.Metadata
+	Metadata map[string]interface{}
+	// HTMLLayout is the layout of HTML pages, see Options.HTMLLayout
+	HTMLLayout HTMLLayout
+
+	// markdownFields are the fields loaded from markdown files, which are
+	// converted for the format of the page
+	markdownFields []*string
+	// formatOverride selects the sections of the page
+	formatOverride *FormatOverride
+	// pageOpts are the validated options the page is generated with, for
+	// PageFile
+	pageOpts *Options
+}
+
+// Includes reports whether the optional section of the page, named by its
+// man page heading (e.g. "SEE ALSO"), is to be included, see
+// Options.FormatOverrides.
+func (d *DocData) Includes(section string) bool {
+	return d.formatOverride.includes(section)
+}
+
+// PageFile returns the name of the file of the page of the command with the
+// given path generated along with the page, e.g. "tool_sub.de.md" for a
+// markdown page of Options.Locales.  It returns "" without the options of a
+// generated page, as for BuildDocData, leaving the name to the template.
+func (d *DocData) PageFile(commandPath string) string {
+	if d.pageOpts == nil {
+		return ""
+	}
+	return pageFileName(commandPath, d.Section, d.pageOpts)
+}
+
+// ExitStatusHeading returns the man page heading of the EXIT STATUS section
+// of the page, which is named after return values in the library sections 2,
+// 3 and 9: RETURN VALUES for ManStyleBSD and RETURN VALUE otherwise.
+func (d *DocData) ExitStatusHeading() string {
+	switch dir := manDir(d.Section); {
+	case dir != "man2" && dir != "man3" && dir != "man9":
+		return "EXIT STATUS"
+	case d.BSDStyle:
+		return "RETURN VALUES"
+	}
+	return "RETURN VALUE"
+}
+
+// Heading returns the text of a heading of the page in the case of
+// FormatOverride.HeadingCase.
+func (d *DocData) Heading(text string) string {
+	if d.formatOverride == nil {
+		return text
+	}
+	return d.formatOverride.HeadingCase.format(text)
+}
+
+// HTMLLayout is the layout HTML pages are wrapped in.  By default each page
+// is a standalone document; Stylesheet, Header and Footer adjust it, while
+// Template replaces it altogether.
+type HTMLLayout struct {
+	// Stylesheet, if set, is the href of a stylesheet linked from the head
+	// of each page.
+	Stylesheet string
+	// Header and Footer are raw HTML, such as a site's navigation bar,
+	// written at the start and the end of the body of each page.
+	Header string
+	Footer string
+	// Template, if set, is a text/template executed with the DocData of
+	// each page to produce the whole document; {{ template "body" . }}
+	// renders the generated content.  Its output is not escaped.
+	Template string
 }
 
-type manFlag struct {
+// Flag describes one flag in the flag arrays of DocData.
+type Flag struct {
 	Shorthand   string
 	Name        string
+	Inherited   bool
+	Type        string
 	NoOptDefVal string
 	DefValue    string
+	Default     string
 	Usage       string
 	ArgHint     string
+	ValueName   string
+	Repeatable  bool
+	Env         string
+	Deprecated  string
+	ReplacedBy  string
+	Group       string
+	Unit        string
+}
+
+// ExampleGroup is a titled block of examples in DocData.ExampleGroups.
+type ExampleGroup struct {
+	Title    string
+	Examples string
+}
+
+// blankLinesRegex matches the blank lines separating blocks of text.
+var blankLinesRegex = regexp.MustCompile(`\n(?:[ \t]*\n)+`)
+
+// exampleGroups splits examples into blocks separated by blank lines, each
+// titled by the comment lines it starts with, or returns nil if some block
+// has no such title or no examples.
+func exampleGroups(examples string) []ExampleGroup {
+	var groups []ExampleGroup
+	for _, block := range blankLinesRegex.Split(strings.TrimSpace(examples), -1) {
+		lines := strings.Split(block, "\n")
+		var title []string
+		for len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "#") {
+			title = append(title, strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(lines[0]), "#")))
+			lines = lines[1:]
+		}
+		if len(title) == 0 || len(lines) == 0 {
+			return nil
+		}
+		groups = append(groups, ExampleGroup{
+			Title:    strings.TrimRight(strings.Join(title, " "), ":"),
+			Examples: strings.Join(lines, "\n"),
+		})
+	}
+	return groups
+}
+
+// SectionEntry is an item of the ENVIRONMENT or FILES section, see
+// Options.EnvironmentEntries.
+type SectionEntry struct {
+	// Name is the name of the environment variable or the path of the file
+	Name        string
+	Description string
+}
+
+// FlagGroup is a group of flags in DocData.FlagGroups, as named by the
+// man-flag-group annotation of the flags.
+type FlagGroup struct {
+	// Title is the title of the group, e.g. "Output options".  It is empty
+	// for the flags that are not in a group, which come first.
+	Title string
+	Flags []Flag
 }
 
-type seeAlso struct {
+// SeeAlso describes one related command in DocData.SeeAlsos.
+type SeeAlso struct {
 	CmdPath   string
 	Section   string
 	IsParent  bool
 	IsChild   bool
 	IsSibling bool
+	// InSuite is set for the other root commands generated by GenerateSuite
+	InSuite bool
+	// Group is the title of the group of a child or sibling, see
+	// CommandGroup
+	Group string
 }
 
-func genFlagArray(flags *pflag.FlagSet) []manFlag {
-	flagArray := make([]manFlag, 0, 15)
-	flags.VisitAll(
-		func(flag *pflag.Flag) {
-			if len(flag.Deprecated) > 0 || flag.Hidden {
-				return
-			}
-			thisFlag := manFlag{
-				Name:        flag.Name,
-				NoOptDefVal: flag.NoOptDefVal,
-				DefValue:    flag.DefValue,
-				Usage:       flag.Usage,
+// CommandGroup is a group of subcommands in DocData.CommandGroups, such as
+// the commands of a cobra command group or of a CommandSpec category.
+type CommandGroup struct {
+	// Title is the title of the group, e.g. "Management Commands".  It is
+	// empty for the commands that are not in a group if they come first.
+	Title    string
+	Commands []CommandModel
+}
+
+// genFlagArrays returns the documented flags of m: all of them, the ones it
+// inherits from its parents and the others.  They are collected in a single
+// visit of the flags, and the inherited and other flags share one array.
+//
+// Unless Options.MergePersistentFlags is set, all is what Flags() holds
+// before the persistent flags are merged below, see there.
+func genFlagArrays(m CommandModel, opts *Options, cache *docCache) (all, inherited, nonInherited []Flag) {
+	var merged map[*pflag.Flag]bool
+	if !opts.MergePersistentFlags && !opts.CollapseInheritedFlags {
+		merged = make(map[*pflag.Flag]bool)
+		m.Flags().VisitAll(func(flag *pflag.Flag) { merged[flag] = true })
+	}
+	// for cobra, this also adds the flags inherited from the parents to Flags()
+	local := m.NonInheritedFlags()
+	flags := m.Flags()
+
+	n := 0
+	flags.VisitAll(func(*pflag.Flag) { n++ })
+	documented := make([]Flag, 0, n)
+	all = make([]Flag, 0, n)
+	nInherited := 0
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if thisFlag, ok := cache.flag(flag, opts); ok {
+			thisFlag.Inherited = local.Lookup(flag.Name) != flag
+			if thisFlag.Inherited {
+				nInherited++
 			}
-			if flag.ShorthandDeprecated == "" {
-				thisFlag.Shorthand = flag.Shorthand
+			documented = append(documented, thisFlag)
+			if merged == nil || merged[flag] {
+				all = append(all, thisFlag)
 			}
-			hintArr, exists := flag.Annotations["man-arg-hints"]
-			if exists && len(hintArr) > 0 {
-				thisFlag.ArgHint = hintArr[0]
+		}
+	})
+
+	parts := make([]Flag, len(documented))
+	nonInherited, inherited = parts[:0:len(documented)-nInherited], parts[len(documented)-nInherited:len(documented)-nInherited]
+	for _, flag := range documented {
+		if flag.Inherited {
+			inherited = append(inherited, flag)
+		} else {
+			nonInherited = append(nonInherited, flag)
+		}
+	}
+	return all, inherited, nonInherited
+}
+
+// flagGroups returns flags grouped by their man-flag-group annotation, in
+// the order of the first flag of each group, or nil if none is in a group.
+func flagGroups(flags []Flag) []FlagGroup {
+	if !slices.ContainsFunc(flags, func(f Flag) bool { return f.Group != "" }) {
+		return nil
+	}
+	groups := []FlagGroup{{}}
+	for _, flag := range flags {
+		i := slices.IndexFunc(groups, func(g FlagGroup) bool { return g.Title == flag.Group })
+		if i < 0 {
+			i = len(groups)
+			groups = append(groups, FlagGroup{Title: flag.Group})
+		}
+		groups[i].Flags = append(groups[i].Flags, flag)
+	}
+	if len(groups[0].Flags) == 0 {
+		groups = groups[1:]
+	}
+	return groups
+}
+
+// envFlags returns the flags that are backed by an environment variable,
+// one for each variable.
+func envFlags(flags []Flag) []Flag {
+	var envFlags []Flag
+	for _, flag := range flags {
+		if flag.Env != "" && !slices.ContainsFunc(envFlags, func(f Flag) bool { return f.Env == flag.Env }) {
+			envFlags = append(envFlags, flag)
+		}
+	}
+	return envFlags
+}
+
+// convertFlag returns the Flag documenting flag, and false if flag is not
+// documented.
+func convertFlag(flag *pflag.Flag, opts *Options) (Flag, bool) {
+	// pflag warns about a deprecated flag whether it is given by its name or
+	// its shorthand, so neither is documented; a deprecated shorthand alone
+	// leaves the flag documented by its name, see newFlag
+	if len(flag.Deprecated) > 0 || flag.Hidden && !opts.IncludeHidden {
+		return Flag{}, false
+	}
+	return newFlag(flag, opts), true
+}
+
+// deprecatedFlags returns the deprecated flags of m, see
+// Options.IncludeDeprecated.  As pflag warns about a deprecated flag given by
+// its shorthand too, the shorthand is listed along with the name.
+func deprecatedFlags(m CommandModel, opts *Options) []Flag {
+	var deprecated []Flag
+	m.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Deprecated == "" {
+			return
+		}
+		thisFlag := newFlag(flag, opts)
+		thisFlag.Deprecated = translate(flag.Deprecated, opts)
+		thisFlag.Shorthand = flag.Shorthand
+		for _, match := range flagNameRegex.FindAllStringSubmatch(flag.Deprecated, -1) {
+			if match[1] != flag.Name {
+				thisFlag.ReplacedBy = match[1]
+				break
 			}
-			flagArray = append(flagArray, thisFlag)
-		},
-	)
+		}
+		deprecated = append(deprecated, thisFlag)
+	})
+	return deprecated
+}
+
+// flagNameRegex matches the long flags named in text, such as a deprecation
+// message.
+var flagNameRegex = regexp.MustCompile(`(?:^|[^\w-])--([a-zA-Z0-9](?:[\w-]*\w)?)`)
+
+// newFlag returns the Flag documenting flag, without its shorthand if that
+// is deprecated.
+func newFlag(flag *pflag.Flag, opts *Options) Flag {
+	thisFlag := Flag{
+		Name:        flag.Name,
+		Type:        flag.Value.Type(),
+		NoOptDefVal: flag.NoOptDefVal,
+		DefValue:    flag.DefValue,
+		Usage:       translate(flag.Usage, opts),
+	}
+	if !isZeroDefault(flag) {
+		thisFlag.Default = flag.DefValue
+	}
+	if flag.ShorthandDeprecated == "" {
+		thisFlag.Shorthand = flag.Shorthand
+	}
+	hintArr, exists := flag.Annotations["man-arg-hints"]
+	if exists && len(hintArr) > 0 {
+		thisFlag.ArgHint = hintArr[0]
+	}
+	thisFlag.ValueName, thisFlag.Repeatable = valueName(thisFlag.Type)
+	// cobra's convention: a name in backquotes in the usage, as in
+	// "read from `FILE`", is the placeholder for the value
+	translated := *flag
+	translated.Usage = thisFlag.Usage
+	if name, usage := pflag.UnquoteUsage(&translated); usage != thisFlag.Usage {
+		thisFlag.ValueName, thisFlag.Usage = name, usage
+	}
+	if thisFlag.ArgHint != "" {
+		thisFlag.ValueName = thisFlag.ArgHint
+	}
+	if unit := flag.Annotations["man-default-unit"]; len(unit) > 0 {
+		thisFlag.Unit = unit[0]
+	}
+	if group := flag.Annotations["man-flag-group"]; len(group) > 0 {
+		thisFlag.Group = translate(group[0], opts)
+	}
+	if env := flag.Annotations["man-flag-env"]; len(env) > 0 {
+		thisFlag.Env = env[0]
+	} else if opts.FlagEnv != nil {
+		thisFlag.Env = opts.FlagEnv(thisFlag)
+	}
+	if opts.RedactDefault != nil {
+		thisFlag.DefValue = opts.RedactDefault(thisFlag, thisFlag.DefValue)
+		if thisFlag.Default != "" {
+			thisFlag.Default = thisFlag.DefValue
+		}
+	}
+	if opts.FormatDefault != nil {
+		thisFlag.Default = opts.FormatDefault(thisFlag)
+	}
+	return thisFlag
+}
+
+// translate returns text in the language of Options.Locale, see
+// Options.Translate.
+func translate(text string, opts *Options) string {
+	if opts.Translate == nil || text == "" {
+		return text
+	}
+	return opts.Translate(opts.Locale, text)
+}
 
-	return flagArray
+// valueName returns the placeholder for the value of a flag of the pflag
+// type typ, and whether the flag may be given more than once: the element type
+// of slices and arrays, e.g. "string" for "stringSlice", "key=value" for maps
+// such as "stringToString", and the type itself otherwise.  Count flags take
+// no value but are repeated.
+func valueName(typ string) (name string, repeatable bool) {
+	switch {
+	case typ == "count":
+		return "", true
+	case strings.HasSuffix(typ, "Slice"):
+		return strings.TrimSuffix(typ, "Slice"), true
+	case strings.HasSuffix(typ, "Array"):
+		return strings.TrimSuffix(typ, "Array"), true
+	case typ == "stringToString":
+		return "key=value", true
+	case strings.HasPrefix(typ, "stringTo"):
+		return "key=" + strings.ToLower(typ[len("stringTo"):len("stringTo")+1]) + typ[len("stringTo")+1:], true
+	default:
+		return typ, false
+	}
 }
 
-func generateSeeAlsos(cmd *cobra.Command, section string) []seeAlso {
-	seealsos := make([]seeAlso, 0)
-	if cmd.HasParent() {
-		see := seeAlso{
-			CmdPath:  cmd.Parent().CommandPath(),
-			Section:  section,
+// isZeroDefault reports whether the default of flag is the zero value of its
+// type, such as false for a bool flag or [] for a slice, which is not worth
+// mentioning.
+func isZeroDefault(flag *pflag.Flag) bool {
+	typ := flag.Value.Type()
+	switch {
+	case flag.DefValue == "":
+		return true
+	case typ == "string":
+		return false
+	case typ == "bool":
+		return flag.DefValue == "false"
+	case typ == "duration":
+		return flag.DefValue == "0s"
+	case strings.HasSuffix(typ, "Slice") || strings.HasSuffix(typ, "Array") || strings.HasPrefix(typ, "stringTo"):
+		return flag.DefValue == "[]" || flag.DefValue == "{}"
+	case strings.HasPrefix(typ, "ip"):
+		return flag.DefValue == "<nil>"
+	default:
+		return flag.DefValue == "0"
+	}
+}
+
+// generateSeeAlsos returns the parent, siblings and children of m, given
+// its subcommands as returned by m.Subcommands() and their groups, if any,
+// as far as policy includes them.  Children and siblings are ordered by group.
+func generateSeeAlsos(m CommandModel, groups []CommandGroup, subCmds []CommandModel, sectionOf func(CommandModel) string, policy SeeAlsoPolicy, cache *docCache) []SeeAlso {
+	if policy == SeeAlsoNone {
+		return nil
+	}
+	seealsos := make([]SeeAlso, 0, 1+len(subCmds))
+	if parent := m.Parent(); parent != nil {
+		see := SeeAlso{
+			CmdPath:  parent.CommandPath(),
+			Section:  sectionOf(parent),
 			IsParent: true,
 		}
 		seealsos = append(seealsos, see)
-		siblings := cmd.Parent().Commands()
-		for _, c := range siblings {
-			if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() || c.Name() == cmd.Name() {
-				continue
+		if policy == SeeAlsoAll {
+			commandPath := m.CommandPath()
+			for _, see := range cache.siblings(parent, see.CmdPath, sectionOf) {
+				if see.CmdPath != commandPath {
+					seealsos = append(seealsos, see)
+				}
 			}
-			see := seeAlso{
-				CmdPath:   c.CommandPath(),
-				Section:   section,
-				IsSibling: true,
+		}
+	}
+	if policy == SeeAlsoParent {
+		return seealsos
+	}
+	if groups == nil {
+		groups = []CommandGroup{{Commands: subCmds}}
+	}
+	for _, group := range groups {
+		for _, c := range group.Commands {
+			see := SeeAlso{
+				CmdPath: c.CommandPath(),
+				Section: sectionOf(c),
+				IsChild: true,
+				Group:   group.Title,
 			}
 			seealsos = append(seealsos, see)
 		}
 	}
-	children := cmd.Commands()
-	for _, c := range children {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
-			continue
+
+	return seealsos
+}
+
+// docCache memoizes what the pages of a GenerateDocs run have in common, so
+// it is computed once rather than for every page: the sibling entries of the
+// SEE ALSO sections, shared by all children of a command, and the documented
+// flags, most of which are inherited by many commands.  It is safe for
+// concurrent use, and a nil *docCache computes everything every time.
+type docCache struct {
+	mu              sync.Mutex
+	siblingSeeAlsos map[string][]SeeAlso // keyed by the command path of the parent
+	flags           map[*pflag.Flag]cachedFlag
+}
+
+type cachedFlag struct {
+	flag Flag
+	ok   bool
+}
+
+func newDocCache() *docCache {
+	return &docCache{
+		siblingSeeAlsos: make(map[string][]SeeAlso),
+		flags:           make(map[*pflag.Flag]cachedFlag),
+	}
+}
+
+// siblings returns a SeeAlso with IsSibling set for every subcommand of
+// parent, whose command path is parentPath.
+func (c *docCache) siblings(parent CommandModel, parentPath string, sectionOf func(CommandModel) string) []SeeAlso {
+	if c != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if seealsos, ok := c.siblingSeeAlsos[parentPath]; ok {
+			return seealsos
 		}
-		see := seeAlso{
-			CmdPath: c.CommandPath(),
-			Section: section,
-			IsChild: true,
+	}
+	children := parent.Subcommands()
+	groups := commandGroups(parent, children)
+	if groups == nil {
+		groups = []CommandGroup{{Commands: children}}
+	}
+	seealsos := make([]SeeAlso, 0, len(children))
+	for _, group := range groups {
+		for _, child := range group.Commands {
+			seealsos = append(seealsos, SeeAlso{
+				CmdPath:   child.CommandPath(),
+				Section:   sectionOf(child),
+				IsSibling: true,
+				Group:     group.Title,
+			})
 		}
-		seealsos = append(seealsos, see)
 	}
-
+	if c != nil {
+		c.siblingSeeAlsos[parentPath] = seealsos
+	}
 	return seealsos
 }
+
+// flag is convertFlag, memoized.
+func (c *docCache) flag(flag *pflag.Flag, opts *Options) (Flag, bool) {
+	if c == nil {
+		return convertFlag(flag, opts)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.flags[flag]
+	if !ok {
+		cached.flag, cached.ok = convertFlag(flag, opts)
+		c.flags[flag] = cached
+	}
+	return cached.flag, cached.ok
+}
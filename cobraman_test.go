@@ -15,19 +15,23 @@ package cobraman_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/carlwr/cobraman"
 	"github.com/carlwr/cobraman/internal/templ"
 	"github.com/carlwr/cobraman/internal/tests/tempdir"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -216,7 +220,7 @@ func TestOptions_New(t *testing.T) {
 			opt: cobraman.Options{},
 			want: wantRegexes{
 				troff: {`\.TH "FOO" "1"`},
-				mdoc:  {`\.Dt FOO 1`},
+				mdoc:  {`\.Dt FOO 1\n\.Os\n`},
 			},
 		}, {
 			opt: cobraman.Options{Section: "3"},
@@ -233,7 +237,7 @@ func TestOptions_New(t *testing.T) {
 			},
 			want: wantRegexes{
 				troff: {`\.TH "FOO" "3" "centerFooter" "left footer" "centerHeader"`},
-				mdoc:  {`\.Dt FOO 3`}, // custom header/footer not supported
+				mdoc:  {`\.Dt FOO 3\n\.Os left footer\n`}, // custom center header/footer not supported
 			},
 		}, {
 			opt: cobraman.Options{Date: mkDate("1968-06-21T15:04:05Z")},
@@ -241,6 +245,24 @@ func TestOptions_New(t *testing.T) {
 				troff: {`\.TH "FOO" "1" "Jun(e?) 1968"`},
 				mdoc:  {`\.Dd Jun(e?) 1968`},
 			},
+		}, {
+			opt: cobraman.Options{
+				Date: mkDate("1968-06-21T15:04:05Z"),
+				MonthNames: []string{
+					"Januar", "Februar", "März", "April", "Mai", "Juni",
+					"Juli", "August", "September", "Oktober", "November", "Dezember",
+				},
+			},
+			want: wantRegexes{
+				troff: {`\.TH "FOO" "1" "Juni 1968"`},
+				mdoc:  {`\.Dd Juni 1968\n`},
+			},
+		}, {
+			opt: cobraman.Options{Date: mkDate("1968-06-21T15:04:05Z"), ISODate: true},
+			want: wantRegexes{
+				troff: {`\.TH "FOO" "1" "1968\\-06\\-21"`},
+				mdoc:  {`\.Dd 1968-06-21\n`},
+			},
 		},
 	}
 
@@ -249,6 +271,16 @@ func TestOptions_New(t *testing.T) {
 	}
 }
 
+func TestInvalidMonthNames(t *testing.T) {
+	cmd := &cobra.Command{Use: "foo"}
+	opts := cobraman.Options{MonthNames: []string{"Januar"}}
+
+	assert.ErrorIs(t, cobraman.GenerateOnePage(cmd, &opts, "troff", new(bytes.Buffer)), cobraman.ErrInvalidOptions)
+	assert.ErrorIs(t, cobraman.GenerateDocs(cmd, &opts, t.TempDir(), "troff"), cobraman.ErrInvalidOptions)
+	_, err := cobraman.BuildDocData(cmd, &opts)
+	assert.ErrorIs(t, err, cobraman.ErrInvalidOptions)
+}
+
 func genDoc(cmd cobra.Command, opts cobraman.Options, dir string, formt format) (err error) {
 	cmdCopy := cmd
 	optCopy := opts
@@ -322,9 +354,9 @@ func TestMisc(t *testing.T) {
 				"header_custom":    `.*(%s%s%s%s%s)?`, // not supported by mdoc
 				"header_date":      `\.Dd %s`,
 				"name":             `\.Sh NAME\n\.Nm %s\n(\.Nd %s\n)?\.Sh SYNOPSIS`,
-				"synopsis":         `\.Sh SYNOPSIS\n\.Nm %s\n\.Op Fl <args>\n\.Ek\n\.Sh DESCRIPTION`,
-				"synopsis_subcmds": `\.Sh SYNOPSIS(\n\.Nm %s (%s|%s) Op Fl flags Op args){2}\n\.Ek\n\.Sh DESCRIPTION`,
-				"synopsis_flags":   `\.Sh SYNOPSIS\n\.Nm %s\n\.Op Fl \\-%s\n\.Op Fl <args>`,
+				"synopsis":         `\.Sh SYNOPSIS\n\.Nm %s\n\.Op Ar args\n\.Ek\n\.Sh DESCRIPTION`,
+				"synopsis_subcmds": `\.Sh SYNOPSIS(\n\.Nm %s Cm (%s|%s)\n\.Op Ar options\n\.Op Ar args){2}\n\.Ek\n\.Sh DESCRIPTION`,
+				"synopsis_flags":   `\.Sh SYNOPSIS\n\.Nm %s\n\.Op Fl -%s Ar string\n\.Op Ar args`,
 				"description":      `\.Sh DESCRIPTION\n%s`,
 				"description_long": `\.Sh DESCRIPTION\n%s\n\.Pp\n%s`,
 			},
@@ -439,7 +471,7 @@ func TestMisc(t *testing.T) {
 			},
 			opts: cobraman.Options{},
 			expectedPatterns: []expectedPattern{
-				{"description_long", []interface{}{"Long desc", "This is long \\\\& stuff\\."}},
+				{"description_long", []interface{}{"Long desc", "This is long & stuff\\."}},
 			},
 		},
 	}
@@ -494,7 +526,7 @@ func TestSec_required(t *testing.T) {
 			fmt:          "mdoc",
 			header:       "\\.Dt %s %s(%s%s%s){0}",
 			sec_name:     "\\.Sh NAME\n\\.Nm %s\n(\\.Nd %s\n)?\\.Sh SYNOPSIS",
-			sec_synopsis: "\\.Sh SYNOPSIS\n\\.Nm %s\n\\.Op Fl <args>\n",
+			sec_synopsis: "\\.Sh SYNOPSIS\n\\.Nm %s\n\\.Op Ar args\n",
 		},
 	}
 
@@ -635,14 +667,14 @@ func TestSec_Alt(t *testing.T) {
 	cmd.Example = "Here is example"
 	buf.Reset()
 	assert.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "troff", buf))
-	assert.Regexp(t, "\\.SH EXAMPLES\n.PP\nHere is example\n", buf.String())
+	assert.Regexp(t, "\\.SH EXAMPLES\n\\.RS 4\n\\.nf\nHere is example\n\\.fi\n", buf.String())
 
 	annotations = make(map[string]string)
 	annotations["man-examples-section"] = "Override at cmd level"
 	cmd.Annotations = annotations
 	buf.Reset()
 	assert.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "troff", buf))
-	assert.Regexp(t, "\\.SH EXAMPLES\n.PP\nOverride at cmd", buf.String())
+	assert.Regexp(t, "\\.SH EXAMPLES\n\\.RS 4\n\\.nf\nOverride at cmd", buf.String())
 
 	// AUTHOR
 	buf.Reset()
@@ -655,6 +687,683 @@ func TestSec_Alt(t *testing.T) {
 	assert.Regexp(t, "\\.SH AUTHOR\nWritten by Ray Johnson\n.PP", buf.String()) // Nocobraman.Options section if not in opts
 }
 
+// TestSec_Parity checks that every format has the same sections, in the same
+// order, and that no content is lost when switching formats.
+func TestSec_Parity(t *testing.T) {
+	root := &cobra.Command{Use: "foo", Short: "The foo tool"}
+	cmd := &cobra.Command{
+		Use:     "bar",
+		Short:   "Bars things",
+		Long:    "Bar does the barring of things.",
+		Example: "foo bar thing",
+		Run:     func(*cobra.Command, []string) {},
+	}
+	cmd.Flags().Bool("loud", false, "Bar loudly")
+	root.AddCommand(cmd)
+	opts := cobraman.Options{
+		Environment: "Reads FOO_HOME.",
+		Files:       "Reads foo.conf.",
+		Bugs:        "Too many bars.",
+		Author:      "Jane Roe",
+	}
+
+	type section struct {
+		headings map[format]string
+		content  string
+	}
+	sections := []section{
+		{map[format]string{troff: ".SH NAME", mdoc: ".Sh NAME", md: "## foo bar"}, "Bars things"},
+		{map[format]string{troff: ".SH SYNOPSIS", mdoc: ".Sh SYNOPSIS", md: "### Synopsis"}, "bar"},
+		{map[format]string{troff: ".SH DESCRIPTION", mdoc: ".Sh DESCRIPTION", md: "### Description"}, "barring of things"},
+		{map[format]string{troff: ".SH OPTIONS", mdoc: "The options are as follows:", md: "### Options"}, "Bar loudly"},
+		{map[format]string{troff: ".SH ENVIRONMENT", mdoc: ".Sh ENVIRONMENT", md: "### Environment"}, "FOO_HOME"},
+		{map[format]string{troff: ".SH FILES", mdoc: ".Sh FILES", md: "### Files"}, "foo.conf"},
+		{map[format]string{troff: ".SH BUGS", mdoc: ".Sh BUGS", md: "### Bugs"}, "Too many bars."},
+		{map[format]string{troff: ".SH EXAMPLES", mdoc: ".Sh EXAMPLES", md: "### Examples"}, "foo bar thing"},
+		{map[format]string{troff: ".SH AUTHOR", mdoc: ".Sh AUTHOR", md: "### Author"}, "Jane Roe"},
+		{map[format]string{troff: ".SH SEE ALSO", mdoc: ".Sh SEE ALSO", md: "### See Also"}, "foo"},
+	}
+
+	for _, formt := range []format{troff, mdoc, md} {
+		t.Run(formt.String(), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			require.NoError(t, cobraman.GenerateOnePage(cmd, &opts, formt.String(), buf))
+			page := buf.String()
+
+			last := -1
+			for _, sec := range sections {
+				heading := sec.headings[formt]
+				i := strings.Index(page, heading+"\n")
+				if !assert.Greater(t, i, last, "section %q missing or out of order", heading) {
+					continue
+				}
+				assert.Contains(t, page[i:], sec.content, "content of %q", heading)
+				last = i
+			}
+		})
+	}
+}
+
+func TestSynopsisUseLine(t *testing.T) {
+	root := &cobra.Command{Use: "kubectl"}
+	get := &cobra.Command{Use: "get RESOURCE [NAME]", Run: func(*cobra.Command, []string) {}}
+	get.Flags().Bool("watch", false, "watch for changes")
+	root.AddCommand(get)
+	opts := cobraman.Options{Synopsis: cobraman.SynopsisUseLine}
+
+	wants := map[format]string{
+		troff: `\.SH SYNOPSIS\n\.sp\n\\fBkubectl get\\fR \\fIRESOURCE\\fP \[\\fINAME\\fP\] \[\\fIoptions\\fP\]\n\.SH`,
+		mdoc:  `\.Sh SYNOPSIS\n\.Nm kubectl Cm get\n\.Ar RESOURCE\n\.Op Ar NAME\n\.Op Ar options\n\.Ek`,
+		md:    "### Synopsis\n\n```\nkubectl get RESOURCE \\[NAME\\] \\[options\\]\n```",
+	}
+	for formt, want := range wants {
+		buf, err := genPage(*get, opts, formt)
+		require.NoError(t, err)
+		assert.Regexp(t, want, buf.String(), formt.String())
+		assert.NotContains(t, buf.String(), "watch]", formt.String())
+	}
+
+	// the usage lines of the subcommands are listed for a parent
+	buf, err := genPage(*root, opts, troff)
+	require.NoError(t, err)
+	assert.Regexp(t, `\.sp\n\\fBkubectl get\\fR \\fIRESOURCE\\fP \[\\fINAME\\fP\] \[\\fIoptions\\fP\]\n\.br\n\.SH DESCRIPTION`, buf.String())
+}
+
+func TestSynopsisArgs(t *testing.T) {
+	cmd := &cobra.Command{Use: "cp <source>... <dest> [mode]", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().Bool("force", false, "overwrite")
+
+	wants := map[format]string{
+		troff: `\\fBcp \\fR\[\\fI\\-\\-force\\fP\] \\fIsource\\fP \.\.\. \\fIdest\\fP \[\\fImode\\fP\]\n`,
+		mdoc:  `\.Op Fl -force\n\.Ar source \.\.\.\n\.Ar dest\n\.Op Ar mode\n\.Ek`,
+		md:    `\ncp \[--force\] source\.\.\. dest \[mode\]\n`,
+	}
+	for formt, want := range wants {
+		buf, err := genPage(*cmd, cobraman.Options{}, formt)
+		require.NoError(t, err)
+		assert.Regexp(t, want, buf.String(), formt.String())
+		assert.NotContains(t, buf.String(), "<args>", formt.String())
+	}
+}
+
+func TestFlagDefaults(t *testing.T) {
+	cmd := &cobra.Command{Use: "foo"}
+	cmd.Flags().String("name", "", "the name")
+	cmd.Flags().Bool("force", false, "force it")
+	cmd.Flags().Bool("color", true, "use colors")
+	cmd.Flags().Duration("timeout", 30*time.Second, "give up after this")
+	cmd.Flags().Duration("delay", 0, "wait first")
+	cmd.Flags().Int("retries", 3, "retry this often")
+	cmd.Flags().StringSlice("tag", nil, "tags to set")
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	defaults := map[string]string{}
+	for _, flag := range data.AllFlags {
+		defaults[flag.Name] = flag.Default
+	}
+	assert.Equal(t, map[string]string{
+		"name": "", "force": "", "color": "true", "timeout": "30s", "delay": "", "retries": "3", "tag": "",
+	}, defaults)
+
+	buf, err := genPage(*cmd, cobraman.Options{}, troff)
+	require.NoError(t, err)
+	page := buf.String()
+	assert.Contains(t, page, "\\fB\\-\\-name\\fP = <string>\nthe name\n")
+	assert.Contains(t, page, "\\fB\\-\\-force\\fP\nforce it\n")
+	assert.Contains(t, page, "\\fB\\-\\-color\\fP\nuse colors (default: true)\n")
+	assert.Contains(t, page, "\\fB\\-\\-timeout\\fP = <duration>\ngive up after this (default: 30s)\n")
+
+	buf, err = genPage(*cmd, cobraman.Options{}, md)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "* --retries=<int> - retry this often (default: 3)\n")
+	assert.Contains(t, buf.String(), "* --name=<string> - the name\n")
+
+	opts := cobraman.Options{FormatDefault: func(flag cobraman.Flag) string {
+		if flag.Type == "duration" && flag.Default != "" {
+			return "a while"
+		}
+		return flag.Default
+	}}
+	buf, err = genPage(*cmd, opts, mdoc)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), ".It Fl -timeout Ar duration\ngive up after this (default: a while)\n")
+	assert.Contains(t, buf.String(), ".It Fl -retries Ar int\nretry this often (default: 3)\n")
+}
+
+func TestRepeatableFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "foo"}
+	cmd.Flags().StringSlice("tag", nil, "tags to set")
+	cmd.Flags().IntSlice("port", []int{80}, "ports to open")
+	cmd.Flags().StringArray("file", nil, "files to read")
+	cmd.Flags().StringToString("label", nil, "labels to set")
+	cmd.Flags().StringToInt64("limit", nil, "limits to set")
+	cmd.Flags().CountP("verbose", "v", "talk more")
+	cmd.Flags().String("name", "", "the name")
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	type valueName struct {
+		name       string
+		repeatable bool
+	}
+	got := map[string]valueName{}
+	for _, flag := range data.AllFlags {
+		got[flag.Name] = valueName{flag.ValueName, flag.Repeatable}
+	}
+	assert.Equal(t, map[string]valueName{
+		"tag":     {"string", true},
+		"port":    {"int", true},
+		"file":    {"string", true},
+		"label":   {"key=value", true},
+		"limit":   {"key=int64", true},
+		"verbose": {"", true},
+		"name":    {"string", false},
+	}, got)
+
+	buf, err := genPage(*cmd, cobraman.Options{}, troff)
+	require.NoError(t, err)
+	page := buf.String()
+	assert.Contains(t, page, "\\fB\\-\\-tag\\fP = <string>\ntags to set (may be repeated)\n")
+	assert.Contains(t, page, "\\fB\\-\\-port\\fP = <int>\nports to open (may be repeated) (default: [80])\n")
+	assert.Contains(t, page, "\\fB\\-\\-label\\fP = <key=value>\nlabels to set (may be repeated)\n")
+	assert.Contains(t, page, "\\fB\\-v\\fP, \\fB\\-\\-verbose\\fP\ntalk more (may be repeated)\n")
+	assert.Contains(t, page, "the name\n")
+	assert.NotContains(t, page, "the name (may be repeated)")
+}
+
+func TestShorthandOnlyFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "foo"}
+	cmd.Flags().StringP("", "n", "", "name to use")
+	cmd.Flags().BoolP("quiet", "q", false, "talk less")
+	cmd.Flags().ShorthandLookup("n").Annotations = map[string][]string{"man-flag-env": {"FOO_NAME"}}
+
+	wants := map[format][]string{
+		troff: {
+			"[\\fI\\-n\\fP] [\\fI\\-q\\fP|\\fI\\-\\-quiet\\fP]",
+			".TP\n\\fB\\-n\\fP <string>\nname to use (env: FOO_NAME)\n",
+			"Sets \\fB\\-n\\fP.",
+		},
+		mdoc: {
+			".Op Fl n Ar string\n.Op Fl q | Fl -quiet\n",
+			".It Fl n Ar string\nname to use",
+			".Fl n .",
+		},
+		md: {
+			"foo [-n] [-q|--quiet]",
+			"* -n <string> - name to use",
+			"* FOO_NAME - sets -n",
+		},
+	}
+	for formt, want := range wants {
+		buf, err := genPage(*cmd, cobraman.Options{}, formt)
+		require.NoError(t, err)
+		for _, w := range want {
+			assert.Contains(t, buf.String(), w, formt.String())
+		}
+		assert.NotRegexp(t, `--[^a-z]|\\-\\-\\fP|Fl - `, buf.String(), formt.String())
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "html", buf))
+	assert.Contains(t, buf.String(), "foo [-n] [-q|--quiet]")
+	assert.Contains(t, buf.String(), "<dt><code>-n &lt;string&gt;</code></dt>")
+	assert.Contains(t, buf.String(), "<dd>Sets <code>-n</code></dd>")
+	assert.NotContains(t, buf.String(), "--]")
+}
+
+func TestDeprecatedFlagNames(t *testing.T) {
+	cmd := &cobra.Command{Use: "foo"}
+	cmd.Flags().StringP("old-file", "f", "", "file to read")
+	require.NoError(t, cmd.Flags().MarkDeprecated("old-file", "use --file"))
+	cmd.Flags().StringP("input", "i", "", "input to read")
+	require.NoError(t, cmd.Flags().MarkShorthandDeprecated("input", "use --input"))
+	cmd.Flags().BoolP("quiet", "q", false, "talk less")
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	require.Len(t, data.AllFlags, 2)
+	assert.Equal(t, "input", data.AllFlags[0].Name)
+	assert.Equal(t, "", data.AllFlags[0].Shorthand)
+
+	wants := map[format][]string{
+		troff: {
+			"[\\fI\\-\\-input\\fP] [\\fI\\-q\\fP|\\fI\\-\\-quiet\\fP]",
+			".TP\n\\fB\\-\\-input\\fP = <string>\ninput to read\n",
+		},
+		mdoc: {".Op Fl -input Ar string\n.Op Fl q | Fl -quiet\n", ".It Fl -input Ar string\ninput to read\n"},
+		md:   {"foo [--input] [-q|--quiet]", "* --input=<string> - input to read\n"},
+	}
+	for formt, want := range wants {
+		buf, err := genPage(*cmd, cobraman.Options{}, formt)
+		require.NoError(t, err)
+		for _, w := range want {
+			assert.Contains(t, buf.String(), w, formt.String())
+		}
+		assert.NotContains(t, buf.String(), "old-file", formt.String())
+		assert.NotRegexp(t, `\bf\b|-i\b`, buf.String(), formt.String())
+	}
+}
+
+func TestBacktickValueName(t *testing.T) {
+	cmd := &cobra.Command{Use: "foo"}
+	cmd.Flags().StringArray("file", nil, "read from `FILE` instead")
+	cmd.Flags().String("out", "", "write to `FILE`")
+	require.NoError(t, cmd.Flags().SetAnnotation("out", "man-arg-hints", []string{"path"}))
+	cmd.Flags().String("plain", "", "no `quotes")
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	flags := map[string]cobraman.Flag{}
+	for _, flag := range data.AllFlags {
+		flags[flag.Name] = flag
+	}
+	assert.Equal(t, "FILE", flags["file"].ValueName)
+	assert.Equal(t, "read from FILE instead", flags["file"].Usage)
+	assert.Equal(t, "path", flags["out"].ValueName)
+	assert.Equal(t, "write to FILE", flags["out"].Usage)
+	assert.Equal(t, "string", flags["plain"].ValueName)
+	assert.Equal(t, "no `quotes", flags["plain"].Usage)
+
+	buf, err := genPage(*cmd, cobraman.Options{}, troff)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "\\fB\\-\\-file\\fP = <FILE>\nread from FILE instead (may be repeated)\n")
+}
+
+func TestSeeAlsoSiblings(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	run := func(*cobra.Command, []string) {}
+	for _, name := range []string{"a", "b", "c"} {
+		root.AddCommand(&cobra.Command{Use: name, Run: run})
+	}
+
+	for _, parallel := range []int{0, 3} {
+		dir := tempDir(t)
+		opts := cobraman.Options{Parallel: parallel}
+		require.NoError(t, cobraman.GenerateDocs(root, &opts, dir, "troff"))
+		for _, name := range []string{"a", "b", "c"} {
+			content, err := os.ReadFile(filepath.Join(dir, "tool-"+name+".1"))
+			require.NoError(t, err)
+			seeAlso := string(content[strings.Index(string(content), ".SH SEE ALSO"):])
+			assert.Contains(t, seeAlso, ".BR tool (1)")
+			for _, other := range []string{"a", "b", "c"} {
+				if other == name {
+					assert.NotContains(t, seeAlso, "tool\\-"+other+" ", name)
+				} else {
+					assert.Contains(t, seeAlso, "tool\\-"+other+" ", name)
+				}
+			}
+		}
+	}
+}
+
+func TestSeeAlsoPolicy(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	run := func(*cobra.Command, []string) {}
+	a := &cobra.Command{Use: "a", Run: run}
+	a.AddCommand(&cobra.Command{Use: "x", Run: run})
+	root.AddCommand(a, &cobra.Command{Use: "b", Run: run})
+
+	for policy, want := range map[cobraman.SeeAlsoPolicy][]string{
+		cobraman.SeeAlsoAll:               {"tool", "tool b", "tool a x"},
+		cobraman.SeeAlsoParentAndChildren: {"tool", "tool a x"},
+		cobraman.SeeAlsoParent:            {"tool"},
+		cobraman.SeeAlsoNone:              nil,
+	} {
+		data, err := cobraman.BuildDocData(a, &cobraman.Options{SeeAlsoPolicy: policy})
+		require.NoError(t, err)
+		var got []string
+		for _, see := range data.SeeAlsos {
+			got = append(got, see.CmdPath)
+		}
+		assert.Equal(t, want, got, policy)
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(a, &cobraman.Options{SeeAlsoPolicy: cobraman.SeeAlsoNone}, "troff", buf))
+	assert.NotContains(t, buf.String(), "SEE ALSO")
+}
+
+func TestCommandGroups(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	root.AddGroup(&cobra.Group{ID: "mgmt", Title: "Management Commands:"}, &cobra.Group{ID: "core", Title: "Core Commands"})
+	run := func(*cobra.Command, []string) {}
+	root.AddCommand(&cobra.Command{Use: "run", Short: "run it", GroupID: "core", Run: run})
+	root.AddCommand(&cobra.Command{Use: "image", Short: "images", GroupID: "mgmt", Run: run})
+	root.AddCommand(&cobra.Command{Use: "misc", Short: "other", Run: run})
+
+	data, err := cobraman.BuildDocData(root, &cobraman.Options{})
+	require.NoError(t, err)
+	var titles, seeAlsos []string
+	for _, g := range data.CommandGroups {
+		titles = append(titles, g.Title)
+		require.Len(t, g.Commands, 1)
+	}
+	for _, see := range data.SeeAlsos {
+		seeAlsos = append(seeAlsos, see.CmdPath+":"+see.Group)
+	}
+	assert.Equal(t, []string{"Management Commands", "Core Commands", "Additional Commands"}, titles)
+	assert.Equal(t, []string{"tool image:Management Commands", "tool run:Core Commands", "tool misc:Additional Commands"}, seeAlsos)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(root, &cobraman.Options{}, "troff", buf))
+	assert.Contains(t, buf.String(), dedent(`.SH COMMANDS
+		.SS "Management Commands"
+		.TP
+		\fBtool image\fP
+		images
+		.SS "Core Commands"`))
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, &cobraman.Options{}, "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Ss Management Commands\n.Bl -tag -width Ds\n.It Cm image\n")
+
+	data, err = cobraman.BuildDocData(root.Commands()[1], &cobraman.Options{}) // misc
+	require.NoError(t, err)
+	assert.Nil(t, data.CommandGroups)
+	assert.Equal(t, "tool image", data.SeeAlsos[1].CmdPath)
+	assert.Equal(t, "tool run", data.SeeAlsos[2].CmdPath)
+
+	plain := &cobra.Command{Use: "plain"}
+	plain.AddCommand(&cobra.Command{Use: "sub", Run: run})
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(plain, &cobraman.Options{}, "troff", buf))
+	assert.NotContains(t, buf.String(), ".SH COMMANDS")
+}
+
+func TestFlagProvenance(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	root.PersistentFlags().Bool("verbose", false, "talk more")
+	root.PersistentFlags().Bool("shadowed", false, "shadowed by sub")
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	sub.Flags().Bool("local", false, "a local flag")
+	sub.Flags().Bool("shadowed", false, "a local flag shadowing a global one")
+	sub.PersistentFlags().Bool("own", false, "a persistent flag of sub")
+	root.AddCommand(sub)
+
+	names := func(flags []cobraman.Flag) []string {
+		var names []string
+		for _, f := range flags {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+	// cobra has not merged the persistent flags, as sub was not executed
+	data, err := cobraman.BuildDocData(sub, &cobraman.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local", "shadowed"}, names(data.AllFlags))
+
+	data, err = cobraman.BuildDocData(sub, &cobraman.Options{MergePersistentFlags: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local", "own", "shadowed", "verbose"}, names(data.AllFlags))
+	assert.Equal(t, []string{"verbose"}, names(data.InheritedFlags))
+	assert.Equal(t, []string{"local", "own", "shadowed"}, names(data.NonInheritedFlags))
+	assert.True(t, data.InheritedFlags[0].Inherited)
+	assert.False(t, data.NonInheritedFlags[0].Inherited)
+
+	// appending to one array does not change the other
+	data.NonInheritedFlags = append(data.NonInheritedFlags, cobraman.Flag{Name: "extra"})
+	assert.Equal(t, []string{"verbose"}, names(data.InheritedFlags))
+}
+
+func TestFlagEnv(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Run: mkMockRunFunc()}
+	cmd.Flags().Duration("timeout", 0, "how long to wait")
+	cmd.Flags().Bool("debug", false, "more output")
+	cmd.Flags().String("token", "", "the API token")
+	require.NoError(t, cmd.Flags().SetAnnotation("timeout", "man-flag-env", []string{"TOOL_TIMEOUT"}))
+
+	buf := new(bytes.Buffer)
+	opts := cobraman.Options{Environment: "Also see TOOL_HOME."}
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "troff", buf))
+	assert.Contains(t, buf.String(), "how long to wait (env: TOOL_TIMEOUT)\n")
+	assert.Contains(t, buf.String(), dedent(`.SH ENVIRONMENT
+		.PP
+		Also see TOOL_HOME.
+		.TP
+		\fBTOOL_TIMEOUT\fP
+		Sets \fB\-\-timeout\fP.
+		.SH`))
+
+	opts = cobraman.Options{FlagEnv: func(flag cobraman.Flag) string {
+		if flag.Name == "token" || flag.Name == "timeout" {
+			return "TOOL_" + strings.ToUpper(flag.Name)
+		}
+		return ""
+	}}
+	data, err := cobraman.BuildDocData(cmd, &opts)
+	require.NoError(t, err)
+	var envs []string
+	for _, f := range data.EnvFlags {
+		envs = append(envs, f.Env+"="+f.Name)
+	}
+	assert.Equal(t, []string{"TOOL_TIMEOUT=timeout", "TOOL_TOKEN=token"}, envs)
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "markdown", buf))
+	assert.Contains(t, buf.String(), "### Environment\n\n* TOOL_TIMEOUT - sets --timeout\n* TOOL_TOKEN - sets --token\n")
+}
+
+func TestFlagGroups(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Run: mkMockRunFunc()}
+	cmd.Flags().String("output", "", "output format")
+	cmd.Flags().Bool("wide", false, "wide output")
+	cmd.Flags().Bool("debug", false, "more output")
+	for _, name := range []string{"output", "wide"} {
+		require.NoError(t, cmd.Flags().SetAnnotation(name, "man-flag-group", []string{"Output options"}))
+	}
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	require.Len(t, data.FlagGroups, 2)
+	assert.Equal(t, "", data.FlagGroups[0].Title)
+	assert.Equal(t, "debug", data.FlagGroups[0].Flags[0].Name)
+	assert.Equal(t, "Output options", data.FlagGroups[1].Title)
+	assert.Len(t, data.FlagGroups[1].Flags, 2)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "troff", buf))
+	assert.Contains(t, buf.String(), dedent(`.SH OPTIONS
+		.TP
+		\fB\-\-debug\fP
+		more output
+		.SS "Output options"
+		.TP
+		\fB\-\-output\fP = <string>
+		output format
+		.TP`))
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "markdown", buf))
+	assert.Contains(t, buf.String(), "supported:\n\n* --debug - more output\n\n#### Output options\n\n* --output=<string>")
+
+	require.NoError(t, cmd.Flags().SetAnnotation("debug", "man-flag-group", []string{`"Debug" options`}))
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "troff", buf))
+	assert.Contains(t, buf.String(), ".SS \"\\(dqDebug\\(dq options\"\n")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Ss \\(dqDebug\\(dq options\n.Pp\n")
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) { delete(f.Annotations, "man-flag-group") })
+	data, err = cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	assert.Nil(t, data.FlagGroups)
+}
+
+func TestCollapseInheritedFlags(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	root.PersistentFlags().Bool("verbose", false, "more output")
+	sub := &cobra.Command{Use: "sub", Run: mkMockRunFunc()}
+	sub.Flags().Bool("wide", false, "wide output")
+	root.AddCommand(sub)
+
+	opts := cobraman.Options{CollapseInheritedFlags: true}
+	data, err := cobraman.BuildDocData(sub, &opts)
+	require.NoError(t, err)
+	assert.Len(t, data.AllFlags, 1)
+	assert.Len(t, data.InheritedFlags, 1)
+	assert.Equal(t, "tool", data.InheritedFlagsFrom)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(sub, &opts, "troff", buf))
+	assert.NotContains(t, buf.String(), "verbose")
+	assert.Contains(t, buf.String(), "wide output\n.PP\nOptions inherited from parent commands are described in\n.BR tool (1).\n")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, &opts, "troff", buf))
+	assert.Contains(t, buf.String(), "verbose", "the root page lists its persistent flags")
+	assert.NotContains(t, buf.String(), "inherited")
+
+	// the pointer names the section of the parent's page
+	opts.SectionFunc = func(cmd *cobra.Command) string {
+		if cmd == root {
+			return "8"
+		}
+		return ""
+	}
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sub, &opts, "troff", buf))
+	assert.Contains(t, buf.String(), "described in\n.BR tool (8).\n")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sub, &opts, "mdoc", buf))
+	assert.Contains(t, buf.String(), "described in\n.Xr tool 8 .\n")
+}
+
+func TestHumanizeDefault(t *testing.T) {
+	for _, tc := range []struct {
+		flag cobraman.Flag
+		want string
+	}{
+		{cobraman.Flag{Type: "duration", Default: "1h0m0s"}, "1h"},
+		{cobraman.Flag{Type: "duration", Default: "1h30m0s"}, "1h30m"},
+		{cobraman.Flag{Type: "duration", Default: "1m30s"}, "1m30s"},
+		{cobraman.Flag{Type: "duration", Default: "1.5s"}, "1.5s"},
+		{cobraman.Flag{Type: "int64", Default: "30000000000", Unit: "ns"}, "30s"},
+		{cobraman.Flag{Type: "int", Default: "1500", Unit: "ms"}, "1.5s"},
+		{cobraman.Flag{Type: "int", Default: "3600", Unit: "s"}, "1h"},
+		{cobraman.Flag{Type: "int", Default: "67108864", Unit: "bytes"}, "64MiB"},
+		{cobraman.Flag{Type: "int", Default: "1500", Unit: "bytes"}, "1500B"},
+		{cobraman.Flag{Type: "int", Default: "1500"}, "1500"},
+		{cobraman.Flag{Type: "string", Default: "auto", Unit: "bytes"}, "auto"},
+		{cobraman.Flag{Type: "duration"}, ""},
+	} {
+		assert.Equal(t, tc.want, cobraman.HumanizeDefault(tc.flag), tc.flag)
+	}
+
+	cmd := &cobra.Command{Use: "tool", Run: mkMockRunFunc()}
+	cmd.Flags().Duration("timeout", time.Hour, "how long to wait")
+	cmd.Flags().Int("buffer", 1<<20, "buffer size")
+	require.NoError(t, cmd.Flags().SetAnnotation("buffer", "man-default-unit", []string{"bytes"}))
+	for _, templateName := range []string{"troff", "mdoc", "markdown", "html"} {
+		buf := new(bytes.Buffer)
+		require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{FormatDefault: cobraman.HumanizeDefault}, templateName, buf))
+		assert.Contains(t, buf.String(), "(default: 1h)", templateName)
+		assert.Contains(t, buf.String(), "(default: 1MiB)", templateName)
+	}
+}
+
+func TestRedactDefault(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	cmd := &cobra.Command{Use: "tool", Run: mkMockRunFunc()}
+	cmd.Flags().String("config", filepath.Join(home, ".toolrc"), "config file")
+	cmd.Flags().String("token", "s3cr3t", "API token")
+
+	opts := cobraman.Options{
+		RedactDefault: func(flag cobraman.Flag, value string) string {
+			if flag.Name == "token" {
+				return "<redacted>"
+			}
+			return cobraman.RedactHomeDir(flag, value)
+		},
+		FormatDefault: func(flag cobraman.Flag) string { return strings.ToUpper(flag.Default) },
+	}
+	data, err := cobraman.BuildDocData(cmd, &opts)
+	require.NoError(t, err)
+	require.Len(t, data.AllFlags, 2)
+	config, token := data.AllFlags[0], data.AllFlags[1]
+	assert.Equal(t, filepath.Join("$HOME", ".toolrc"), config.DefValue)
+	assert.Equal(t, strings.ToUpper(filepath.Join("$HOME", ".toolrc")), config.Default, "FormatDefault sees the redacted value")
+	assert.Equal(t, "<redacted>", token.DefValue)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "markdown", buf))
+	assert.NotContains(t, buf.String(), "s3cr3t")
+	assert.NotContains(t, buf.String(), home)
+
+	assert.Equal(t, home+"2/x", cobraman.RedactHomeDir(cobraman.Flag{}, home+"2/x"))
+}
+
+func TestIncludeDeprecated(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Run: mkMockRunFunc()}
+	cmd.Flags().String("output", "", "where to write")
+	cmd.Flags().StringP("out", "o", "", "where to write")
+	cmd.Flags().Bool("legacy", false, "old behavior")
+	require.NoError(t, cmd.Flags().MarkDeprecated("out", "use --output instead"))
+	require.NoError(t, cmd.Flags().MarkDeprecated("legacy", "it is always on"))
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	assert.Empty(t, data.DeprecatedFlags)
+
+	data, err = cobraman.BuildDocData(cmd, &cobraman.Options{IncludeDeprecated: true})
+	require.NoError(t, err)
+	require.Len(t, data.DeprecatedFlags, 2)
+	assert.Equal(t, "legacy", data.DeprecatedFlags[0].Name)
+	assert.Equal(t, "", data.DeprecatedFlags[0].ReplacedBy)
+	assert.Equal(t, "out", data.DeprecatedFlags[1].Name)
+	assert.Equal(t, "o", data.DeprecatedFlags[1].Shorthand)
+	assert.Equal(t, "output", data.DeprecatedFlags[1].ReplacedBy)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{IncludeDeprecated: true}, "troff", buf))
+	assert.Equal(t, 1, strings.Count(buf.String(), "\\fB\\-o\\fP"), "-o is only listed as deprecated")
+	assert.Contains(t, buf.String(), dedent(`.SS "Deprecated options"
+		.TP
+		\fB\-\-legacy\fP
+		it is always on
+		.TP
+		\fB\-o\fP, \fB\-\-out\fP = <string>
+		use \-\-output instead (superseded by \fB\-\-output\fP)
+		`))
+}
+
+func TestTranslate(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Short: "the tool", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().String("file", "", "read from `FILE`")
+	cmd.Flags().Bool("quiet", false, "")
+	catalog := map[string]map[string]string{"de": {
+		"the tool":         "das Werkzeug",
+		"read from `FILE`": "aus `DATEI` lesen",
+	}}
+	var calls []string
+	opts := cobraman.Options{
+		Locale: "de",
+		Translate: func(locale, text string) string {
+			calls = append(calls, text)
+			if translated, ok := catalog[locale][text]; ok {
+				return translated
+			}
+			return text
+		},
+	}
+
+	data, err := cobraman.BuildDocData(cmd, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "das Werkzeug", data.ShortDescription)
+	assert.Equal(t, "das Werkzeug", data.Description)
+	require.Len(t, data.AllFlags, 2)
+	assert.Equal(t, "aus DATEI lesen", data.AllFlags[0].Usage)
+	assert.Equal(t, "DATEI", data.AllFlags[0].ValueName)
+	assert.NotContains(t, calls, "", "empty texts are not translated")
+
+	opts.Locale = "fr"
+	data, err = cobraman.BuildDocData(cmd, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "the tool", data.ShortDescription)
+	assert.Equal(t, "FILE", data.AllFlags[0].ValueName)
+}
+
 func TestBiggerExample(t *testing.T) {
 	cmd1 := mkCobraCmd("bob", false)
 	cmd2 := mkCobraCmd("bar", true)
@@ -756,6 +1465,11 @@ func TestCustomerTemplate(t *testing.T) {
 	opts := cobraman.Options{}
 	assert.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "good", buf))
 	assert.Regexp(t, "Hello world", buf.String())
+
+	templ.RegisterTemplate("unparsable", "-", "txt", "Hello {{ ")
+	assert.ErrorIs(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "unparsable", buf), cobraman.ErrInvalidTemplate)
+	_, err := cobraman.GenerateDocsFiles(cmd, &cobraman.Options{}, tempDir(t), "unparsable")
+	assert.ErrorIs(t, err, cobraman.ErrInvalidTemplate)
 }
 
 func TestAddTemplateFunc(t *testing.T) {
@@ -781,3 +1495,1123 @@ func TestAddTemplateFunc(t *testing.T) {
 	assert.Regexp(t, "hello world!", buf.String())
 	assert.Regexp(t, "xxxxx", buf.String())
 }
+
+func TestPostProcess(t *testing.T) {
+	cmd := &cobra.Command{Use: "foo", Short: "does foo"}
+	errBoom := errors.New("boom")
+
+	var seen *cobra.Command
+	opts := cobraman.Options{
+		PostProcess: func(c *cobra.Command, content []byte) ([]byte, error) {
+			seen = c
+			return append(content, "TRADEMARK\n"...), nil
+		},
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "markdown", buf))
+	assert.Same(t, cmd, seen)
+	assert.Regexp(t, "TRADEMARK\n$", buf.String())
+
+	opts.PostProcess = func(*cobra.Command, []byte) ([]byte, error) { return nil, errBoom }
+	buf.Reset()
+	assert.ErrorIs(t, cobraman.GenerateOnePage(cmd, &opts, "markdown", buf), errBoom)
+	assert.Empty(t, buf.String())
+}
+
+func TestPrepareData(t *testing.T) {
+	parent := &cobra.Command{Use: "tool"}
+	cmd := mkCobraCmd("foo", true)
+	parent.AddCommand(cmd)
+	errBoom := errors.New("boom")
+
+	opts := cobraman.Options{
+		PrepareData: func(c *cobra.Command, data *cobraman.DocData) error {
+			data.Examples = "computed example for " + c.Name()
+			data.SeeAlsos = append(data.SeeAlsos, cobraman.SeeAlso{CmdPath: "git", Section: "1"})
+			return nil
+		},
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "troff", buf))
+	assert.Regexp(t, `\.SH EXAMPLES\n\.RS 4\n\.nf\ncomputed example for foo`, buf.String())
+	assert.Regexp(t, `\.BR tool \(1\)\n\.BR git \(1\)`, buf.String())
+
+	opts.PrepareData = func(*cobra.Command, *cobraman.DocData) error { return errBoom }
+	assert.ErrorIs(t, cobraman.GenerateOnePage(cmd, &opts, "troff", buf), errBoom)
+}
+
+func TestBuildDocData(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	cmd := &cobra.Command{
+		Use:         "foo",
+		Short:       "does foo",
+		Run:         mkMockRunFunc(),
+		Annotations: map[string]string{"man-files-section": "a file", "team": "docs"},
+	}
+	cmd.Flags().StringP("name", "n", "bob", "the name")
+	root.AddCommand(cmd)
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{Section: "8"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tool foo", data.CommandPath)
+	assert.Equal(t, "8", data.Section)
+	assert.NotNil(t, data.Date)
+	assert.Equal(t, "does foo", data.Description)
+	assert.Equal(t, "a file", data.Files)
+	assert.Equal(t, "docs", data.Annotations["team"])
+	assert.Same(t, cmd, data.CobraCmd)
+	require.Len(t, data.AllFlags, 1)
+	assert.Equal(t, cobraman.Flag{
+		Shorthand: "n", Name: "name", Type: "string", DefValue: "bob", Default: "bob", Usage: "the name",
+		ValueName: "string",
+	}, data.AllFlags[0])
+	assert.Equal(t, []cobraman.SeeAlso{{CmdPath: "tool", Section: "8", IsParent: true}}, data.SeeAlsos)
+}
+
+func TestCustomDataFunc(t *testing.T) {
+	templ.RegisterTemplate("customdata", "-", "txt", `{{ .CustomData.team }}/{{ .CustomData.stability }}`)
+
+	root := &cobra.Command{Use: "tool"}
+	admin := &cobra.Command{Use: "admin", Run: mkMockRunFunc()}
+	root.AddCommand(admin)
+
+	opts := cobraman.Options{
+		CustomData: map[string]interface{}{"team": "core", "stability": "stable"},
+		CustomDataFunc: func(cmd *cobra.Command) map[string]interface{} {
+			if cmd.Name() == "admin" {
+				return map[string]interface{}{"stability": "beta"}
+			}
+			return nil
+		},
+	}
+
+	for cmd, want := range map[*cobra.Command]string{root: "core/stable", admin: "core/beta"} {
+		buf := new(bytes.Buffer)
+		require.NoError(t, cobraman.GenerateOnePage(cmd, &opts, "customdata", buf))
+		assert.Equal(t, want, buf.String())
+	}
+	assert.Equal(t, "stable", opts.CustomData["stability"], "shared map must not be modified")
+}
+
+func TestExpandAnnotations(t *testing.T) {
+	files := "{{ .CustomData.confDir }}/{{ .CommandPath | underscoreify }}.yaml"
+	cmd := &cobra.Command{
+		Use:         "sub",
+		Run:         mkMockRunFunc(),
+		Annotations: map[string]string{"man-files-section": files},
+	}
+	opts := cobraman.Options{CustomData: map[string]interface{}{"confDir": "/etc/tool"}}
+
+	data, err := cobraman.BuildDocData(cmd, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, files, data.Files, "annotations are not expanded by default")
+
+	opts.ExpandAnnotations = true
+	data, err = cobraman.BuildDocData(cmd, &opts)
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/tool/sub.yaml", data.Files)
+
+	cmd.Annotations["man-bugs-section"] = "{{ .Nope }}"
+	_, err = cobraman.BuildDocData(cmd, &opts)
+	require.ErrorIs(t, err, cobraman.ErrAnnotationTemplate)
+	assert.ErrorContains(t, err, `man-bugs-section of "sub"`)
+}
+
+func TestParallel(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	for i := 0; i < 20; i++ {
+		sub := mkCobraCmd(fmt.Sprintf("sub%02d", i), true)
+		sub.AddCommand(mkCobraCmd("leaf", true))
+		root.AddCommand(sub)
+	}
+
+	sequential, err := cobraman.GenerateDocsFiles(root, &cobraman.Options{}, tempDir(t), "troff")
+	require.NoError(t, err)
+	require.Len(t, sequential, 41)
+
+	tmpD := tempDir(t)
+	parallel, err := cobraman.GenerateDocsFiles(root, &cobraman.Options{Parallel: 4}, tmpD, "troff")
+	require.NoError(t, err)
+	require.Len(t, parallel, 41)
+	for i := range parallel {
+		assert.Equal(t, filepath.Base(sequential[i]), filepath.Base(parallel[i]))
+		assert.FileExists(t, parallel[i])
+	}
+	assert.Equal(t, filepath.Join(tmpD, "tool.1"), parallel[40])
+
+	t.Run("error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		opts := cobraman.Options{
+			Parallel: 4,
+			PostProcess: func(c *cobra.Command, content []byte) ([]byte, error) {
+				if c.Name() == "sub07" {
+					return nil, errBoom
+				}
+				return content, nil
+			},
+		}
+		assert.ErrorIs(t, cobraman.GenerateDocs(root, &opts, tempDir(t), "troff"), errBoom)
+	})
+}
+
+func TestFilter(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	admin := mkCobraCmd("admin", false)
+	admin.AddCommand(mkCobraCmd("users", true))
+	root.AddCommand(admin, mkCobraCmd("run", true))
+
+	opts := cobraman.Options{Filter: func(cmdPath string) bool { return cmdPath != "tool admin" }}
+	tmpD := tempDir(t)
+	files, err := cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpD, "tool-admin-users.1"),
+		filepath.Join(tmpD, "tool-run.1"),
+		filepath.Join(tmpD, "tool.1"),
+	}, files)
+
+	main, err := cobraman.GenerateDocsF(admin, &opts, tempDir(t), "troff")
+	require.NoError(t, err)
+	assert.Empty(t, main)
+}
+
+func TestLocales(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	root.Short = "the tool"
+	root.AddCommand(mkCobraCmd("run", true))
+	var locales []string
+	opts := cobraman.Options{
+		Locales: []string{"", "de"},
+		Translate: func(locale, text string) string {
+			locales = append(locales, locale)
+			return text
+		},
+	}
+
+	tmpD := tempDir(t)
+	files, err := cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpD, "man1", "tool-run.1"),
+		filepath.Join(tmpD, "man1", "tool.1"),
+		filepath.Join(tmpD, "de", "man1", "tool-run.1"),
+		filepath.Join(tmpD, "de", "man1", "tool.1"),
+	}, files)
+	assert.Contains(t, locales, "de")
+	assert.Empty(t, opts.Locale, "the options passed are not changed")
+
+	tmpD = tempDir(t)
+	main, err := cobraman.GenerateDocsF(root, &cobraman.Options{Locales: []string{"de", "fr"}}, tmpD, "markdown")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpD, "tool.de.md"), main)
+	assert.FileExists(t, filepath.Join(tmpD, "tool_run.fr.md"))
+	content, err := os.ReadFile(main)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "[tool run](tool_run.de.md)", "links go to the pages of the locale")
+}
+
+func TestProfiles(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	root.Flags().String("trace", "", "trace to this file")
+	require.NoError(t, root.Flags().MarkHidden("trace"))
+	root.Annotations = map[string]string{
+		"man-files-section":          "/etc/toolrc",
+		"internal.man-files-section": "/etc/toolrc and /var/lib/tool",
+	}
+	debug := mkCobraCmd("debug", true)
+	debug.Hidden = true
+	root.AddCommand(mkCobraCmd("run", true), debug)
+
+	opts := cobraman.Options{
+		Bugs: "Report bugs on GitHub.",
+		Profiles: []cobraman.Profile{
+			{Name: "public"},
+			{Name: "internal", IncludeHidden: true, AnnotationPrefix: "internal.", Bugs: "Ask in #tool."},
+		},
+	}
+	tmpD := tempDir(t)
+	files, err := cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpD, "public", "tool-run.1"),
+		filepath.Join(tmpD, "public", "tool.1"),
+		filepath.Join(tmpD, "internal", "tool-debug.1"),
+		filepath.Join(tmpD, "internal", "tool-run.1"),
+		filepath.Join(tmpD, "internal", "tool.1"),
+	}, files)
+
+	public, err := os.ReadFile(filepath.Join(tmpD, "public", "tool.1"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(public), "trace")
+	assert.NotContains(t, string(public), "debug")
+	assert.Contains(t, string(public), "\n/etc/toolrc\n")
+	assert.Contains(t, string(public), "Report bugs")
+
+	internal, err := os.ReadFile(filepath.Join(tmpD, "internal", "tool.1"))
+	require.NoError(t, err)
+	assert.Contains(t, string(internal), "trace to this file")
+	assert.Contains(t, string(internal), ".BR tool\\-debug (1)")
+	assert.Contains(t, string(internal), "/etc/toolrc and /var/lib/tool")
+	assert.Contains(t, string(internal), "Ask in #tool.")
+}
+
+func TestProvenance(t *testing.T) {
+	cmd := mkCobraCmd("tool", false)
+	cmd.AddCommand(mkCobraCmd("sub", true))
+	sub := cmd.Commands()[0]
+
+	buf := new(bytes.Buffer)
+	opts := cobraman.Options{Provenance: true, Encoding: cobraman.EncodingUTF8, Date: mkDate("1968-06-21T15:04:05Z")}
+	require.NoError(t, cobraman.GenerateOnePage(sub, &opts, "troff", buf))
+	assert.Regexp(t, `^.*coding: UTF-8.*\n`+
+		`\.\\" Generated by github\.com/carlwr/cobraman \S+ for the command "tool sub"\.\n`+
+		`\.\\" Dated 1968-06-21 from Options\.Date\.\n\.TH`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sub, &cobraman.Options{Provenance: true}, "markdown", buf))
+	assert.Regexp(t, `^<!--\nGenerated by .* for the command "tool sub"\.\nDated \d{4}-\d\d-\d\d, the time of generation\.\n-->\n## tool sub\n`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sub, &cobraman.Options{}, "markdown", buf))
+	assert.NotContains(t, buf.String(), "Generated by")
+}
+
+func TestContinueOnError(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	root.AddCommand(mkCobraCmd("bad", true), mkCobraCmd("good", true), mkCobraCmd("worse", true))
+
+	errBad := errors.New("bad page")
+	opts := cobraman.Options{
+		PrepareData: func(cmd *cobra.Command, data *cobraman.DocData) error {
+			if cmd.Name() == "bad" || cmd.Name() == "worse" {
+				return errBad
+			}
+			return nil
+		},
+	}
+
+	_, err := cobraman.GenerateDocsFiles(root, &opts, tempDir(t), "troff")
+	require.ErrorIs(t, err, errBad)
+	var pageErr *cobraman.PageError
+	assert.NotErrorAs(t, err, &pageErr)
+
+	opts.ContinueOnError = true
+	tmpD := tempDir(t)
+	files, err := cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+	require.ErrorIs(t, err, errBad)
+	require.ErrorAs(t, err, &pageErr)
+	assert.Equal(t, "tool bad", pageErr.CommandPath)
+	assert.EqualError(t, err, "tool bad: bad page\ntool worse: bad page")
+	assert.Equal(t, []string{
+		filepath.Join(tmpD, "tool-good.1"),
+		filepath.Join(tmpD, "tool.1"),
+	}, files)
+}
+
+func TestHistory(t *testing.T) {
+	changelog := `# Changelog
+
+## [Unreleased]
+- tool sync: added --force
+
+## [1.2.0] - 2024-05-01
+### Added
+- tool sync: added --dry-run
+- remote: renamed from
+  origin
+
+## v1.1.0 (2024-01-10)
+* sync now retries
+`
+	history, err := cobraman.ParseChangelog(strings.NewReader(changelog))
+	require.NoError(t, err)
+	assert.Equal(t, []cobraman.HistoryEntry{
+		{Version: "1.2.0", Date: "2024-05-01", Note: "tool sync: added --dry-run"},
+		{Version: "1.2.0", Date: "2024-05-01", Note: "remote: renamed from origin"},
+		{Version: "v1.1.0", Date: "2024-01-10", Note: "sync now retries"},
+	}, history)
+	history = append(history, cobraman.HistoryEntry{Version: "1.0.0", Note: "first release", Commands: []string{"tool sync"}})
+
+	root := &cobra.Command{Use: "tool", Run: mkMockRunFunc()}
+	sync := &cobra.Command{Use: "sync", Run: mkMockRunFunc()}
+	syncer := &cobra.Command{Use: "syncer", Run: mkMockRunFunc()}
+	root.AddCommand(sync, syncer)
+
+	opts := cobraman.Options{History: history}
+	data, err := cobraman.BuildDocData(sync, &opts)
+	require.NoError(t, err)
+	require.Len(t, data.History, 3)
+	assert.Equal(t, "tool sync: added --dry-run", data.History[0].Note)
+	assert.Equal(t, "sync now retries", data.History[1].Note)
+	assert.Equal(t, "first release", data.History[2].Note)
+
+	data, err = cobraman.BuildDocData(syncer, &opts)
+	require.NoError(t, err)
+	assert.Empty(t, data.History)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(sync, &opts, "troff", buf))
+	assert.Contains(t, buf.String(), dedent(`.SH HISTORY
+		.TP
+		\fB1.2.0\fP (2024-05-01)
+		tool sync: added \-\-dry\-run
+		.TP`))
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sync, &opts, "markdown", buf))
+	assert.Contains(t, buf.String(), "### History\n\n* 1.2.0 (2024-05-01) - tool sync: added --dry-run\n* v1.1.0")
+	for _, format := range []string{"mdoc", "html"} {
+		buf.Reset()
+		require.NoError(t, cobraman.GenerateOnePage(sync, &opts, format, buf))
+		assert.Contains(t, buf.String(), "first release", format)
+	}
+}
+
+func TestGenerateSuite(t *testing.T) {
+	tmpD := tempDir(t)
+	run := func(*cobra.Command, []string) {}
+	server := &cobra.Command{Use: "toold", Run: run}
+	server.AddCommand(&cobra.Command{Use: "serve", Run: run})
+	client := &cobra.Command{Use: "tool", Run: run}
+	admin := &cobra.Command{Use: "tooladm", Run: run}
+
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	opts := &cobraman.Options{Date: &date, LeftFooter: "Tool 1.2"}
+	require.NoError(t, cobraman.GenerateSuite([]*cobra.Command{server, client, admin}, opts, tmpD, "troff"))
+
+	content, err := os.ReadFile(filepath.Join(tmpD, "tool.1"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"May 2024" "Tool 1.2"`)
+	assert.Contains(t, string(content), ".SH SEE ALSO\n.BR toold (1)\n.BR tooladm (1)\n")
+
+	content, err = os.ReadFile(filepath.Join(tmpD, "toold-serve.1"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "tooladm", "only the roots refer to the suite")
+	assert.FileExists(t, filepath.Join(tmpD, "tooladm.1"))
+
+	err = cobraman.GenerateSuite([]*cobra.Command{client, {Use: "tool"}}, opts, tmpD, "troff")
+	assert.ErrorIs(t, err, cobraman.ErrSuiteConflict)
+}
+
+func TestSuiteIndex(t *testing.T) {
+	tmpD := tempDir(t)
+	run := func(*cobra.Command, []string) {}
+	client := &cobra.Command{Use: "tool", Short: "Use the service", Run: run}
+	client.AddCommand(&cobra.Command{Use: "sync", Short: "Sync files", Run: run})
+	server := &cobra.Command{Use: "toold", Short: "Run the service", Run: run}
+	cmds := []*cobra.Command{client, server}
+
+	opts := &cobraman.Options{SuiteIndex: "index.md"}
+	require.NoError(t, cobraman.GenerateSuite(cmds, opts, tmpD, "troff"))
+	content, err := os.ReadFile(filepath.Join(tmpD, "index.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Manual pages\n\n"+
+		"## tool\n\nUse the service\n\n### Section 1\n\n"+
+		"* [tool](tool.1) - Use the service\n"+
+		"  * [tool sync](tool-sync.1) - Sync files\n\n"+
+		"## toold\n\nRun the service\n\n### Section 1\n\n"+
+		"* [toold](toold.1) - Run the service\n", string(content))
+
+	files := []string{
+		filepath.Join(tmpD, "md", "tool_sync.md"),
+		filepath.Join(tmpD, "md", "tool.md"),
+		filepath.Join(tmpD, "tool.1"),
+		filepath.Join(tmpD, "md", "index.md"),
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.WriteSuiteIndex(buf, cmds[:1], files, tmpD))
+	assert.Equal(t, "# Manual pages\n\n"+
+		"## tool\n\nUse the service\n\n"+
+		"### Section 1\n\n* [tool](tool.1) - Use the service\n\n"+
+		"### Markdown\n\n* [tool](md/tool.md) - Use the service\n"+
+		"  * [tool sync](md/tool_sync.md) - Sync files\n", buf.String())
+}
+
+func TestDocOverrides(t *testing.T) {
+	tmpD := tempDir(t)
+	base := filepath.Join(tmpD, "docs.yaml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+tool sync:
+  short: Synchronize files
+  long: |
+    Sync copies the changed files.
+  see_also: [toolrc(5), tool push]
+`), 0o600))
+	extra := filepath.Join(tmpD, "docs.json")
+	require.NoError(t, os.WriteFile(extra, []byte(`{"tool sync": {"bugs": "Symlinks are followed."}}`), 0o600))
+
+	overrides, err := cobraman.LoadDocOverrides(base, extra)
+	require.NoError(t, err)
+
+	root := &cobra.Command{Use: "tool"}
+	sync := &cobra.Command{Use: "sync", Short: "sync", Long: "Old text.", Example: "tool sync", Run: mkMockRunFunc()}
+	root.AddCommand(sync)
+	data, err := cobraman.BuildDocData(sync, &cobraman.Options{DocOverrides: overrides})
+	require.NoError(t, err)
+	assert.Equal(t, "Synchronize files", data.ShortDescription)
+	assert.Equal(t, "Sync copies the changed files.\n", data.Description)
+	assert.Equal(t, "tool sync", data.Examples, "not overridden")
+	assert.Equal(t, "Symlinks are followed.", data.Bugs)
+	assert.Equal(t, []cobraman.SeeAlso{
+		{CmdPath: "tool", Section: "1", IsParent: true},
+		{CmdPath: "toolrc", Section: "5"},
+		{CmdPath: "tool push", Section: "1"},
+	}, data.SeeAlsos)
+
+	// without a long description, the description is the overridden short one
+	sync.Long = ""
+	data, err = cobraman.BuildDocData(sync, &cobraman.Options{DocOverrides: map[string]cobraman.DocOverride{
+		"tool sync": {Short: "Synchronize files"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "Synchronize files", data.ShortDescription)
+	assert.Equal(t, "Synchronize files", data.Description)
+
+	typo := filepath.Join(tmpD, "typo.yaml")
+	require.NoError(t, os.WriteFile(typo, []byte("tool sync:\n  lnog: text\n"), 0o600))
+	_, err = cobraman.LoadDocOverrides(typo)
+	assert.ErrorContains(t, err, "lnog")
+}
+
+func TestDocFiles(t *testing.T) {
+	tmpD := tempDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpD, "serve.md"), []byte("# Serving\n\nServes **all** files, see <https://example.com>.\n"), 0o600))
+
+	cmd := &cobra.Command{
+		Use:         "serve",
+		Long:        "Replaced.",
+		Annotations: map[string]string{"man-description-file": "serve.md"},
+		Run:         mkMockRunFunc(),
+	}
+	opts := &cobraman.Options{DocFileRoot: tmpD}
+	data, err := cobraman.BuildDocData(cmd, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "# Serving\n\nServes **all** files, see <https://example.com>.", data.Description)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "troff", buf))
+	assert.Contains(t, buf.String(), ".SH DESCRIPTION\n.PP\nServing\n.PP\nServes all files, see\n.UR https://example.com\n.UE .\n")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "markdown", buf))
+	assert.Contains(t, buf.String(), "# Serving\n\nServes **all** files")
+
+	cmd.Annotations["man-bugs-file"] = "missing.md"
+	_, err = cobraman.BuildDocData(cmd, opts)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFormatOverrides(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Example: "tool --verbose", Run: mkMockRunFunc()}
+	cmd.Flags().Bool("verbose", false, "say more")
+	opts := &cobraman.Options{
+		Author: "Jane Doe",
+		Bugs:   "Report them.",
+		FormatOverrides: map[string]cobraman.FormatOverride{
+			"markdown": {Exclude: []string{"AUTHOR", "BUGS"}},
+			"html":     {Include: []string{"OPTIONS"}},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "troff", buf))
+	assert.Contains(t, buf.String(), ".SH BUGS")
+	assert.Contains(t, buf.String(), ".SH AUTHOR")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "markdown", buf))
+	assert.NotContains(t, buf.String(), "### Bugs")
+	assert.NotContains(t, buf.String(), "### Author")
+	assert.Contains(t, buf.String(), "### Examples")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "html", buf))
+	assert.Contains(t, buf.String(), "<h2>Options</h2>")
+	assert.NotContains(t, buf.String(), "<h2>Examples</h2>")
+	assert.NotContains(t, buf.String(), "<h2>Author</h2>")
+
+	data, err := cobraman.BuildDocData(cmd, opts)
+	require.NoError(t, err)
+	assert.True(t, data.Includes("AUTHOR"), "BuildDocData is not for a format")
+
+	bad := &cobraman.Options{FormatOverrides: map[string]cobraman.FormatOverride{"troff": {Exclude: []string{"AUTHORS"}}}}
+	assert.ErrorIs(t, cobraman.GenerateOnePage(cmd, bad, "troff", buf), cobraman.ErrInvalidOptions)
+}
+
+func TestGenerateDocsWithWarnings(t *testing.T) {
+	root := &cobra.Command{Use: "tool", Short: "A tool", Run: mkMockRunFunc()}
+	root.PersistentFlags().String("config", "", "")
+	sync := &cobra.Command{
+		Use:         "sync",
+		Long:        "Sync\x00 files.",
+		Annotations: map[string]string{"man-file-section": "typo", "man-bug-section": "typo"},
+		Run:         mkMockRunFunc(),
+	}
+	sync.Flags().Bool("force", false, "overwrite")
+	require.NoError(t, sync.Flags().SetAnnotation("force", "man-flag-grop", []string{"Output"}))
+	root.AddCommand(sync)
+
+	want := []string{
+		"tool: --config: no usage",
+		"tool sync: no short description",
+		"tool sync: description has control characters or invalid UTF-8",
+		"tool sync: unknown annotation man-bug-section",
+		"tool sync: unknown annotation man-file-section",
+		"tool sync: --force: unknown annotation man-flag-grop",
+	}
+	warnings, err := cobraman.GenerateDocsWithWarnings(root, &cobraman.Options{Parallel: 2}, tempDir(t), "troff")
+	require.NoError(t, err)
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, cobraman.WarnMissingUsage, warnings[0].Kind)
+	assert.Equal(t, cobraman.WarnUnknownAnnotation, warnings[5].Kind)
+
+	// the pages of every locale have the same warnings, which are reported once
+	warnings, err = cobraman.GenerateDocsWithWarnings(root, &cobraman.Options{Locales: []string{"", "de", "fr"}}, tempDir(t), "troff")
+	require.NoError(t, err)
+	got = nil
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestStrict(t *testing.T) {
+	root := &cobra.Command{Use: "tool", Short: "A tool", Run: mkMockRunFunc()}
+	root.Flags().String("config", "", "the config file")
+	require.NoError(t, cobraman.GenerateDocs(root, &cobraman.Options{Strict: true}, tempDir(t), "troff"))
+
+	sync := &cobra.Command{Use: "sync", Run: mkMockRunFunc()}
+	root.AddCommand(sync)
+	err := cobraman.GenerateDocs(root, &cobraman.Options{Strict: true}, tempDir(t), "troff")
+	assert.ErrorIs(t, err, cobraman.ErrIncompleteDocs)
+	assert.ErrorContains(t, err, "tool sync: no short description")
+
+	sync.Short = "Sync"
+	sync.Annotations = map[string]string{"man-file-section": "typo", "man-bug-section": "typo", "man-exit-section": "typo"}
+	err = cobraman.GenerateDocs(root, &cobraman.Options{Strict: true}, tempDir(t), "troff")
+	assert.ErrorContains(t, err, "unknown annotation man-bug-section; tool sync: unknown annotation man-exit-section; tool sync: unknown annotation man-file-section")
+
+	err = cobraman.GenerateDocs(root, &cobraman.Options{Strict: true, ContinueOnError: true}, tempDir(t), "troff")
+	var pageErr *cobraman.PageError
+	require.ErrorAs(t, err, &pageErr)
+	assert.Equal(t, "tool sync", pageErr.CommandPath)
+}
+
+func TestHeadingCase(t *testing.T) {
+	root := &cobra.Command{Use: "tool", Short: "A tool"}
+	root.AddCommand(&cobra.Command{Use: "sync", Short: "Sync", Run: mkMockRunFunc()})
+	opts := &cobraman.Options{FormatOverrides: map[string]cobraman.FormatOverride{
+		"troff":    {HeadingCase: cobraman.HeadingSentence},
+		"markdown": {HeadingCase: cobraman.HeadingUpper},
+		"html":     {HeadingCase: cobraman.HeadingSentence},
+	}}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(root, opts, "troff", buf))
+	assert.Contains(t, buf.String(), ".SH Name\n")
+	assert.Contains(t, buf.String(), ".SH See also\n")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts, "markdown", buf))
+	assert.Contains(t, buf.String(), "### SYNOPSIS\n")
+	assert.Contains(t, buf.String(), "### SEE ALSO\n")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts, "html", buf))
+	assert.Contains(t, buf.String(), "<h2>See also</h2>")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts, "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Sh SEE ALSO\n", "the default is kept")
+
+	opts.FormatOverrides["mdoc"] = cobraman.FormatOverride{HeadingCase: cobraman.HeadingTitle}
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts, "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Sh See Also\n")
+}
+
+func TestSectionEntries(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Run: mkMockRunFunc()}
+	opts := &cobraman.Options{
+		EnvironmentEntries: []cobraman.SectionEntry{{Name: "TOOL_HOME", Description: "Where tool keeps its data."}},
+		FilesEntries:       []cobraman.SectionEntry{{Name: "/etc/toolrc", Description: "The system-wide configuration."}},
+	}
+
+	for format, want := range map[string][]string{
+		"troff": {
+			".SH ENVIRONMENT\n.TP\n\\fBTOOL_HOME\\fP\nWhere tool keeps its data.\n",
+			".SH FILES\n.TP\n\\fI/etc/toolrc\\fP\nThe system-wide configuration.\n",
+		},
+		"mdoc": {
+			".Sh ENVIRONMENT\n.Bl -tag -width Ds\n.It Ev TOOL_HOME\nWhere tool keeps its data.\n.El\n",
+			".Sh FILES\n.Bl -tag -width Ds\n.It Pa /etc/toolrc\nThe system-wide configuration.\n.El\n",
+		},
+		"markdown": {
+			"### Environment\n\n* TOOL_HOME - Where tool keeps its data.\n",
+			"### Files\n\n* /etc/toolrc - The system-wide configuration.\n",
+		},
+		"html": {
+			"<h2>Environment</h2>\n<dl>\n<dt><code>TOOL_HOME</code></dt>\n<dd>Where tool keeps its data.</dd>\n</dl>",
+			"<h2>Files</h2>\n<dl>\n<dt><code>/etc/toolrc</code></dt>\n<dd>The system-wide configuration.</dd>\n</dl>",
+		},
+	} {
+		buf := new(bytes.Buffer)
+		require.NoError(t, cobraman.GenerateOnePage(cmd, opts, format, buf))
+		for _, w := range want {
+			assert.Contains(t, buf.String(), w, format)
+		}
+	}
+}
+
+func TestExampleTitles(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Run: mkMockRunFunc(), Example: `  # List all files
+  tool ls
+
+  # Copy a file:
+  tool cp a b
+  tool cp -r dir other`}
+
+	data, err := cobraman.BuildDocData(cmd, &cobraman.Options{})
+	require.NoError(t, err)
+	assert.Nil(t, data.ExampleGroups)
+
+	opts := &cobraman.Options{ExampleTitles: true}
+	data, err = cobraman.BuildDocData(cmd, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []cobraman.ExampleGroup{
+		{Title: "List all files", Examples: "  tool ls"},
+		{Title: "Copy a file", Examples: "  tool cp a b\n  tool cp -r dir other"},
+	}, data.ExampleGroups)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "troff", buf))
+	assert.Contains(t, buf.String(), dedent(`.SH EXAMPLES
+		.SS "List all files"
+		.RS 4
+		.nf
+		tool ls
+		.fi
+		.RE
+		.SS "Copy a file"`))
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "markdown", buf))
+	assert.Contains(t, buf.String(), "### Examples\n\n#### List all files\n\n```sh\ntool ls\n```\n\n#### Copy a file\n")
+
+	cmd.Example = "# Copy a \"file\"\ntool cp a b"
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Ss Copy a \\(dqfile\\(dq\n")
+
+	cmd.Example = "# Not all blocks are titled\ntool ls\n\ntool cp a b"
+	data, err = cobraman.BuildDocData(cmd, opts)
+	require.NoError(t, err)
+	assert.Nil(t, data.ExampleGroups)
+}
+
+func TestMarkdownTOC(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Short: "A tool", Example: "tool", Run: mkMockRunFunc()}
+	cmd.Flags().Bool("verbose", false, "say more")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{MarkdownTOC: true}, "markdown", buf))
+	assert.Contains(t, buf.String(), "A tool\n\n* [Synopsis](#synopsis)\n* [Description](#description)\n"+
+		"* [Options](#options)\n* [Examples](#examples)\n* [Author](#author)\n\n### Synopsis\n")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{MarkdownTOC: true}, "troff", buf))
+	assert.NotContains(t, buf.String(), "(#synopsis)")
+}
+
+func TestMetadata(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Short: "A tool", Run: mkMockRunFunc()}
+	opts := &cobraman.Options{
+		Metadata: map[string]interface{}{"category": "cli", "weight": 10},
+		MetadataFunc: func(cmd *cobra.Command) map[string]interface{} {
+			return map[string]interface{}{"edit_url": "https://example.com/edit/" + cmd.Name() + ".go"}
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "markdown", buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "---\n"+
+		"category: cli\n"+
+		"edit_url: https://example.com/edit/tool.go\n"+
+		"weight: 10\n"+
+		"---\n\n## tool\n"), buf.String())
+
+	opts.Provenance = true
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "markdown", buf))
+	assert.Contains(t, buf.String(), "weight: 10\n---\n<!--\nGenerated by")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "markdown", buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "## tool\n"))
+}
+
+func TestHTMLLayout(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Short: "A tool", Run: mkMockRunFunc()}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, &cobraman.Options{}, "html", buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n"+
+		"<meta charset=\"utf-8\">\n"+
+		"<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n"+
+		"<title>tool</title>\n</head>\n<body>\n"+
+		"<a class=\"skip-link\" href=\"#content\">Skip to content</a>\n"+
+		"<main id=\"content\">\n<h1>tool</h1>\n"), buf.String())
+	assert.True(t, strings.HasSuffix(buf.String(), "</pre>\n\n<h2>Description</h2>\n<p>A tool</p>\n</main>\n</body>\n</html>\n"), buf.String())
+
+	opts := &cobraman.Options{HTMLLayout: cobraman.HTMLLayout{
+		Stylesheet: "/css/site.css",
+		Header:     `<nav><a href="/">Home</a></nav>`,
+		Footer:     "<footer>Example Inc.</footer>",
+	}}
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "html", buf))
+	assert.Contains(t, buf.String(), "<title>tool</title>\n"+
+		"<link rel=\"stylesheet\" href=\"/css/site.css\">\n</head>\n"+
+		"<body>\n<a class=\"skip-link\" href=\"#content\">Skip to content</a>\n"+
+		"<nav><a href=\"/\">Home</a></nav>\n<main id=\"content\">\n<h1>tool</h1>\n")
+	assert.True(t, strings.HasSuffix(buf.String(), "<p>A tool</p>\n</main>\n<footer>Example Inc.</footer>\n</body>\n</html>\n"), buf.String())
+
+	opts.HTMLLayout.Template = `<article class="{{ .CommandPath | underscoreify }}">{{ template "body" . }}</article>`
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "html", buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "<article class=\"tool\"><h1>tool</h1>\n"), buf.String())
+	assert.True(t, strings.HasSuffix(buf.String(), "<p>A tool</p></article>"), buf.String())
+
+	// Only HTML pages have a layout
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "markdown", buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "## tool\n"))
+
+	opts.HTMLLayout.Template = "{{ template "
+	assert.Error(t, cobraman.GenerateOnePage(cmd, opts, "html", buf))
+}
+
+func TestHTMLSemantics(t *testing.T) {
+	root := &cobra.Command{Use: "tool", Short: "A tool", Run: mkMockRunFunc()}
+	root.Flags().StringP("name", "n", "", "The `name` to use")
+	root.Flags().Bool("old", false, "Old behavior")
+	require.NoError(t, root.Flags().MarkDeprecated("old", "do not use"))
+	root.AddCommand(&cobra.Command{Use: "sync", Short: "Sync things", Run: mkMockRunFunc()})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(root, &cobraman.Options{IncludeDeprecated: true}, "html", buf))
+	assert.Contains(t, buf.String(), "<h2>Options</h2>\n<dl>\n"+
+		"<dt><code>-n, --name=&lt;name&gt;</code></dt>\n<dd>The name to use</dd>\n</dl>\n")
+	assert.Contains(t, buf.String(), "<h3>Deprecated options</h3>\n<dl>\n"+
+		"<dt><code>--old</code></dt>\n<dd>do not use</dd>\n</dl>\n")
+	assert.Contains(t, buf.String(), "<h2>See Also</h2>\n<nav aria-label=\"See Also\">\n<ul>\n"+
+		"<li><a href=\"tool_sync.html\">tool sync</a></li>\n</ul>\n</nav>\n</main>\n")
+}
+
+func TestRegisterSectionProvider(t *testing.T) {
+	cobraman.RegisterSectionProvider("TELEMETRY", func(cmd *cobra.Command) string {
+		if cmd.Annotations["telemetry"] == "" {
+			return ""
+		}
+		return cmd.CommandPath() + " sends " + cmd.Annotations["telemetry"] + "."
+	})
+	cobraman.RegisterSectionProvider("CONFIG PRECEDENCE", func(cmd *cobra.Command) string {
+		return "Flags override the environment."
+	})
+	t.Cleanup(func() {
+		cobraman.RegisterSectionProvider("TELEMETRY", nil)
+		cobraman.RegisterSectionProvider("CONFIG PRECEDENCE", nil)
+	})
+
+	root := &cobra.Command{Use: "tool", Short: "A tool", Run: mkMockRunFunc()}
+	sync := &cobra.Command{Use: "sync", Short: "Sync things", Run: mkMockRunFunc(),
+		Annotations: map[string]string{"telemetry": "usage counts"}}
+	root.AddCommand(sync)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(sync, &cobraman.Options{}, "troff", buf))
+	assert.Contains(t, buf.String(), ".SH TELEMETRY\n.PP\ntool sync sends usage counts.\n"+
+		".SH CONFIG PRECEDENCE\n.PP\nFlags override the environment.\n.SH SEE ALSO\n")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, &cobraman.Options{}, "troff", buf))
+	assert.NotContains(t, buf.String(), "TELEMETRY")
+	assert.Contains(t, buf.String(), ".SH CONFIG PRECEDENCE\n")
+
+	// in the BSD order of sections, AUTHOR follows SEE ALSO
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sync, &cobraman.Options{ManStyle: cobraman.ManStyleBSD, Author: "Jane"}, "mdoc", buf))
+	assert.Regexp(t, `(?s)\.Sh TELEMETRY\n.*\.Sh CONFIG PRECEDENCE\n.*\.Sh SEE ALSO\n.*\.Sh AUTHOR\n`, buf.String())
+
+	opts := &cobraman.Options{FormatOverrides: map[string]cobraman.FormatOverride{
+		"markdown": {Exclude: []string{"CONFIG PRECEDENCE"}, HeadingCase: cobraman.HeadingTitle},
+	}}
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sync, opts, "markdown", buf))
+	assert.Contains(t, buf.String(), "### Telemetry\n\ntool sync sends usage counts.\n")
+	assert.NotContains(t, buf.String(), "Config Precedence")
+
+	cobraman.RegisterSectionProvider("CONFIG PRECEDENCE", nil)
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sync, &cobraman.Options{}, "html", buf))
+	assert.Contains(t, buf.String(), "<h2>TELEMETRY</h2>\n<p>tool sync sends usage counts.</p>\n")
+	assert.NotContains(t, buf.String(), "CONFIG PRECEDENCE")
+
+	// titles are macro arguments, where a double quote would end the title
+	cobraman.RegisterSectionProvider(`"FAIR" USE`, func(cmd *cobra.Command) string { return "Be fair." })
+	t.Cleanup(func() { cobraman.RegisterSectionProvider(`"FAIR" USE`, nil) })
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sync, &cobraman.Options{}, "troff", buf))
+	assert.Contains(t, buf.String(), ".SH \\(dqFAIR\\(dq USE\n.PP\nBe fair.\n")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(sync, &cobraman.Options{}, "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Sh \\(dqFAIR\\(dq USE\nBe fair.\n")
+}
+
+func TestCommandHooks(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	root.AddCommand(mkCobraCmd("sub", true))
+	dir := tempDir(t)
+
+	var calls []string
+	opts := cobraman.Options{
+		BeforeCommand: func(cmd *cobra.Command, filename string) error {
+			_, err := os.Stat(filename)
+			assert.True(t, os.IsNotExist(err), filename)
+			calls = append(calls, "before "+cmd.CommandPath()+" "+filepath.Base(filename))
+			return nil
+		},
+		AfterCommand: func(cmd *cobra.Command, filename string) error {
+			assert.FileExists(t, filename)
+			calls = append(calls, "after "+cmd.CommandPath()+" "+filepath.Base(filename))
+			return nil
+		},
+	}
+	require.NoError(t, cobraman.GenerateDocs(root, &opts, dir, "troff"))
+	assert.Equal(t, []string{
+		"before tool sub tool-sub.1", "after tool sub tool-sub.1",
+		"before tool tool.1", "after tool tool.1",
+	}, calls)
+
+	errBoom := errors.New("boom")
+	opts.BeforeCommand = func(cmd *cobra.Command, filename string) error { return errBoom }
+	calls = nil
+	assert.ErrorIs(t, cobraman.GenerateDocs(root, &opts, tempDir(t), "troff"), errBoom)
+	assert.Empty(t, calls)
+}
+
+func TestFileMode(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	root.AddCommand(mkCobraCmd("sub", true))
+
+	opts := cobraman.Options{
+		Profiles: []cobraman.Profile{{Name: "public"}},
+		FileMode: 0o666,
+		DirMode:  0o777,
+	}
+	tmpD := tempDir(t)
+	files, err := cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o666), info.Mode().Perm(), file)
+	}
+	info, err := os.Stat(filepath.Join(tmpD, "public"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o777), info.Mode().Perm())
+}
+
+func TestMemFS(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	root.AddCommand(mkCobraCmd("sub", true))
+
+	mem := &cobraman.MemFS{}
+	opts := cobraman.Options{
+		FS:       mem,
+		Profiles: []cobraman.Profile{{Name: "public"}},
+		FileMode: 0o600,
+		DirMode:  0o700,
+	}
+	files, err := cobraman.GenerateDocsFiles(root, &opts, "man", "troff")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("man", "public", "tool-sub.1"),
+		filepath.Join("man", "public", "tool.1"),
+	}, files)
+	require.NoError(t, fstest.TestFS(mem, "man/public/tool-sub.1", "man/public/tool.1"))
+
+	page, err := fs.ReadFile(mem, "man/public/tool.1")
+	require.NoError(t, err)
+	assert.Contains(t, string(page), ".TH \"TOOL\" \"1\"")
+	info, err := fs.Stat(mem, "man/public/tool.1")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), info.Mode())
+	info, err = fs.Stat(mem, "man/public")
+	require.NoError(t, err)
+	assert.Equal(t, fs.ModeDir|0o700, info.Mode())
+
+	_, err = os.Stat("man")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNow(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Short: "A tool", Run: mkMockRunFunc()}
+	now := time.Date(2021, time.March, 14, 15, 9, 26, 0, time.UTC)
+	opts := &cobraman.Options{Now: func() time.Time { return now }, Provenance: true}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(cmd, opts, "troff", buf))
+	assert.Contains(t, buf.String(), `.\" Dated 2021-03-14, the time of generation.`)
+	assert.Contains(t, buf.String(), `.TH "TOOL" "1" "Mar 2021"`)
+	assert.Equal(t, now, *opts.Date)
+}
+
+func TestSectionFunc(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	admin := mkCobraCmd("admin", false)
+	admin.AddCommand(mkCobraCmd("user", true))
+	root.AddCommand(admin, mkCobraCmd("run", true))
+
+	opts := cobraman.Options{
+		SectionFunc: func(cmd *cobra.Command) string {
+			if strings.HasPrefix(cmd.CommandPath(), "tool admin") {
+				return "8"
+			}
+			return ""
+		},
+	}
+	tmpD := tempDir(t)
+	files, err := cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpD, "tool-admin-user.8"),
+		filepath.Join(tmpD, "tool-admin.8"),
+		filepath.Join(tmpD, "tool-run.1"),
+		filepath.Join(tmpD, "tool.1"),
+	}, files)
+
+	page, err := os.ReadFile(filepath.Join(tmpD, "tool-admin.8"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `.TH "TOOL\-ADMIN" "8"`)
+	assert.Contains(t, string(page), ".BR tool (1)\n.BR tool\\-run (1)\n.BR tool\\-admin\\-user (8)\n")
+	page, err = os.ReadFile(filepath.Join(tmpD, "tool.1"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `.TH "TOOL" "1"`)
+	assert.Contains(t, string(page), ".BR tool\\-admin (8)\n.BR tool\\-run (1)\n")
+
+	// the subcommands wrapped for NoArgsFunc are still assigned sections
+	withNoArgs := opts
+	withNoArgs.NoArgsFunc = func(string) (bool, bool) { return false, false }
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(root, &withNoArgs, "troff", buf))
+	assert.Contains(t, buf.String(), ".BR tool\\-admin (8)\n.BR tool\\-run (1)\n")
+	assert.NotContains(t, buf.String(), "tool\\-admin (1)")
+
+	opts.Locales = []string{"", "de"}
+	files, err = cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+	require.NoError(t, err)
+	assert.Contains(t, files, filepath.Join(tmpD, "de", "man8", "tool-admin.8"))
+	assert.Contains(t, files, filepath.Join(tmpD, "de", "man1", "tool.1"))
+	assert.Contains(t, files, filepath.Join(tmpD, "man8", "tool-admin-user.8"))
+
+	pages := cobraman.NewPageFS(os.DirFS(tmpD), &cobraman.Options{SectionFunc: opts.SectionFunc}, "troff")
+	buf.Reset()
+	pages.AddManCommand(root)
+	root.SetOut(buf)
+	root.SetArgs([]string{"man", "admin"})
+	require.NoError(t, root.Execute())
+	assert.Contains(t, buf.String(), `.TH "TOOL\-ADMIN" "8"`)
+}
+
+func TestSectionSuffixes(t *testing.T) {
+	for _, section := range []string{"1m", "3pm", "8c", "n"} {
+		root := mkCobraCmd("tool", false)
+		root.AddCommand(mkCobraCmd("sub", true))
+
+		opts := cobraman.Options{Section: section}
+		tmpD := tempDir(t)
+		files, err := cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(tmpD, "tool-sub."+section),
+			filepath.Join(tmpD, "tool."+section),
+		}, files)
+		page, err := os.ReadFile(filepath.Join(tmpD, "tool-sub."+section))
+		require.NoError(t, err)
+		assert.Contains(t, string(page), `.TH "TOOL\-SUB" "`+section+`"`)
+		assert.Contains(t, string(page), ".BR tool ("+section+")")
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, cobraman.GenerateOnePage(root, &cobraman.Options{Section: section}, "mdoc", buf))
+		assert.Contains(t, buf.String(), ".Dt TOOL "+section+"\n")
+		assert.Contains(t, buf.String(), ".Xr tool\\-sub "+section+"\n")
+
+		opts.Locales = []string{"de"}
+		files, err = cobraman.GenerateDocsFiles(root, &opts, tmpD, "troff")
+		require.NoError(t, err)
+		dir := "man" + strings.TrimRight(section, "abcdefghijklmnopqrstuvwxyz")
+		if section == "n" {
+			dir = "mann"
+		}
+		assert.Contains(t, files, filepath.Join(tmpD, "de", dir, "tool."+section))
+	}
+}
+
+func TestManStyle(t *testing.T) {
+	root := &cobra.Command{Use: "tool", Short: "A tool", Example: "tool", Run: mkMockRunFunc(),
+		Annotations: map[string]string{"man-exit-status-section": "0 on success, 1 on failure."}}
+	root.AddCommand(&cobra.Command{Use: "sub", Short: "A subcommand", Run: mkMockRunFunc()})
+	opts := func(style cobraman.ManStyle, section string) *cobraman.Options {
+		return &cobraman.Options{
+			ManStyle:    style,
+			Section:     section,
+			LeftFooter:  "tool 1.0",
+			Files:       "/etc/toolrc",
+			Bugs:        "Report bugs.",
+			Author:      "Jane Doe",
+			History:     []cobraman.HistoryEntry{{Version: "1.0", Note: "First release.", Commands: []string{"tool"}}},
+			Environment: "TOOL_HOME",
+		}
+	}
+	headings := func(page, macro string) []string {
+		var headings []string
+		for _, line := range strings.Split(page, "\n") {
+			if heading, ok := strings.CutPrefix(line, macro+" "); ok {
+				headings = append(headings, heading)
+			}
+		}
+		return headings
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateOnePage(root, opts(cobraman.ManStyleGNU, "1"), "troff", buf))
+	assert.Equal(t, []string{"NAME", "SYNOPSIS", "DESCRIPTION", "EXIT STATUS", "ENVIRONMENT", "FILES",
+		"BUGS", "EXAMPLES", "HISTORY", "AUTHOR", "SEE ALSO"}, headings(buf.String(), ".SH"))
+	assert.Contains(t, buf.String(), ".SH EXIT STATUS\n.PP\n0 on success, 1 on failure.\n")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts(cobraman.ManStyleBSD, "1"), "troff", buf))
+	assert.Equal(t, []string{"NAME", "SYNOPSIS", "DESCRIPTION", "ENVIRONMENT", "FILES", "EXIT STATUS",
+		"EXAMPLES", "SEE ALSO", "HISTORY", "AUTHOR", "BUGS"}, headings(buf.String(), ".SH"))
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts(cobraman.ManStyleGNU, "1"), "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Os tool 1.0\n")
+	assert.Equal(t, []string{"NAME", "SYNOPSIS", "DESCRIPTION", "EXIT STATUS", "ENVIRONMENT", "FILES",
+		"BUGS", "EXAMPLES", "HISTORY", "AUTHOR", "SEE ALSO"}, headings(buf.String(), ".Sh"))
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts(cobraman.ManStyleBSD, "3"), "mdoc", buf))
+	assert.Contains(t, buf.String(), ".Os\n")
+	assert.Equal(t, []string{"NAME", "SYNOPSIS", "DESCRIPTION", "ENVIRONMENT", "FILES", "RETURN VALUES",
+		"EXAMPLES", "SEE ALSO", "HISTORY", "AUTHOR", "BUGS"}, headings(buf.String(), ".Sh"))
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts(cobraman.ManStyleGNU, "3pm"), "troff", buf))
+	assert.Contains(t, buf.String(), ".SH RETURN VALUE\n")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateOnePage(root, opts(cobraman.ManStyleBSD, "1"), "markdown", buf))
+	assert.Contains(t, buf.String(), "### Exit Status\n\n0 on success, 1 on failure.\n")
+}
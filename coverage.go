@@ -0,0 +1,146 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CoverageReport describes the documentation gaps found in a command tree.
+// It is created by Coverage.
+type CoverageReport struct {
+	// Commands holds one entry per documented command, in the order the
+	// commands are visited (parents before their children).
+	Commands []CommandCoverage
+}
+
+// CommandCoverage describes the documentation gaps of a single command.
+type CommandCoverage struct {
+	// CommandPath is the space separated path of the command (e.g. "git commit")
+	CommandPath string
+
+	// MissingLong is true if the command has no Long description
+	MissingLong bool
+
+	// MissingExample is true if the command has neither an Example nor a
+	// man-examples-section annotation
+	MissingExample bool
+
+	// FlagsMissingUsage lists the names of flags that have no Usage text
+	FlagsMissingUsage []string
+
+	// FlagsMissingArgHint lists the names of flags that take a value but
+	// have no man-arg-hints annotation
+	FlagsMissingArgHint []string
+}
+
+// Complete reports whether no gaps were found for the command.
+func (cc CommandCoverage) Complete() bool {
+	return !cc.MissingLong && !cc.MissingExample &&
+		len(cc.FlagsMissingUsage) == 0 && len(cc.FlagsMissingArgHint) == 0
+}
+
+// Complete reports whether no gaps were found for any command.
+func (r *CoverageReport) Complete() bool {
+	for _, cc := range r.Commands {
+		if !cc.Complete() {
+			return false
+		}
+	}
+	return true
+}
+
+// Coverage walks cmd and all of its children and reports what documentation
+// is missing.  Commands and flags that would not be documented by
+// GenerateDocs (hidden, deprecated, help topics) are skipped.
+func Coverage(cmd *cobra.Command) *CoverageReport {
+	report := &CoverageReport{}
+	addCoverage(report, cmd)
+	return report
+}
+
+func addCoverage(report *CoverageReport, cmd *cobra.Command) {
+	cc := CommandCoverage{
+		CommandPath:    cmd.CommandPath(),
+		MissingLong:    cmd.Long == "",
+		MissingExample: cmd.Example == "" && cmd.Annotations["man-examples-section"] == "",
+	}
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if len(flag.Deprecated) > 0 || flag.Hidden {
+			return
+		}
+		if flag.Usage == "" {
+			cc.FlagsMissingUsage = append(cc.FlagsMissingUsage, flag.Name)
+		}
+		hintArr := flag.Annotations["man-arg-hints"]
+		if flag.NoOptDefVal == "" && (len(hintArr) == 0 || hintArr[0] == "") {
+			cc.FlagsMissingArgHint = append(cc.FlagsMissingArgHint, flag.Name)
+		}
+	})
+	report.Commands = append(report.Commands, cc)
+
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		addCoverage(report, c)
+	}
+}
+
+// WriteMarkdown writes a markdown summary of the report to w: a table with one
+// row per command followed by a count of complete commands.
+func (r *CoverageReport) WriteMarkdown(w io.Writer) error {
+	complete := 0
+	_, err := fmt.Fprint(w, "| Command | Long | Example | Flags missing usage | Flags missing arg hint |\n"+
+		"|---|---|---|---|---|\n")
+	if err != nil {
+		return err
+	}
+	for _, cc := range r.Commands {
+		if cc.Complete() {
+			complete++
+		}
+		_, err = fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			cc.CommandPath,
+			checkmark(!cc.MissingLong),
+			checkmark(!cc.MissingExample),
+			flagList(cc.FlagsMissingUsage),
+			flagList(cc.FlagsMissingArgHint))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "\n%d of %d commands fully documented.\n", complete, len(r.Commands))
+	return err
+}
+
+func checkmark(ok bool) string {
+	if ok {
+		return "yes"
+	}
+	return "**no**"
+}
+
+func flagList(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return "--" + strings.Join(names, ", --")
+}
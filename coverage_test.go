@@ -0,0 +1,60 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverage(t *testing.T) {
+	root := &cobra.Command{Use: "tool", Long: "A tool.", Example: "tool run"}
+	root.Flags().String("file", "", "file to read")
+	_ = root.Flags().SetAnnotation("file", "man-arg-hints", []string{"path"})
+
+	sub := mkCobraCmd("sub", true)
+	sub.Flags().String("name", "", "")
+	sub.Flags().Bool("force", false, "force it")
+	sub.Flags().Bool("secret", false, "")
+	sub.Flags().Lookup("secret").Hidden = true
+
+	hidden := mkCobraCmd("hidden", true)
+	hidden.Hidden = true
+
+	root.AddCommand(sub, hidden)
+
+	report := cobraman.Coverage(root)
+	require.Len(t, report.Commands, 2)
+
+	assert.Equal(t, "tool", report.Commands[0].CommandPath)
+	assert.True(t, report.Commands[0].Complete())
+
+	cc := report.Commands[1]
+	assert.Equal(t, "tool sub", cc.CommandPath)
+	assert.True(t, cc.MissingLong)
+	assert.True(t, cc.MissingExample)
+	assert.Equal(t, []string{"name"}, cc.FlagsMissingUsage)
+	assert.Equal(t, []string{"name"}, cc.FlagsMissingArgHint)
+	assert.False(t, report.Complete())
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, report.WriteMarkdown(buf))
+	assert.Regexp(t, `\| tool sub \| \*\*no\*\* \| \*\*no\*\* \| --name \| --name \|`, buf.String())
+	assert.Regexp(t, "1 of 2 commands fully documented", buf.String())
+}
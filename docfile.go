@@ -0,0 +1,55 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// docFileAnnotations are the annotations naming a markdown file with the
+// content of a part of a page, and the field of DocData it is loaded into.
+var docFileAnnotations = []struct {
+	annotation string
+	field      func(*DocData) *string
+}{
+	{"man-description-file", func(d *DocData) *string { return &d.Description }},
+	{"man-environment-file", func(d *DocData) *string { return &d.Environment }},
+	{"man-files-file", func(d *DocData) *string { return &d.Files }},
+	{"man-bugs-file", func(d *DocData) *string { return &d.Bugs }},
+}
+
+// loadDocFiles loads the markdown files named by the man-*-file annotations
+// of a command into values, see Options.DocFileRoot.
+func loadDocFiles(values *DocData, annotations map[string]string, opts *Options) error {
+	for _, a := range docFileAnnotations {
+		name := sectionAnnotation(annotations, a.annotation, opts)
+		if name == "" {
+			continue
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(opts.DocFileRoot, name)
+		}
+		content, err := os.ReadFile(name) //nolint:gosec // reading the named file is the point
+		if err != nil {
+			return fmt.Errorf("%s of %q: %w", a.annotation, values.CommandPath, err)
+		}
+		field := a.field(values)
+		*field = strings.TrimSpace(string(content))
+		values.markdownFields = append(values.markdownFields, field)
+	}
+	return nil
+}
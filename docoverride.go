@@ -0,0 +1,134 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DocOverride is documentation of a command kept outside of its Go code, in
+// Options.DocOverrides, e.g. loaded from a sidecar file by
+// LoadDocOverrides so it can be edited by technical writers.  The fields
+// that are set replace what the command defines.
+type DocOverride struct {
+	Short       string `yaml:"short" json:"short"`
+	Long        string `yaml:"long" json:"long"`
+	Example     string `yaml:"example" json:"example"`
+	Environment string `yaml:"environment" json:"environment"`
+	Files       string `yaml:"files" json:"files"`
+	Bugs        string `yaml:"bugs" json:"bugs"`
+	// SeeAlso are further pages for the SEE ALSO section, given by command
+	// path, e.g. "tool sync", or as a reference to a page of another
+	// section, e.g. "toolrc(5)"
+	SeeAlso []string `yaml:"see_also" json:"see_also"`
+}
+
+// LoadDocOverrides reads the files at paths, each a YAML or JSON mapping of
+// command paths to their DocOverride, e.g.
+//
+//	tool sync:
+//	  long: |
+//	    Sync copies the files ...
+//	  see_also: [toolrc(5)]
+//
+// Later files override the fields set by earlier ones.  Unknown fields are
+// an error, so that misspelled fields are not silently ignored.
+func LoadDocOverrides(paths ...string) (map[string]DocOverride, error) {
+	overrides := make(map[string]DocOverride)
+	for _, path := range paths {
+		f, err := os.Open(path) //nolint:gosec // reading the named file is the point
+		if err != nil {
+			return nil, err
+		}
+		err = decodeDocOverrides(f, overrides)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return overrides, nil
+}
+
+// decodeDocOverrides decodes the overrides read from r into overrides.
+func decodeDocOverrides(r io.Reader, overrides map[string]DocOverride) error {
+	var file map[string]DocOverride
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	for commandPath, o := range file {
+		overrides[commandPath] = overrides[commandPath].merge(o)
+	}
+	return nil
+}
+
+// merge returns o with the fields that are set in other replaced.
+func (o DocOverride) merge(other DocOverride) DocOverride {
+	for _, field := range []struct{ dst, src *string }{
+		{&o.Short, &other.Short},
+		{&o.Long, &other.Long},
+		{&o.Example, &other.Example},
+		{&o.Environment, &other.Environment},
+		{&o.Files, &other.Files},
+		{&o.Bugs, &other.Bugs},
+	} {
+		if *field.src != "" {
+			*field.dst = *field.src
+		}
+	}
+	if other.SeeAlso != nil {
+		o.SeeAlso = other.SeeAlso
+	}
+	return o
+}
+
+// applyDocOverride replaces the fields of values that o sets.
+func applyDocOverride(values *DocData, o DocOverride) {
+	if o.Short != "" {
+		// a description that fell back to the short description follows it
+		if values.Description == values.ShortDescription {
+			values.Description = o.Short
+		}
+		values.ShortDescription = o.Short
+	}
+	if o.Long != "" {
+		values.Description = o.Long
+	}
+	if o.Example != "" {
+		values.Examples = o.Example
+	}
+	if o.Environment != "" {
+		values.Environment = o.Environment
+	}
+	if o.Files != "" {
+		values.Files = o.Files
+	}
+	if o.Bugs != "" {
+		values.Bugs = o.Bugs
+	}
+	for _, ref := range o.SeeAlso {
+		see := SeeAlso{CmdPath: ref, Section: values.Section}
+		if name, section, ok := strings.Cut(ref, "("); ok && strings.HasSuffix(section, ")") {
+			see.CmdPath, see.Section = name, strings.TrimSuffix(section, ")")
+		}
+		values.SeeAlsos = append(values.SeeAlsos, see)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctest
+
+import "strings"
+
+// diffContext is the number of unchanged lines shown around the changes of
+// a diff.
+const diffContext = 2
+
+// diff returns the lines of want and got that differ, prefixed with "-" and
+// "+" respectively, with a few unchanged lines, prefixed with " ", around
+// them.  Runs of unchanged lines that are left out are shown as "...".
+func diff(want, got string) string {
+	a := splitLines(want)
+	b := splitLines(got)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	skipped := false
+	for k, l := range lines {
+		if l.op == ' ' && !changedNear(k, func(k int) bool { return lines[k].op != ' ' }, len(lines)) {
+			if !skipped {
+				sb.WriteString("...\n")
+				skipped = true
+			}
+			continue
+		}
+		skipped = false
+		sb.WriteByte(l.op)
+		sb.WriteString(l.text)
+		if !strings.HasSuffix(l.text, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	return sb.String()
+}
+
+// changedNear reports whether one of the lines within diffContext of line k,
+// of n lines, is changed.
+func changedNear(k int, changed func(int) bool, n int) bool {
+	for c := max(0, k-diffContext); c <= min(n-1, k+diffContext); c++ {
+		if changed(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines returns the lines of text, with their line endings.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,154 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doctest guards the documentation generated by cobraman against
+// regressions, by comparing the pages of a command tree to golden files:
+//
+//	func TestManPages(t *testing.T) {
+//		doctest.Golden(t, cmd.NewRootCmd(), nil, "troff", "testdata/man")
+//	}
+//
+// Running the tests with the environment variable COBRAMAN_UPDATE_GOLDEN set
+// writes the golden files instead, to create them or accept a change.
+package doctest
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+)
+
+// UpdateEnv is the environment variable which, when set to a non-empty
+// value, makes Golden write the golden files instead of comparing them.
+const UpdateEnv = "COBRAMAN_UPDATE_GOLDEN"
+
+// Date is the date of the pages Golden generates unless Options.Date is set,
+// so the golden files do not change from day to day.
+var Date = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Golden generates the pages of cmd and its children with opts, which may be
+// nil, and the template templateName, and reports a test failure for every
+// page that differs from its golden file in dir, with a diff of the two, as
+// well as for pages without a golden file and golden files without a page.
+// The pages and golden files are compared after applying normalizers, e.g.
+// Dates, to them.
+func Golden(t testing.TB, cmd *cobra.Command, opts *cobraman.Options, templateName string, dir string, normalizers ...Normalizer) {
+	t.Helper()
+	var o cobraman.Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Date == nil {
+		date := Date
+		o.Date = &date
+	}
+	mem := &cobraman.MemFS{}
+	o.FS = mem
+	if err := cobraman.GenerateDocs(cmd, &o, "", templateName); err != nil {
+		t.Fatalf("generating the pages: %v", err)
+		return
+	}
+	pages, err := readFiles(mem)
+	if err != nil {
+		t.Fatalf("reading the generated pages: %v", err)
+		return
+	}
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := update(dir, pages); err != nil {
+			t.Fatalf("updating the golden files: %v", err)
+		}
+		return
+	}
+
+	golden, err := readFiles(os.DirFS(dir))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("reading the golden files: %v", err)
+		return
+	}
+	for _, name := range names(pages, golden) {
+		want, hasGolden := golden[name]
+		got, hasPage := pages[name]
+		want, got = normalize(want, normalizers), normalize(got, normalizers)
+		switch {
+		case !hasGolden:
+			t.Errorf("%s: no golden file, run the test with %s=1 to create it", name, UpdateEnv)
+		case !hasPage:
+			t.Errorf("%s: golden file of a page that is no longer generated", name)
+		case !bytes.Equal(want, got):
+			t.Errorf("%s differs from the golden file (-golden +generated):\n%s", name, diff(string(want), string(got)))
+		}
+	}
+}
+
+// readFiles returns the contents of the regular files of fsys by their
+// slash separated names.
+func readFiles(fsys fs.FS) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		files[name] = data
+		return err
+	})
+	return files, err
+}
+
+// names returns the sorted names of the files of a and b.
+func names(a, b map[string][]byte) []string {
+	var names []string
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// update replaces the golden files in dir with pages.
+func update(dir string, pages map[string][]byte) error {
+	golden, err := readFiles(os.DirFS(dir))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for name := range golden {
+		if _, ok := pages[name]; !ok {
+			if err := os.Remove(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+				return err
+			}
+		}
+	}
+	for name, data := range pages {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // docs are world readable
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil { //nolint:gosec // docs are world readable
+			return err
+		}
+	}
+	return nil
+}
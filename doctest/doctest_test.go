@@ -0,0 +1,144 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a testing.TB recording the failures reported to it.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) { r.Errorf(format, args...) }
+
+func newTree(short string) *cobra.Command {
+	root := &cobra.Command{Use: "tool", Short: short, Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(&cobra.Command{Use: "sub", Short: "A subcommand", Run: func(*cobra.Command, []string) {}})
+	return root
+}
+
+func TestGolden(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "man")
+
+	r := &recorder{TB: t}
+	Golden(r, newTree("A tool"), nil, "troff", dir)
+	assert.Equal(t, []string{
+		"tool-sub.1: no golden file, run the test with COBRAMAN_UPDATE_GOLDEN=1 to create it",
+		"tool.1: no golden file, run the test with COBRAMAN_UPDATE_GOLDEN=1 to create it",
+	}, r.errors)
+
+	t.Setenv(UpdateEnv, "1")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool-old.1"), []byte("stale"), 0o644))
+	r = &recorder{TB: t}
+	Golden(r, newTree("A tool"), nil, "troff", dir)
+	assert.Empty(t, r.errors)
+	assert.FileExists(t, filepath.Join(dir, "tool.1"))
+	assert.NoFileExists(t, filepath.Join(dir, "tool-old.1"))
+
+	t.Setenv(UpdateEnv, "")
+	r = &recorder{TB: t}
+	Golden(r, newTree("A tool"), nil, "troff", dir)
+	assert.Empty(t, r.errors)
+
+	r = &recorder{TB: t}
+	Golden(r, newTree("A better tool"), nil, "troff", dir)
+	require.Len(t, r.errors, 1)
+	assert.Equal(t, "tool.1 differs from the golden file (-golden +generated):\n"+
+		"...\n"+
+		" .ad l  \n"+
+		" .SH NAME\n"+
+		"-tool - A tool\n"+
+		"+tool - A better tool\n"+
+		" .SH SYNOPSIS\n"+
+		" .sp\n"+
+		"...\n"+
+		" .SH DESCRIPTION\n"+
+		" .PP\n"+
+		"-A tool\n"+
+		"+A better tool\n"+
+		" .SH AUTHOR\n"+
+		" .PP\n"+
+		"...\n", r.errors[0])
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool-old.1"), []byte("stale"), 0o644))
+	r = &recorder{TB: t}
+	Golden(r, newTree("A tool"), nil, "troff", dir)
+	assert.Equal(t, []string{"tool-old.1: golden file of a page that is no longer generated"}, r.errors)
+}
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, "-b\n+B\n", diff("b\n", "B\n"))
+	assert.Equal(t, " a\n+b\n c\n", diff("a\nc\n", "a\nb\nc\n"))
+	assert.Equal(t, " a\n-b\n+b\n\\ No newline at end of file\n", diff("a\nb\n", "a\nb"))
+	assert.Equal(t, "...\n 3\n 4\n-5\n+five\n 6\n 7\n...\n", diff("1\n2\n3\n4\n5\n6\n7\n8\n9\n", "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"))
+}
+
+func TestNormalizers(t *testing.T) {
+	dir := t.TempDir()
+	opts := &cobraman.Options{Provenance: true, LeftFooter: "tool v1.2.3"}
+
+	t.Setenv(UpdateEnv, "1")
+	Golden(t, newTree("A tool"), opts, "troff", dir)
+	t.Setenv(UpdateEnv, "")
+
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	changed := &cobraman.Options{Provenance: true, LeftFooter: "tool v1.3.0-rc.1", Date: &date}
+	r := &recorder{TB: t}
+	Golden(r, newTree("A tool"), changed, "troff", dir)
+	assert.Len(t, r.errors, 2)
+
+	Golden(t, newTree("A tool"), changed, "troff", dir, Dates, Versions, GeneratorComments)
+
+	for _, tc := range []struct {
+		normalizer Normalizer
+		page, want string
+	}{
+		{Dates, `.TH "TOOL" "1" "May 2024" "" ""`, `.TH "TOOL" "1" "DATE" "" ""`},
+		{Dates, ".Dd May 1, 2024\n", ".Dd DATE\n"},
+		{Dates, "Released 2024-05-01 in Maybe 12345", "Released DATE in Maybe 12345"},
+		{Versions, "tool v1.2.3 and 2.0.0-beta.2+build.5, not 1.2", "tool VERSION and VERSION, not 1.2"},
+		{Versions, `"tool 1.3.0\-rc.1"`, `"tool VERSION"`},
+		{GeneratorComments,
+			".\\\" Generated by github.com/carlwr/cobraman (devel) for the command \"tool\".\n" +
+				".\\\" Dated 2024-05-01 from Options.Date.\n.TH \"TOOL\"\n",
+			".TH \"TOOL\"\n"},
+		{GeneratorComments,
+			"<!--\nGenerated by github.com/carlwr/cobraman v1.0.0 for the command \"tool\".\n" +
+				"Dated 2024-05-01, the time of generation.\n-->\n## tool\n",
+			"## tool\n"},
+		{Replace(regexp.MustCompile(`build \d+`), "build N"), "build 42", "build N"},
+	} {
+		assert.Equal(t, tc.want, string(tc.normalizer([]byte(tc.page))))
+	}
+}
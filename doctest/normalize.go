@@ -0,0 +1,57 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctest
+
+import "regexp"
+
+// Normalizer rewrites content of a page that changes from run to run, such
+// as its date, to a canonical form.  Golden applies its normalizers to both
+// the generated pages and the golden files before comparing them, so the
+// golden files keep the content as it was generated.
+type Normalizer func(page []byte) []byte
+
+// Replace returns a Normalizer replacing the matches of re with repl, which
+// is expanded as by regexp.Regexp.ReplaceAll.
+func Replace(re *regexp.Regexp, repl string) Normalizer {
+	return func(page []byte) []byte { return re.ReplaceAll(page, []byte(repl)) }
+}
+
+var (
+	// Dates replaces the dates the built-in templates write, e.g.
+	// "2024-05-01", "May 2024" or "May 1, 2024", with "DATE".  Dates with
+	// the month names of Options.MonthNames are left alone.
+	Dates = Replace(regexp.MustCompile(
+		`\b\d{4}-\d{2}-\d{2}\b|\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]*\.? (?:\d{1,2}, )?\d{4}\b`),
+		"DATE")
+
+	// Versions replaces semantic versions, e.g. "v1.2.3" or "1.2.3-rc.1",
+	// with "VERSION", also with their hyphens escaped as in man pages.
+	Versions = Replace(regexp.MustCompile(
+		`\bv?\d+\.\d+\.\d+(?:\\?-[0-9A-Za-z.]+)*(?:\+[0-9A-Za-z.-]+)?\b`),
+		"VERSION")
+
+	// GeneratorComments removes the comment of Options.Provenance, which
+	// records the version of cobraman and the date of generation.
+	GeneratorComments = Replace(regexp.MustCompile(
+		`(?m)^(?:<!--\n)?(?:\.\\" )?Generated by github\.com/carlwr/cobraman .*\n(?:\.\\" )?Dated .*\n(?:-->\n)?`),
+		"")
+)
+
+// normalize returns page with normalizers applied in order.
+func normalize(page []byte, normalizers []Normalizer) []byte {
+	for _, n := range normalizers {
+		page = n(page)
+	}
+	return page
+}
@@ -0,0 +1,81 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/spf13/cobra"
+)
+
+// PageFS looks up pages that were generated ahead of time, typically into a
+// directory embedded into the application with go:embed.  It is created by
+// NewPageFS.
+type PageFS struct {
+	fsys  fs.FS
+	opts  *Options
+	isMan bool
+}
+
+// NewPageFS returns a PageFS for the pages in the root of fsys, which were
+// generated by GenerateDocs with opts and templateName.  Use fs.Sub if the
+// pages are in a subdirectory of fsys.  NewPageFS panics if templateName does
+// not exist.
+func NewPageFS(fsys fs.FS, opts *Options, templateName string) *PageFS {
+	// only the names of the pages matter here, which any options have
+	_ = validate(opts, templateName)
+	_, ext, _, _ := templ.GetTemplate(templateName)
+	return &PageFS{
+		fsys:  fsys,
+		opts:  opts,
+		isMan: ext == "use_section",
+	}
+}
+
+// Page returns the page of the command with the given space separated path
+// (e.g. "git commit").  The error wraps fs.ErrNotExist if there is no page.
+// Pages are looked up in Options.Section; use AddManCommand for pages placed
+// in other sections by Options.SectionFunc.
+func (p *PageFS) Page(commandPath string) ([]byte, error) {
+	return p.page(commandPath, p.opts.Section)
+}
+
+// page returns the page of the command with the given path in section.
+func (p *PageFS) page(commandPath string, section string) ([]byte, error) {
+	page, err := fs.ReadFile(p.fsys, pageFileName(commandPath, section, p.opts))
+	if err != nil {
+		return nil, fmt.Errorf("page of %q: %w", commandPath, err)
+	}
+	return page, nil
+}
+
+// AddManCommand is like the package level AddManCommand but shows the pages
+// of p instead of generating them.  Pages that are not man pages are written
+// as they are.
+func (p *PageFS) AddManCommand(root *cobra.Command) *cobra.Command {
+	return addManCommand(root, func(cmd *cobra.Command, w io.Writer) error {
+		page, err := p.page(cmd.CommandPath(), p.opts.sectionOf(NewCobraModel(cmd)))
+		if err != nil {
+			return err
+		}
+		if !p.isMan {
+			_, err = w.Write(page)
+			return err
+		}
+		return showManPage(w, page, nil)
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman_test
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/carlwr/cobraman"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageFS(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	root.AddCommand(mkCobraCmd("sub", true))
+
+	tmpD := tempDir(t)
+	require.NoError(t, cobraman.GenerateDocs(root, &cobraman.Options{Section: "8"}, tmpD, "troff"))
+
+	pages := cobraman.NewPageFS(os.DirFS(tmpD), &cobraman.Options{Section: "8"}, "troff")
+	page, err := pages.Page("tool sub")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\.TH "TOOL\\-SUB" "8"`, string(page))
+	_, err = pages.Page("tool nope")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	md := cobraman.NewPageFS(fstest.MapFS{
+		"tool_sub.md": {Data: []byte("# tool sub\n")},
+	}, &cobraman.Options{}, "markdown")
+	md.AddManCommand(root)
+
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetArgs([]string{"man", "sub"})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, "# tool sub\n", buf.String())
+
+	assert.Panics(t, func() { cobraman.NewPageFS(fstest.MapFS{}, &cobraman.Options{}, "nope") })
+}
@@ -0,0 +1,82 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnknownEncoding is returned for an unknown Options.Encoding.
+var ErrUnknownEncoding = errors.New("unknown encoding")
+
+// Values for Options.Encoding.
+const (
+	// EncodingUTF8 declares man pages to be UTF-8.
+	EncodingUTF8 = "utf-8"
+	// EncodingASCII writes man pages as ASCII, using \[uXXXX] escapes.
+	EncodingASCII = "ascii"
+)
+
+// utf8CodingLine is the coding tag recognized by preconv(1) and mandoc(1).
+const utf8CodingLine = ".\\\" -*- coding: UTF-8 -*-\n"
+
+// typographyReplacer replaces typographic characters by their roff escapes,
+// for Options.Typography.
+var typographyReplacer = strings.NewReplacer(
+	"\u201C", `\(lq`, // left double quotation mark
+	"\u201D", `\(rq`, // right double quotation mark
+	"\u2018", `\(oq`, // left single quotation mark
+	"\u2019", `\(cq`, // right single quotation mark
+	"\u2014", `\(em`, // em dash
+	"\u2013", `\(en`, // en dash
+	"\u2026", `\&.\|.\|.`, // horizontal ellipsis
+	"\u00A0", `\~`, // no-break space
+)
+
+// encodeManPage applies the Options.Encoding encoding to a man page.
+func encodeManPage(page []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return page, nil
+	case EncodingUTF8:
+		return append([]byte(utf8CodingLine), page...), nil
+	case EncodingASCII:
+		return asciiEscape(page), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownEncoding, encoding)
+}
+
+// asciiEscape replaces all runes of page that are not ASCII by groff
+// \[uXXXX] escapes.  Invalid UTF-8 is replaced by U+FFFD.
+func asciiEscape(page []byte) []byte {
+	if !bytes.ContainsFunc(page, func(r rune) bool { return r >= utf8.RuneSelf }) {
+		return page
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(page))
+	for len(page) > 0 {
+		r, size := utf8.DecodeRune(page)
+		if r < utf8.RuneSelf {
+			buf.WriteByte(page[0])
+		} else {
+			fmt.Fprintf(&buf, `\[u%04X]`, r)
+		}
+		page = page[size:]
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,117 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoding(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Short: "“quoted” café 😀"}
+
+	page := func(encoding string, templateName string) (string, error) {
+		buf := new(bytes.Buffer)
+		err := cobraman.GenerateOnePage(cmd, &cobraman.Options{Encoding: encoding, Author: "José"}, templateName, buf)
+		return buf.String(), err
+	}
+
+	out, err := page("", "troff")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\.TH`, out)
+	assert.Contains(t, out, "“quoted” café 😀")
+
+	out, err = page(cobraman.EncodingUTF8, "mdoc")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\.\\" -\*- coding: UTF-8 -\*-\n\.\\" Man page for tool\n`, out)
+
+	out, err = page(cobraman.EncodingASCII, "troff")
+	require.NoError(t, err)
+	assert.Contains(t, out, `\[u201C]quoted\[u201D] caf\[u00E9] \[u1F600]`)
+	assert.Contains(t, out, `Jos\[u00E9]`)
+
+	out, err = page(cobraman.EncodingASCII, "markdown")
+	require.NoError(t, err)
+	assert.Contains(t, out, "“quoted” café 😀")
+
+	_, err = page("latin1", "troff")
+	assert.ErrorIs(t, err, cobraman.ErrUnknownEncoding)
+}
+
+func TestTypography(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Short: "“quoted” ‘single’ — dash – range… end"}
+
+	page := func(typography bool, templateName string) string {
+		buf := new(bytes.Buffer)
+		opts := &cobraman.Options{Typography: typography, Encoding: cobraman.EncodingASCII}
+		require.NoError(t, cobraman.GenerateOnePage(cmd, opts, templateName, buf))
+		return buf.String()
+	}
+
+	assert.Contains(t, page(true, "troff"), `\(lqquoted\(rq \(oqsingle\(cq \(em dash \(en range\&.\|.\|. end`)
+	assert.Contains(t, page(true, "mdoc"), `.Nd \(lqquoted\(rq`)
+	assert.Contains(t, page(false, "troff"), `\[u201C]quoted\[u201D]`)
+	assert.Contains(t, page(true, "markdown"), "“quoted” ‘single’ — dash – range… end")
+}
+
+func TestHyphensAsMinus(t *testing.T) {
+	cmd := &cobra.Command{Use: "tool", Long: "A well-known tool, see --dry-run."}
+	cmd.Flags().Bool("dry-run", false, "do a well-behaved dry run")
+
+	page := func(hyphensAsMinus bool, templateName string) string {
+		buf := new(bytes.Buffer)
+		opts := &cobraman.Options{HyphensAsMinus: hyphensAsMinus}
+		require.NoError(t, cobraman.GenerateOnePage(cmd, opts, templateName, buf))
+		return buf.String()
+	}
+
+	out := page(false, "troff")
+	assert.Contains(t, out, `A well-known tool, see \-\-dry\-run.`)
+	assert.Contains(t, out, `\fB\-\-dry\-run\fP`)
+	assert.Contains(t, out, "do a well-behaved dry run")
+
+	out = page(true, "troff")
+	assert.Contains(t, out, `A well\-known tool, see \-\-dry\-run.`)
+	assert.Contains(t, out, `do a well\-behaved dry run`)
+
+	assert.Contains(t, page(true, "mdoc"), `A well\-known tool`)
+	assert.Contains(t, page(false, "mdoc"), `A well-known tool`)
+}
+
+func TestLineWidth(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:  "tool",
+		Long: "The first sentence is here. The second sentence is a little bit longer than the first one.",
+	}
+
+	page := func(opts *cobraman.Options, templateName string) string {
+		buf := new(bytes.Buffer)
+		require.NoError(t, cobraman.GenerateOnePage(cmd, opts, templateName, buf))
+		return buf.String()
+	}
+
+	assert.Contains(t, page(&cobraman.Options{SentencePerLine: true}, "troff"),
+		".PP\nThe first sentence is here.\nThe second sentence is a little bit longer than the first one.\n")
+	assert.Contains(t, page(&cobraman.Options{LineWidth: 40, SentencePerLine: true}, "mdoc"),
+		"The first sentence is here.\nThe second sentence is a little bit\nlonger than the first one.\n")
+	assert.Contains(t, page(&cobraman.Options{LineWidth: 40}, "markdown"),
+		"The first sentence is here. The second\nsentence is a little bit longer than the\nfirst one.\n")
+	assert.Contains(t, page(&cobraman.Options{}, "markdown"),
+		"The first sentence is here. The second sentence is a little bit longer than the first one.\n")
+}
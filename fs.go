@@ -0,0 +1,180 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// FS is the file system GenerateDocs writes the pages to, see Options.FS.
+// The names are those of the operating system, e.g. joined with the
+// directory passed to GenerateDocs by filepath.Join.
+type FS interface {
+	// Create creates or truncates the file name for writing.
+	Create(name string) (io.WriteCloser, error)
+	// MkdirAll creates the directory path and any missing parents.
+	MkdirAll(path string, perm os.FileMode) error
+	// Chmod changes the permissions of the file name to mode.
+	Chmod(name string, mode os.FileMode) error
+}
+
+// osFS is the FS of the operating system.
+type osFS struct{}
+
+func (osFS) Create(name string) (io.WriteCloser, error) {
+	return os.Create(name) //nolint:gosec // the file is constructed safely
+}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+func (osFS) Chmod(name string, mode os.FileMode) error { return os.Chmod(name, mode) }
+
+// fileSystem returns Options.FS, or the file system of the operating system
+// if it is not set.
+func (opts *Options) fileSystem() FS {
+	if opts.FS != nil {
+		return opts.FS
+	}
+	return osFS{}
+}
+
+// writeFile writes data to the file name of Options.FS, with
+// Options.FileMode.
+func writeFile(name string, data []byte, opts *Options) (err error) {
+	fsys := opts.fileSystem()
+	f, err := fsys.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	if opts.FileMode != 0 {
+		if err := fsys.Chmod(name, opts.FileMode); err != nil {
+			return err
+		}
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// MemFS is an FS holding the generated pages in memory, for tests and
+// pipelines that inspect the generated tree without writing it to disk.  It
+// is also an fs.FS, so the pages can be read with fs.ReadFile, fs.WalkDir and
+// the like.  The names of the files are slash separated and relative, i.e.
+// "man/tool.1" for a page written to "./man/tool.1" or "/man/tool.1".  The
+// zero value is an empty MemFS ready to use.
+type MemFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+// memName returns the fs.FS name of the file name.
+func memName(name string) string {
+	name = path.Clean(filepath.ToSlash(name))
+	name = strings.TrimLeft(name, "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// Create implements FS.
+func (m *MemFS) Create(name string) (io.WriteCloser, error) {
+	name = memName(name)
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = fstest.MapFS{}
+	}
+	mode := os.FileMode(0o644)
+	if f, ok := m.files[name]; ok {
+		mode = f.Mode
+	}
+	m.files[name] = &fstest.MapFile{Mode: mode, ModTime: time.Now()}
+	return &memFile{fs: m, name: name}, nil
+}
+
+// MkdirAll implements FS.
+func (m *MemFS) MkdirAll(path string, perm os.FileMode) error {
+	path = memName(path)
+	if !fs.ValidPath(path) {
+		return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrInvalid}
+	}
+	if path == "." {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = fstest.MapFS{}
+	}
+	if _, ok := m.files[path]; !ok {
+		m.files[path] = &fstest.MapFile{Mode: fs.ModeDir | perm.Perm(), ModTime: time.Now()}
+	}
+	return nil
+}
+
+// Chmod implements FS.
+func (m *MemFS) Chmod(name string, mode os.FileMode) error {
+	name = memName(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	f.Mode = f.Mode.Type() | mode.Perm()
+	return nil
+}
+
+// Open implements fs.FS.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.Open(name)
+}
+
+// memFile is a file of MemFS being written, which is stored when it is
+// closed.
+type memFile struct {
+	fs   *MemFS
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) { return f.buf.Write(p) }
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if file, ok := f.fs.files[f.name]; ok {
+		file.Data = bytes.Clone(f.buf.Bytes())
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// HistoryEntry is a change in a release of the software, as listed in the
+// HISTORY section of the pages of the commands it is about, see
+// Options.History.
+type HistoryEntry struct {
+	// Version is the release, e.g. "1.2.0"
+	Version string
+	// Date is the date of the release as it is to be shown, e.g.
+	// "2024-05-01".  It may be empty.
+	Date string
+	// Note describes the change, e.g. "tool sync: added --dry-run"
+	Note string
+	// Commands, if set, are the paths of the commands the entry is about,
+	// e.g. "tool sync".  Otherwise the entry is about the commands its Note
+	// mentions, by their full path or by their path without the name of the
+	// root command.
+	Commands []string
+}
+
+// historyFor returns the entries of history that are about the command with
+// the given path.
+func historyFor(commandPath string, history []HistoryEntry) []HistoryEntry {
+	var entries []HistoryEntry
+	var mentions []*regexp.Regexp
+	for _, entry := range history {
+		if entry.Commands != nil {
+			for _, c := range entry.Commands {
+				if c == commandPath {
+					entries = append(entries, entry)
+					break
+				}
+			}
+			continue
+		}
+		if mentions == nil {
+			mentions = append(mentions, mentionRegex(commandPath))
+			if _, sub, ok := strings.Cut(commandPath, " "); ok {
+				mentions = append(mentions, mentionRegex(sub))
+			}
+		}
+		for _, re := range mentions {
+			if re.MatchString(entry.Note) {
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// mentionRegex matches text mentioning a command path as whole words.
+func mentionRegex(commandPath string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w-])` + regexp.QuoteMeta(commandPath) + `(?:$|[^\w-])`)
+}
+
+// ParseChangelog reads a changelog in the format of keepachangelog.com, or a
+// similar one, into entries for Options.History: a release starts with a
+// second level heading with its version and optionally its date, such as
+// "## [1.2.0] - 2024-05-01" or "## v1.2.0 (2024-05-01)", and each of the
+// list items that follow, e.g. "- tool sync: added --dry-run", is an entry.
+// Items under an "Unreleased" heading and other lines are ignored.
+func ParseChangelog(r io.Reader) ([]HistoryEntry, error) {
+	var (
+		entries          []HistoryEntry
+		version, date    string
+		inItem, released bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "## "):
+			fields := strings.Fields(line[3:])
+			version, date = "", ""
+			if len(fields) > 0 {
+				version = strings.Trim(fields[0], "[]")
+				date = strings.Trim(strings.Join(fields[1:], " "), " -–()")
+			}
+			released = version != "" && !strings.EqualFold(version, "unreleased")
+			inItem = false
+		case !released:
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "):
+			entries = append(entries, HistoryEntry{Version: version, Date: date, Note: trimmed[2:]})
+			inItem = true
+		case inItem && trimmed != "" && line != trimmed:
+			// an indented continuation line of an item
+			entries[len(entries)-1].Note += " " + trimmed
+		default:
+			inItem = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,80 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HumanizeDefault is an Options.FormatDefault that renders the defaults of
+// durations and sizes in a human-readable way: durations without zero units,
+// e.g. "1h" rather than "1h0m0s", and integer flags whose man-default-unit
+// annotation names a unit of time ("ns", "us", "ms" or "s") or "bytes" as a
+// duration or a size, e.g. "30s" rather than "30000000000" or "64MiB" rather
+// than "67108864".  Other defaults are left as they are.
+func HumanizeDefault(flag Flag) string {
+	if flag.Default == "" {
+		return ""
+	}
+	if flag.Type == "duration" {
+		if d, err := time.ParseDuration(flag.Default); err == nil {
+			return formatDuration(d)
+		}
+		return flag.Default
+	}
+	n, err := strconv.ParseInt(flag.Default, 10, 64)
+	if err != nil {
+		return flag.Default
+	}
+	switch flag.Unit {
+	case "ns":
+		return formatDuration(time.Duration(n))
+	case "us":
+		return formatDuration(time.Duration(n) * time.Microsecond)
+	case "ms":
+		return formatDuration(time.Duration(n) * time.Millisecond)
+	case "s":
+		return formatDuration(time.Duration(n) * time.Second)
+	case "bytes":
+		return formatSize(n)
+	}
+	return flag.Default
+}
+
+// formatDuration formats d like time.Duration.String but without trailing
+// zero units, e.g. "1h30m" rather than "1h30m0s".
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// formatSize formats the size n in the largest binary unit it is a multiple
+// of, e.g. "64MiB", or in bytes, e.g. "1500B".
+func formatSize(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i := 0
+	for n != 0 && n%1024 == 0 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	return strconv.FormatInt(n, 10) + units[i]
+}
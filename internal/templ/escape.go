@@ -0,0 +1,153 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EscapeMode tells RoffEscape where the escaped text is placed in the page.
+// One of EscapeProse, EscapeMacroArg and EscapeLiteral can be combined with
+// EscapeHyphensAsMinus.
+type EscapeMode int
+
+const (
+	// EscapeProse is for running text that is filled by roff.  Trailing
+	// whitespace of lines is removed, and hyphens within words are kept as
+	// hyphens, e.g. in "well-known", while the dashes of options such as
+	// "--dry-run" become minus signs.
+	EscapeProse EscapeMode = iota
+	// EscapeMacroArg is for text on the line of a macro, e.g. in .TH "...".
+	// Newlines become spaces and double quotes are escaped.
+	EscapeMacroArg
+	// EscapeLiteral is for text in a no-fill block, e.g. between .nf and
+	// .fi, where whitespace is preserved.
+	EscapeLiteral
+
+	// EscapeHyphensAsMinus makes EscapeProse turn every '-' into a minus
+	// sign, like the other modes do.
+	EscapeHyphensAsMinus EscapeMode = 1 << 8
+)
+
+// RoffEscape escapes str for roff in a single pass, so nothing is escaped
+// twice:
+//
+//   - backslash is printed with \e
+//   - '-' becomes the minus sign \-, as expected for options and names
+//     that are typed, except for hyphens in EscapeProse mode
+//   - '~' and '^', which are special in some roff implementations, become
+//     \(ti and \(ha
+//   - lines starting with a control character, a dot or a single quote, are
+//     prefixed with the zero-width \&
+//
+// The mode adds the handling described for its EscapeMode constant.
+//
+//nolint:gocognit,cyclop // a single pass over the runes is the point
+func RoffEscape(str string, mode EscapeMode) string {
+	hyphens := mode&EscapeHyphensAsMinus == 0
+	mode &^= EscapeHyphensAsMinus
+
+	var b strings.Builder
+	b.Grow(len(str))
+	lineStart := mode != EscapeMacroArg
+	spaces := -1      // index in str of pending whitespace that may be trailing
+	inOption := false // whether the current word is an option, e.g. --dry-run
+	var prev rune
+	for i, r := range str {
+		if mode == EscapeProse {
+			if r == ' ' || r == '\t' {
+				if spaces < 0 {
+					spaces = i
+				}
+				prev, inOption = r, false
+				continue
+			}
+			if spaces >= 0 && r != '\n' {
+				b.WriteString(str[spaces:i])
+			}
+			spaces = -1
+		}
+		atLineStart := lineStart
+		lineStart = false
+		switch r {
+		case '\\':
+			b.WriteString(`\e`)
+		case '-':
+			switch {
+			case mode != EscapeProse || !hyphens || inOption:
+				b.WriteString(`\-`)
+			case isWordRune(prev) && isWordRune(nextRune(str, i+1)):
+				b.WriteByte('-')
+			default:
+				b.WriteString(`\-`)
+				inOption = !isWordRune(prev)
+			}
+		case '~':
+			b.WriteString(`\(ti`)
+		case '^':
+			b.WriteString(`\(ha`)
+		case '.', '\'':
+			if atLineStart {
+				b.WriteString(`\&`)
+			}
+			b.WriteRune(r)
+		case '"':
+			if mode == EscapeMacroArg {
+				b.WriteString(`\(dq`)
+			} else {
+				b.WriteRune(r)
+			}
+		case '\n':
+			inOption = false
+			if mode == EscapeMacroArg {
+				b.WriteByte(' ')
+			} else {
+				b.WriteByte('\n')
+				lineStart = true
+			}
+		default:
+			if unicode.IsSpace(r) {
+				inOption = false
+			}
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// isWordRune reports whether r is a letter or digit.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// nextRune returns the first rune of str[i:], or 0 if there is none.
+func nextRune(str string, i int) rune {
+	r, _ := utf8.DecodeRuneInString(str[i:])
+	if r == utf8.RuneError {
+		return 0
+	}
+	return r
+}
+
+// RoffText is RoffEscape in EscapeProse mode.
+func RoffText(str string) string { return RoffEscape(str, EscapeProse) }
+
+// RoffArg is RoffEscape in EscapeMacroArg mode.
+func RoffArg(str string) string { return RoffEscape(str, EscapeMacroArg) }
+
+// RoffLiteral is RoffEscape in EscapeLiteral mode.
+func RoffLiteral(str string) string { return RoffEscape(str, EscapeLiteral) }
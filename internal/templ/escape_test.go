@@ -0,0 +1,54 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoffEscape(t *testing.T) {
+	cases := []struct {
+		in      string
+		prose   string
+		arg     string
+		literal string
+	}{
+		{`foo-bar`, `foo-bar`, `foo\-bar`, `foo\-bar`},
+		{`use --dry-run or -n`, `use \-\-dry\-run or \-n`, `use \-\-dry\-run or \-n`, `use \-\-dry\-run or \-n`},
+		{`well-known (-v) a - b -`, `well-known (\-v) a \- b \-`, `well\-known (\-v) a \- b \-`, `well\-known (\-v) a \- b \-`},
+		{"x-\n-y", "x\\-\n\\-y", "x\\- \\-y", "x\\-\n\\-y"},
+		{`C:\dir`, `C:\edir`, `C:\edir`, `C:\edir`},
+		{`\-`, `\e\-`, `\e\-`, `\e\-`},
+		{`~/x^2`, `\(ti/x\(ha2`, `\(ti/x\(ha2`, `\(ti/x\(ha2`},
+		{`a & b_c`, `a & b_c`, `a & b_c`, `a & b_c`},
+		{`say "hi"`, `say "hi"`, `say \(dqhi\(dq`, `say "hi"`},
+		{".foo\n'bar", "\\&.foo\n\\&'bar", ".foo 'bar", "\\&.foo\n\\&'bar"},
+		{"a  \n  b\t", "a\n  b", "a     b\t", "a  \n  b\t"},
+		{"x.\n  .y", "x.\n  \\&.y", "x.   .y", "x.\n  .y"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.prose, templ.RoffEscape(c.in, templ.EscapeProse), "prose: %q", c.in)
+		assert.Equal(t, c.arg, templ.RoffEscape(c.in, templ.EscapeMacroArg), "arg: %q", c.in)
+		assert.Equal(t, c.literal, templ.RoffEscape(c.in, templ.EscapeLiteral), "literal: %q", c.in)
+	}
+	assert.Equal(t, `well\-known \-\-dry\-run`,
+		templ.RoffEscape("well-known --dry-run", templ.EscapeProse|templ.EscapeHyphensAsMinus))
+	assert.Equal(t, templ.RoffText("a-b"), templ.RoffEscape("a-b", templ.EscapeProse))
+	assert.Equal(t, templ.RoffArg("a-b"), templ.RoffEscape("a-b", templ.EscapeMacroArg))
+	assert.Equal(t, templ.RoffLiteral("a-b"), templ.RoffEscape("a-b", templ.EscapeLiteral))
+}
@@ -0,0 +1,97 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"strings"
+)
+
+// shellPrompt is the prompt put in front of command lines by FormatExamples.
+const shellPrompt = "$ "
+
+// FormatExamples normalizes the examples of the command with the given
+// space separated path for verbatim output: surrounding blank lines and the
+// indentation common to all lines are removed.  Command lines are those that
+// start with the name of the root command or with a "$ " prompt; if any of
+// them has a prompt, it is added to the others, so they are consistent.
+// Lines continuing a command line ending with a backslash are left as they
+// are.
+func FormatExamples(examples string, commandPath string) string {
+	lines := strings.Split(strings.TrimRight(examples, " \t\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	indent := ""
+	first := true
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = lineIndent, false
+		}
+		for !strings.HasPrefix(lineIndent, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	for i := range lines {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+
+	root, _, _ := strings.Cut(commandPath, " ")
+	isCommand := make([]bool, len(lines))
+	prompt := false
+	continued := false
+	for i, line := range lines {
+		if !continued && (strings.HasPrefix(line, shellPrompt) ||
+			root != "" && (line == root || strings.HasPrefix(line, root+" "))) {
+			isCommand[i] = true
+			prompt = prompt || strings.HasPrefix(line, shellPrompt)
+		}
+		continued = strings.HasSuffix(line, "\\")
+	}
+	if prompt {
+		for i, line := range lines {
+			if isCommand[i] && !strings.HasPrefix(line, shellPrompt) {
+				lines[i] = shellPrompt + line
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExamplesToTroff renders examples as an indented no-fill block of the man
+// macros, see FormatExamples.
+func ExamplesToTroff(examples string, commandPath string) string {
+	return ".RS 4\n.nf\n" + RoffLiteral(FormatExamples(examples, commandPath)) + "\n.fi\n.RE"
+}
+
+// ExamplesToMdoc is ExamplesToTroff for mdoc, using a literal display.
+func ExamplesToMdoc(examples string, commandPath string) string {
+	return ".Bd -literal -offset indent\n" + RoffLiteral(FormatExamples(examples, commandPath)) + "\n.Ed"
+}
+
+// ExamplesToMarkdown renders examples as a fenced shell code block, see
+// FormatExamples.
+func ExamplesToMarkdown(examples string, commandPath string) string {
+	formatted := FormatExamples(examples, commandPath)
+	fence := "```"
+	for strings.Contains(formatted, fence) {
+		fence += "`"
+	}
+	return fence + "sh\n" + formatted + "\n" + fence
+}
@@ -0,0 +1,49 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatExamples(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"tool run", "tool run"},
+		{"\n\n  # run it\n  tool run\n\n    output\n\n", "# run it\ntool run\n\n  output"},
+		{"  $ tool a\n  tool b \\\n    tool c\n  toolbox", "$ tool a\n$ tool b \\\n  tool c\ntoolbox"},
+		{"\ttool a\n\t\tb", "tool a\n\tb"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, templ.FormatExamples(c.in, "tool sub"), "%q", c.in)
+	}
+}
+
+func TestExamplesTo(t *testing.T) {
+	examples := "  # list\n  tool ls --all\n  .hidden"
+
+	assert.Equal(t, ".RS 4\n.nf\n# list\ntool ls \\-\\-all\n\\&.hidden\n.fi\n.RE",
+		templ.ExamplesToTroff(examples, "tool ls"))
+	assert.Equal(t, ".Bd -literal -offset indent\n# list\ntool ls \\-\\-all\n\\&.hidden\n.Ed",
+		templ.ExamplesToMdoc(examples, "tool ls"))
+	assert.Equal(t, "```sh\n# list\ntool ls --all\n.hidden\n```",
+		templ.ExamplesToMarkdown(examples, "tool ls"))
+	assert.Equal(t, "````sh\necho ```\n````", templ.ExamplesToMarkdown("echo ```", "tool"))
+
+	assert.Equal(t, ".RS 4\n.nf\n\\&.B not a macro\n.fi\n.RE", templ.ExamplesToTroff(".B not a macro", "tool"))
+	assert.Equal(t, ".Bd -literal -offset indent\n\\&.Nm not a macro\n.Ed", templ.ExamplesToMdoc(".Nm not a macro", "tool"))
+}
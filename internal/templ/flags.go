@@ -0,0 +1,115 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+)
+
+// FlagSynopsis renders the canonical form of a flag as the built-in templates
+// show it in their OPTIONS section, e.g. "-v, --verbose" or
+// "--file=<path>", for format "troff", "mdoc", "md" or "html".  flag is a
+// struct, or pointer to one, with the string fields Name, Shorthand,
+// NoOptDefVal and ValueName, as the Flag of cobraman has, or a map with
+// those keys; a flag that has NoOptDefVal set takes no value, and one
+// without a Name is shown by its shorthand only, e.g. "-n <count>".
+func FlagSynopsis(flag interface{}, format string) (string, error) {
+	v := reflect.Indirect(reflect.ValueOf(flag))
+	field := func(name string) reflect.Value {
+		switch v.Kind() {
+		case reflect.Struct:
+			return v.FieldByName(name)
+		case reflect.Map:
+			if v.Type().Key().Kind() == reflect.String {
+				if e := v.MapIndex(reflect.ValueOf(name)); e.IsValid() {
+					return reflect.Indirect(reflect.ValueOf(e.Interface()))
+				}
+			}
+		}
+		return reflect.Value{}
+	}
+	if !field("Name").IsValid() && !field("Shorthand").IsValid() {
+		return "", fmt.Errorf("flagSynopsis: %T is not a flag", flag)
+	}
+	str := func(name string) string {
+		if f := field(name); f.Kind() == reflect.String {
+			return f.String()
+		}
+		return ""
+	}
+	name, short := str("Name"), str("Shorthand")
+	value := ""
+	if str("NoOptDefVal") == "" {
+		value = str("ValueName")
+	}
+
+	switch format {
+	case "troff":
+		s := ""
+		if short != "" {
+			s = `\fB` + RoffText("-"+short) + `\fP`
+			if name == "" {
+				if value != "" {
+					s += " <" + RoffText(value) + ">"
+				}
+				return s, nil
+			}
+			s += ", "
+		}
+		s += `\fB` + RoffText("--"+name) + `\fP`
+		if value != "" {
+			s += " = <" + RoffText(value) + ">"
+		}
+		return s, nil
+	case "mdoc":
+		s := "Fl -" + MdocArg(name)
+		if short != "" && name == "" {
+			s = "Fl " + MdocArg(short)
+		} else if short != "" {
+			s = "Fl " + MdocArg(short) + " , " + s
+		}
+		if value != "" {
+			s += " Ar " + MdocArg(value)
+		}
+		return s, nil
+	case "md", "markdown":
+		return plainFlagSynopsis(name, short, value), nil
+	case "html":
+		return html.EscapeString(plainFlagSynopsis(name, short, value)), nil
+	}
+	return "", fmt.Errorf("flagSynopsis: unknown format %q", format)
+}
+
+// plainFlagSynopsis is the unescaped form of FlagSynopsis, as Markdown and
+// HTML show it.
+func plainFlagSynopsis(name, short, value string) string {
+	s := ""
+	if short != "" {
+		s = "-" + short
+		if name == "" {
+			if value != "" {
+				s += " <" + value + ">"
+			}
+			return s
+		}
+		s += ", "
+	}
+	s += "--" + name
+	if value != "" {
+		s += "=<" + value + ">"
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+type synopsisFlag struct {
+	Name, Shorthand, NoOptDefVal, ValueName string
+}
+
+func TestFlagSynopsis(t *testing.T) {
+	output := synopsisFlag{Name: "output", Shorthand: "o", ValueName: "file"}
+	verbose := &synopsisFlag{Name: "verbose", NoOptDefVal: "true", ValueName: "bool"}
+
+	cases := []struct {
+		flag   interface{}
+		format string
+		want   string
+	}{
+		{output, "troff", `\fB\-o\fP, \fB\-\-output\fP = <file>`},
+		{output, "mdoc", `Fl o , Fl -output Ar file`},
+		{output, "md", `-o, --output=<file>`},
+		{output, "html", `-o, --output=&lt;file&gt;`},
+		{verbose, "troff", `\fB\-\-verbose\fP`},
+		{verbose, "md", `--verbose`},
+		{map[string]interface{}{"Name": "dry-run", "Shorthand": "d"}, "md", `-d, --dry-run`},
+		{synopsisFlag{Shorthand: "n", ValueName: "count"}, "troff", `\fB\-n\fP <count>`},
+		{synopsisFlag{Shorthand: "n", ValueName: "count"}, "mdoc", `Fl n Ar count`},
+		{synopsisFlag{Shorthand: "n", ValueName: "count"}, "html", `-n &lt;count&gt;`},
+		{synopsisFlag{Shorthand: "x", NoOptDefVal: "true"}, "md", `-x`},
+	}
+	for _, c := range cases {
+		got, err := templ.FlagSynopsis(c.flag, c.format)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, got, c.format)
+	}
+
+	_, err := templ.FlagSynopsis(output, "pdf")
+	assert.Error(t, err)
+	_, err = templ.FlagSynopsis("output", "md")
+	assert.Error(t, err)
+}
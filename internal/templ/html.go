@@ -0,0 +1,53 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"html"
+	"strings"
+)
+
+// SimpleToHTML escapes str for HTML and marks up its paragraphs, separated
+// by blank lines, with <p> and its URLs and email addresses as links.
+func SimpleToHTML(str string) string {
+	var b strings.Builder
+	for _, para := range multiNewlineRegex.Split(strings.TrimSpace(str), -1) {
+		if para == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(htmlWithLinks(para))
+		b.WriteString("</p>\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// htmlWithLinks escapes str for HTML and makes its URLs and email addresses
+// links.
+func htmlWithLinks(str string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range findLinks(str) {
+		target := str[m[0]:m[1]]
+		href := target
+		if !strings.Contains(target, "://") {
+			href = "mailto:" + target
+		}
+		b.WriteString(html.EscapeString(str[last:m[0]]))
+		b.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(target) + "</a>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(str[last:]))
+	return b.String()
+}
@@ -0,0 +1,36 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleToHTML(t *testing.T) {
+	cases := [][]string{
+		{"", ""},
+		{"one line", "<p>one line</p>"},
+		{"a <b> & c\n\n\nnext", "<p>a &lt;b&gt; &amp; c</p>\n<p>next</p>"},
+		{"see https://example.com/?a=1&b=2.", `<p>see <a href="https://example.com/?a=1&amp;b=2">https://example.com/?a=1&amp;b=2</a>.</p>`},
+		{"mail jane@example.com", `<p>mail <a href="mailto:jane@example.com">jane@example.com</a></p>`},
+		{"clone git@github.com:foo/bar.git", "<p>clone git@github.com:foo/bar.git</p>"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c[1], templ.SimpleToHTML(c[0]), c[0])
+	}
+}
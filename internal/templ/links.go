@@ -0,0 +1,167 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// linkRegex matches URLs and email addresses.  A URL does not end with
+// punctuation, so that "see https://example.com." does not link the dot.
+var linkRegex = regexp.MustCompile(
+	`(?:https?|ftp)://[^\s<>"]*[^\s<>".,;:!?)\]'` + "`" + `]` +
+		`|[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+// findLinks returns the indexes of the URLs and email addresses in str, as
+// FindAllStringIndex does.  An address followed by ':' is not an email
+// address but e.g. the host of an scp-like git URL such as
+// git@github.com:foo/bar.git, and is skipped.
+func findLinks(str string) [][]int {
+	var links [][]int
+	for _, m := range linkRegex.FindAllStringIndex(str, -1) {
+		if !strings.Contains(str[m[0]:m[1]], "://") && strings.HasPrefix(str[m[1]:], ":") {
+			continue
+		}
+		links = append(links, m)
+	}
+	return links
+}
+
+// codeSpans returns the indexes of the markdown code spans in str, each a
+// run of backticks up to the next run of as many backticks.
+func codeSpans(str string) [][]int {
+	var spans [][]int
+	for i := 0; i < len(str); {
+		if str[i] != '`' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(str) && str[i] == '`' {
+			i++
+		}
+		fence := str[start:i]
+		for j := i; j < len(str); {
+			k := strings.Index(str[j:], fence)
+			if k < 0 {
+				break
+			}
+			end := j + k + len(fence)
+			if end < len(str) && str[end] == '`' {
+				// a longer run of backticks does not close the span
+				for end < len(str) && str[end] == '`' {
+					end++
+				}
+				j = end
+				continue
+			}
+			spans = append(spans, []int{start, end})
+			i = end
+			break
+		}
+	}
+	return spans
+}
+
+// roffLinkMacros are the macros used for links in a man page flavor.
+type roffLinkMacros struct {
+	url, urlEnd     string
+	email, emailEnd string
+}
+
+var (
+	troffLinks = roffLinkMacros{url: ".UR", urlEnd: ".UE", email: ".MT", emailEnd: ".ME"}
+	mdocLinks  = roffLinkMacros{url: ".Lk", email: ".Mt"}
+)
+
+// roffWithLinks is RoffEscape for prose, but puts URLs and email addresses
+// on lines of their own with the link macros of macros.  Punctuation directly
+// following a link is passed as an argument to the macro ending the link (to
+// the link macro if there is no end macro) so that no space is put before it,
+// and angle brackets around a link are removed, as the macros add them.
+func roffWithLinks(str string, mode EscapeMode, macros roffLinkMacros) string {
+	matches := findLinks(str)
+	if matches == nil {
+		return RoffEscape(str, mode)
+	}
+
+	var b strings.Builder
+	text := str[:matches[0][0]]
+	for i, m := range matches {
+		target := str[m[0]:m[1]]
+		end := len(str)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		after := str[m[1]:end]
+
+		if strings.HasSuffix(text, "<") && strings.HasPrefix(after, ">") {
+			text, after = text[:len(text)-1], after[1:]
+		}
+		text = strings.TrimRight(RoffEscape(text, mode), " \t")
+		b.WriteString(text)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			b.WriteByte('\n')
+		}
+
+		punct := after[:len(after)-len(strings.TrimLeftFunc(after, func(r rune) bool {
+			return !unicode.IsSpace(r)
+		}))]
+		after = strings.TrimLeft(after[len(punct):], " \t")
+		start, stop := macros.url, macros.urlEnd
+		if !strings.Contains(target, "://") {
+			start, stop = macros.email, macros.emailEnd
+		}
+		b.WriteString(start + " " + RoffArg(target))
+		if stop != "" {
+			b.WriteString("\n" + stop)
+		}
+		if punct != "" {
+			b.WriteString(" " + RoffArg(punct))
+		}
+		if after != "" && after[0] != '\n' {
+			b.WriteByte('\n')
+		}
+		text = after
+	}
+	b.WriteString(RoffEscape(text, mode))
+	return b.String()
+}
+
+// MarkdownLinks makes the URLs and email addresses in str markdown autolinks
+// by putting them in angle brackets, unless they already are or are in a code
+// span.
+func MarkdownLinks(str string) string {
+	spans := codeSpans(str)
+	var b strings.Builder
+	last := 0
+	for _, m := range findLinks(str) {
+		if slices.ContainsFunc(spans, func(span []int) bool { return span[0] <= m[0] && m[1] <= span[1] }) {
+			continue
+		}
+		b.WriteString(str[last:m[0]])
+		target := str[m[0]:m[1]]
+		if strings.HasSuffix(str[:m[0]], "<") && strings.HasPrefix(str[m[1]:], ">") {
+			b.WriteString(target)
+		} else {
+			b.WriteString("<" + target + ">")
+		}
+		last = m[1]
+	}
+	b.WriteString(str[last:])
+	return b.String()
+}
@@ -0,0 +1,79 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinks(t *testing.T) {
+	cases := []struct {
+		in       string
+		troff    string
+		mdoc     string
+		markdown string
+	}{
+		{
+			"no links here",
+			"no links here",
+			"no links here",
+			"no links here",
+		},
+		{
+			"File bugs at https://github.com/carlwr/cobraman/issues.",
+			"File bugs at\n.UR https://github.com/carlwr/cobraman/issues\n.UE .",
+			"File bugs at\n.Lk https://github.com/carlwr/cobraman/issues .",
+			"File bugs at <https://github.com/carlwr/cobraman/issues>.",
+		},
+		{
+			"Ray Johnson <ray.johnson@gmail.com>",
+			"Ray Johnson\n.MT ray.johnson@gmail.com\n.ME",
+			"Ray Johnson\n.Mt ray.johnson@gmail.com",
+			"Ray Johnson <ray.johnson@gmail.com>",
+		},
+		{
+			"Mail a@b.org, or see http://x.org/a-b then\n\nmore.",
+			"Mail\n.MT a@b.org\n.ME ,\nor see\n.UR http://x.org/a\\-b\n.UE\nthen\n.PP\nmore.",
+			"Mail\n.Mt a@b.org ,\nor see\n.Lk http://x.org/a\\-b\nthen\n.Pp\nmore.",
+			"Mail <a@b.org>, or see <http://x.org/a-b> then\n\nmore.",
+		},
+		{
+			"see https://x.org\n.not a request",
+			"see\n.UR https://x.org\n.UE\n\\&.not a request",
+			"see\n.Lk https://x.org\n\\&.not a request",
+			"see <https://x.org>\n.not a request",
+		},
+		{
+			"clone git@github.com:foo/bar.git",
+			"clone git@github.com:foo/bar.git",
+			"clone git@github.com:foo/bar.git",
+			"clone git@github.com:foo/bar.git",
+		},
+		{
+			"run `curl https://x.org` or ``see `https://y.org` ``",
+			"run `curl\n.UR https://x.org\n.UE `\nor ``see `\n.UR https://y.org\n.UE `\n``",
+			"run `curl\n.Lk https://x.org `\nor ``see `\n.Lk https://y.org `\n``",
+			"run `curl https://x.org` or ``see `https://y.org` ``",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.troff, templ.SimpleToTroff(c.in), "troff: %q", c.in)
+		assert.Equal(t, c.mdoc, templ.SimpleToMdoc(c.in), "mdoc: %q", c.in)
+		assert.Equal(t, c.markdown, templ.MarkdownLinks(c.in), "markdown: %q", c.in)
+	}
+}
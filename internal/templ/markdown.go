@@ -0,0 +1,172 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	mdHeadingRegex = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	mdFenceRegex   = regexp.MustCompile("^ {0,3}(```|~~~)")
+	mdImageRegex   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkRegex    = regexp.MustCompile(`\[([^\]]+)\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+	mdAutoRegex    = regexp.MustCompile(`<((?:https?|ftp)://[^>\s]+|[^@>\s]+@[^>\s]+)>`)
+	mdCodeRegex    = regexp.MustCompile("`+([^`]+)`+")
+	mdStrongRegex  = regexp.MustCompile(`\*\*([^*]+)\*\*|(?:^|\b)__([^_]+)__(?:\b|$)`)
+	mdEmRegex      = regexp.MustCompile(`\*([^*\s][^*]*)\*|(?:^|\b)_([^_\s][^_]*)_(?:\b|$)`)
+)
+
+// MarkdownToText turns markdown into the plain text with blank lines between
+// paragraphs that the simpleTo functions render, for showing a description
+// written in markdown in another format: headings become paragraphs of their
+// own, the markup of emphasis and code is removed, links become their text
+// followed by their URL in parentheses, and the lines of code blocks are
+// kept without their fences.  List items are kept as they are.
+func MarkdownToText(md string) string {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inFence := ""
+	for _, line := range lines {
+		if m := mdFenceRegex.FindStringSubmatch(line); m != nil {
+			switch inFence {
+			case "":
+				inFence = m[1]
+				continue
+			case m[1]:
+				inFence = ""
+				continue
+			}
+		}
+		if inFence != "" {
+			out = append(out, line)
+			continue
+		}
+		if m := mdHeadingRegex.FindStringSubmatch(line); m != nil {
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			out = append(out, markdownInline(m[1]), "")
+			continue
+		}
+		out = append(out, markdownInline(line))
+	}
+	return strings.TrimSpace(multiNewlineRegex.ReplaceAllString(strings.Join(out, "\n"), "\n\n"))
+}
+
+// markdownInline removes the inline markup of a line of markdown.
+func markdownInline(line string) string {
+	line = mdImageRegex.ReplaceAllString(line, "$1")
+	line = mdLinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+		m := mdLinkRegex.FindStringSubmatch(link)
+		if m[1] == m[2] {
+			return m[2]
+		}
+		return m[1] + " (" + m[2] + ")"
+	})
+	line = mdAutoRegex.ReplaceAllString(line, "$1")
+
+	// emphasis is not markup within code spans
+	var b strings.Builder
+	last := 0
+	for _, m := range mdCodeRegex.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(markdownEmphasis(line[last:m[0]]))
+		b.WriteString(line[m[2]:m[3]])
+		last = m[1]
+	}
+	b.WriteString(markdownEmphasis(line[last:]))
+	return b.String()
+}
+
+// markdownEmphasis removes the markup of strong and emphasized text.
+func markdownEmphasis(text string) string {
+	text = mdStrongRegex.ReplaceAllString(text, "$1$2")
+	return mdEmRegex.ReplaceAllString(text, "$1$2")
+}
+
+// MarkdownTOC inserts a table of contents linking the level 3 headings of a
+// markdown page, its sections, before the first of them.  The links use the
+// anchors GitHub generates for headings, see GitHubAnchor.  Headings in code
+// blocks are ignored, and pages with fewer than two sections are returned as
+// they are.
+func MarkdownTOC(page string) string {
+	lines := strings.Split(page, "\n")
+	var (
+		toc     []string
+		first   = -1
+		inFence = ""
+		anchors = make(map[string]int)
+	)
+	for i, line := range lines {
+		if m := mdFenceRegex.FindStringSubmatch(line); m != nil {
+			switch inFence {
+			case "":
+				inFence = m[1]
+			case m[1]:
+				inFence = ""
+			}
+			continue
+		}
+		if inFence != "" || !strings.HasPrefix(line, "### ") {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		title := strings.TrimSpace(line[4:])
+		anchor := GitHubAnchor(title)
+		if n := anchors[anchor]; n > 0 {
+			anchors[anchor]++
+			anchor += "-" + strconv.Itoa(n)
+		} else {
+			anchors[anchor] = 1
+		}
+		toc = append(toc, "* ["+title+"](#"+anchor+")")
+	}
+	if len(toc) < 2 {
+		return page
+	}
+	toc = append(toc, "")
+	return strings.Join(slices.Concat(lines[:first], toc, lines[first:]), "\n")
+}
+
+// GitHubAnchor returns the anchor GitHub generates for a markdown heading
+// with the text title: lower case, with spaces replaced by hyphens and other
+// characters than letters, digits, hyphens and underscores removed.
+func GitHubAnchor(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// FrontMatter renders metadata as the YAML of the front matter of a markdown
+// page, without the "---" lines around it, e.g. "weight: 10\n".  The keys
+// are sorted.
+func FrontMatter(metadata map[string]interface{}) (string, error) {
+	out, err := yaml.Marshal(metadata)
+	return string(out), err
+}
@@ -0,0 +1,52 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownToText(t *testing.T) {
+	cases := [][]string{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"# Serving\nServe **all** files in *dir*.", "Serving\n\nServe all files in dir."},
+		{"Run `a*b*c` with __care__ in snake_case_names.", "Run a*b*c with care in snake_case_names."},
+		{"See [the docs](https://example.com/docs) or <https://example.com>.", "See the docs (https://example.com/docs) or https://example.com."},
+		{"![logo](logo.png) [https://x.org](https://x.org)", "logo https://x.org"},
+		{"Example:\n\n```sh\n# not a heading\ntool serve\n```\n\n- one\n- two", "Example:\n\n# not a heading\ntool serve\n\n- one\n- two"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c[1], templ.MarkdownToText(c[0]), c[0])
+	}
+}
+
+func TestMarkdownTOC(t *testing.T) {
+	page := "## TOOL\n\nA tool\n\n### Synopsis\n\n```\n### not a section\n```\n\n### See Also: more\n\n### Options\n\n### Options\n"
+	assert.Equal(t, "## TOOL\n\nA tool\n\n"+
+		"* [Synopsis](#synopsis)\n"+
+		"* [See Also: more](#see-also-more)\n"+
+		"* [Options](#options)\n"+
+		"* [Options](#options-1)\n\n"+
+		"### Synopsis\n\n```\n### not a section\n```\n\n### See Also: more\n\n### Options\n\n### Options\n",
+		templ.MarkdownTOC(page))
+
+	single := "## TOOL\n\n### Synopsis\n"
+	assert.Equal(t, single, templ.MarkdownTOC(single))
+	assert.Equal(t, "tool-sync_x-2", templ.GitHubAnchor("Tool sync_x (2)!"))
+}
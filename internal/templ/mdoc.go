@@ -0,0 +1,54 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mdocCallable matches words that mdoc would parse as a macro when they
+// appear in the arguments of a macro, e.g. the "No" in ".Ar No".
+var mdocCallable = regexp.MustCompile(`^[A-Z][a-z][a-z]?$`)
+
+// mdocDelimiters are the words that mdoc treats as punctuation when they
+// appear in the arguments of a macro.
+const mdocDelimiters = "()[].,:;|?!"
+
+// MdocArg escapes str for the arguments of an mdoc macro such as Fl, Ar or
+// Cm.  It is RoffArg, except that hyphens are kept, as mdoc decides how to
+// render them, and words that mdoc would take for a macro or punctuation are
+// prefixed with the zero-width \& so they are printed as they are.
+func MdocArg(str string) string {
+	words := strings.Fields(str)
+	for i, word := range words {
+		escaped := strings.ReplaceAll(RoffArg(word), `\-`, "-")
+		if mdocCallable.MatchString(word) || strings.Trim(word, mdocDelimiters) == "" {
+			escaped = `\&` + escaped
+		}
+		words[i] = escaped
+	}
+	return strings.Join(words, " ")
+}
+
+// MdocCommand renders a space separated command path for the line of an mdoc
+// .Nm macro: the root command is the argument of .Nm, and the names of
+// subcommands are marked up with Cm, e.g. "tool Cm sub".
+func MdocCommand(commandPath string) string {
+	root, subs, _ := strings.Cut(commandPath, " ")
+	if subs == "" {
+		return MdocArg(root)
+	}
+	return MdocArg(root) + " Cm " + MdocArg(subs)
+}
@@ -0,0 +1,72 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMdocArg(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"dry-run", "dry-run"},
+		{`say "hi"`, `say \(dqhi\(dq`},
+		{`C:\dir`, `C:\edir`},
+		{"No Ar Xref", `\&No \&Ar Xref`},
+		{"a | b", `a \&| b`},
+		{"x,", "x,"},
+		{"(", `\&(`},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, templ.MdocArg(c.in), "%q", c.in)
+	}
+
+	assert.Equal(t, "tool", templ.MdocCommand("tool"))
+	assert.Equal(t, "tool Cm sub leaf", templ.MdocCommand("tool sub leaf"))
+}
+
+func TestMdocFlags(t *testing.T) {
+	_, _, tmpl, _ := templ.GetTemplate("mdoc")
+	data := map[string]interface{}{
+		"CommandPath": "tool sub",
+		"UseLine":     "tool sub [flags]",
+		"Section":     "1",
+		"Description": "Does things.",
+		"AllFlags": []map[string]interface{}{
+			{"Name": "dry-run", "Shorthand": "n", "Type": "bool", "NoOptDefVal": "true", "DefValue": "false", "Usage": "Do nothing."},
+			{"Name": "file", "Type": "string", "ArgHint": "path", "ValueName": "path", "Usage": "Read path."},
+			{"Name": "count", "Type": "int", "ValueName": "int", "DefValue": "3", "Default": "3", "Usage": "Repeat."},
+			{"Name": "name", "Type": "string", "ValueName": "string", "Usage": "Set the name."},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, data))
+	page := buf.String()
+
+	assert.Contains(t, page, ".Nm tool Cm sub\n"+
+		".Op Fl n | Fl -dry-run\n"+
+		".Op Fl -file Ar path\n"+
+		".Op Fl -count Ar int\n"+
+		".Op Fl -name Ar string\n"+
+		".Op Ar args\n")
+	assert.Contains(t, page, ".It Fl n , Fl -dry-run\n")
+	assert.Contains(t, page, ".It Fl -file Ar path\n")
+	assert.Contains(t, page, ".It Fl -count Ar int\nRepeat. (default: 3)\n")
+	assert.Contains(t, page, ".It Fl -name Ar string\n")
+	assert.NotContains(t, page, `Fl \-`)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+// SectionIncluder is implemented by the data of a page that decides which of
+// the optional sections of the page are included, such as cobraman's DocData.
+type SectionIncluder interface {
+	Includes(section string) bool
+}
+
+// Includes reports whether the section of the page with data, named by its
+// man page heading (e.g. "SEE ALSO"), is to be included.  Sections are
+// included unless data is a SectionIncluder excluding them.
+func Includes(data interface{}, section string) bool {
+	if d, ok := data.(SectionIncluder); ok {
+		return d.Includes(section)
+	}
+	return true
+}
+
+// HeadingFormatter is implemented by the data of a page that decides the
+// case of the headings of the page, such as cobraman's DocData.
+type HeadingFormatter interface {
+	Heading(text string) string
+}
+
+// PageFiler is implemented by the data of a page that knows the names of the
+// files of the pages generated along with it, such as cobraman's DocData.
+type PageFiler interface {
+	PageFile(commandPath string) string
+}
+
+// PageFile returns the name of the file of the page of the command with the
+// given path, to link to it from the page with data, e.g. "tool_sub.de.md"
+// for a page in German.  If data is not a PageFiler, or returns "", it is
+// the path with underscores for spaces and the extension ext, e.g.
+// "tool_sub.md".
+func PageFile(data interface{}, commandPath string, ext string) string {
+	if d, ok := data.(PageFiler); ok {
+		if name := d.PageFile(commandPath); name != "" {
+			return name
+		}
+	}
+	return Underscoreify(commandPath) + "." + ext
+}
+
+// Heading returns the heading text of the page with data, e.g. "SEE ALSO"
+// or "See Also", as the data formats it, or text as it is if data is not a
+// HeadingFormatter.
+func Heading(data interface{}, text string) string {
+	if d, ok := data.(HeadingFormatter); ok {
+		return d.Heading(text)
+	}
+	return text
+}
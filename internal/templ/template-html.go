@@ -0,0 +1,213 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+func init() {
+	registerBuiltin("html", "_", "html", "Standalone HTML page", htmlTemplate)
+}
+
+// htmlTemplate is a template that generates a standalone HTML page.  The
+// page content is the "body" template, which the "layout" template wraps
+// in the surrounding document; WithLayout replaces the latter.
+const htmlTemplate = `{{ template "layout" . }}
+{{- define "layout" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .CommandPath | html }}</title>
+{{- with .HTMLLayout.Stylesheet }}
+<link rel="stylesheet" href="{{ . | html }}">
+{{- end }}
+</head>
+<body>
+<a class="skip-link" href="#content">Skip to content</a>
+{{- with .HTMLLayout.Header }}
+{{ . }}
+{{- end }}
+<main id="content">
+{{ template "body" . }}
+</main>
+{{- with .HTMLLayout.Footer }}
+{{ . }}
+{{- end }}
+</body>
+</html>
+{{ end }}
+{{- define "body" -}}
+<h1>{{ .CommandPath | html }}</h1>
+{{- if .ShortDescription }}
+<p>{{ .ShortDescription | html }}</p>
+{{- end }}
+
+<h2>{{ heading $ "Synopsis" }}</h2>
+<pre>
+{{- if .UseLineSynopsis }}
+{{- range .SubCommands }}
+{{ .CommandPath | html }}{{ with useArgs .UseLine .CommandPath }} {{ argsToMarkdown . | html }}{{ end }}
+{{- end }}
+{{- if not .SubCommands }}
+{{ .CommandPath | html }}{{ with useArgs .UseLine .CommandPath }} {{ argsToMarkdown . | html }}{{ end }}
+{{- end }}
+{{- else if .SubCommands }}
+{{- range .SubCommands }}
+{{ .CommandPath | html }} [flags]
+{{- end }}
+{{- else }}
+{{ .CommandPath | html }}
+{{- range .AllFlags }} [{{ if .Shorthand }}-{{ .Shorthand | html }}{{ if .Name }}|{{ end }}{{ end }}{{ if .Name }}--{{ .Name | html }}{{ end }}]{{ end }}
+{{- if not .NoArgs }} {{ with positionalArgs .UseLine .CommandPath }}{{ argsToMarkdown . | html }}{{ else }}[&lt;args&gt;]{{ end }}{{ end }}
+{{- end }}
+</pre>
+
+<h2>{{ heading $ "Description" }}</h2>
+{{ .Description | simpleToHTML }}
+{{- if and (or .AllFlags .DeprecatedFlags .InheritedFlagsFrom) (includes . "OPTIONS") }}
+
+<h2>{{ heading $ "Options" }}</h2>
+{{- if .FlagGroups }}
+{{- range .FlagGroups }}
+{{- with .Title }}
+<h3>{{ . | html }}</h3>
+{{- end }}
+{{ template "options" .Flags }}
+{{- end }}
+{{- else if .AllFlags }}
+{{ template "options" .AllFlags }}
+{{- end }}
+{{- with .InheritedFlagsFrom }}
+<p>Options inherited from parent commands are described in <a href="{{ pageFile $ . "html" | html }}">{{ . | html }}</a>.</p>
+{{- end }}
+{{- if .DeprecatedFlags }}
+<h3>{{ heading $ "Deprecated options" }}</h3>
+<dl>
+{{- range .DeprecatedFlags }}
+<dt><code>{{ flagSynopsis . "html" }}</code></dt>
+<dd>{{ .Deprecated | html }}{{ with .ReplacedBy }} (superseded by <code>--{{ . | html }}</code>){{ end }}</dd>
+{{- end }}
+</dl>
+{{- end }}
+{{- end }}
+{{- if and .CommandGroups (includes . "COMMANDS") }}
+
+<h2>{{ heading $ "Commands" }}</h2>
+{{- range .CommandGroups }}
+{{- if .Title }}
+<h3>{{ .Title | html }}</h3>
+{{- end }}
+<ul>
+{{- range .Commands }}
+<li><a href="{{ pageFile $ .CommandPath "html" | html }}">{{ .CommandPath | html }}</a>{{ with .Short }} - {{ . | html }}{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- end }}
+{{- if and .ExitStatus (includes . "EXIT STATUS") }}
+
+<h2>{{ heading $ "Exit Status" }}</h2>
+{{ .ExitStatus | simpleToHTML }}
+{{- end }}
+{{- if and (or .Environment .EnvironmentEntries .EnvFlags) (includes . "ENVIRONMENT") }}
+
+<h2>{{ heading $ "Environment" }}</h2>
+{{- with .Environment }}
+{{ . | simpleToHTML }}
+{{- end }}
+{{- if or .EnvironmentEntries .EnvFlags }}
+<dl>
+{{- range .EnvironmentEntries }}
+<dt><code>{{ .Name | html }}</code></dt>
+<dd>{{ .Description | html }}</dd>
+{{- end }}
+{{- range .EnvFlags }}
+<dt><code>{{ .Env | html }}</code></dt>
+<dd>Sets <code>{{ if .Name }}--{{ .Name | html }}{{ else }}-{{ .Shorthand | html }}{{ end }}</code></dd>
+{{- end }}
+</dl>
+{{- end }}
+{{- end }}
+{{- if and (or .Files .FilesEntries) (includes . "FILES") }}
+
+<h2>{{ heading $ "Files" }}</h2>
+{{- with .Files }}
+{{ . | simpleToHTML }}
+{{- end }}
+{{- if .FilesEntries }}
+<dl>
+{{- range .FilesEntries }}
+<dt><code>{{ .Name | html }}</code></dt>
+<dd>{{ .Description | html }}</dd>
+{{- end }}
+</dl>
+{{- end }}
+{{- end }}
+{{- if and .Bugs (includes . "BUGS") }}
+
+<h2>{{ heading $ "Bugs" }}</h2>
+{{ .Bugs | simpleToHTML }}
+{{- end }}
+{{- if and .Examples (includes . "EXAMPLES") }}
+
+<h2>{{ heading $ "Examples" }}</h2>
+{{- if .ExampleGroups }}
+{{- range .ExampleGroups }}
+<h3>{{ .Title | html }}</h3>
+<pre><code>{{ formatExamples .Examples $.CommandPath | html }}</code></pre>
+{{- end }}
+{{- else }}
+<pre><code>{{ formatExamples .Examples .CommandPath | html }}</code></pre>
+{{- end }}
+{{- end }}
+{{- if and .History (includes . "HISTORY") }}
+
+<h2>{{ heading $ "History" }}</h2>
+<ul>
+{{- range .History }}
+<li>{{ .Version | html }}{{ with .Date }} ({{ . | html }}){{ end }}{{ print " - " .Note | html }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if and .Author (includes . "AUTHOR") }}
+
+<h2>{{ heading $ "Author" }}</h2>
+{{ .Author | simpleToHTML }}
+{{- end }}
+{{- range .CustomSections }}
+{{- if includes $ .Title }}
+
+<h2>{{ heading $ .Title | html }}</h2>
+{{ .Content | simpleToHTML }}
+{{- end }}
+{{- end }}
+{{- if and .SeeAlsos (includes . "SEE ALSO") }}
+
+<h2>{{ heading $ "See Also" }}</h2>
+<nav aria-label="{{ heading $ "See Also" }}">
+<ul>
+{{- range .SeeAlsos }}
+<li><a href="{{ pageFile $ .CmdPath "html" | html }}">{{ .CmdPath | html }}</a></li>
+{{- end }}
+</ul>
+</nav>
+{{- end }}
+{{- end }}
+{{- define "options" -}}
+<dl>
+{{- range . }}
+<dt><code>{{ flagSynopsis . "html" }}</code></dt>
+<dd>{{ .Usage | html }}{{ if .Repeatable }} (may be repeated){{ end }}{{ with .Default }} (default: {{ . | html }}){{ end }}{{ with .Env }} (env: <code>{{ . | html }}</code>){{ end }}</dd>
+{{- end }}
+</dl>
+{{- end }}`
@@ -14,70 +14,179 @@
 package templ
 
 func init() {
-	RegisterTemplate("markdown", "_", "md", markdownTemplate)
+	registerBuiltin("markdown", "_", "md", "Markdown page", markdownTemplate)
 }
 
 // markdownTemplate is a template what will generate markdown syntax documentation.
-const markdownTemplate = `## {{.CommandPath}}
+const markdownTemplate = `{{ with .Metadata }}---
+{{ frontMatter . }}---
+
+{{ end }}## {{.CommandPath}}
 
 {{ .ShortDescription }}
 
-### Synopsis
+### {{ heading $ "Synopsis" }}
+
+` + "```" + `
+{{- if .UseLineSynopsis }}
+{{- range .SubCommands }}
+{{ .CommandPath }}{{ with useArgs .UseLine .CommandPath }} {{ argsToMarkdown . }}{{ end }}
+{{- end }}
+{{- if not .SubCommands }}
+{{ .CommandPath }}{{ with useArgs .UseLine .CommandPath }} {{ argsToMarkdown . }}{{ end }}
+{{- end }}
+{{- else if .SubCommands }}
+{{- range .SubCommands }}
+{{ .CommandPath }} [flags]
+{{- end }}
+{{- else }}
+{{ .CommandPath }}
+{{- range .AllFlags }} [{{ if .Shorthand }}-{{ .Shorthand }}{{ if .Name }}|{{ end }}{{ end }}{{ if .Name }}--{{ .Name }}{{ end }}]{{ end }}
+{{- if not .NoArgs }} {{ with positionalArgs .UseLine .CommandPath }}{{ argsToMarkdown . }}{{ else }}[<args>]{{ end }}{{ end }}
+{{- end }}
+` + "```" + `
 
-{{ .Description }}
+### {{ heading $ "Description" }}
 
-{{- if .AllFlags }}
+{{ .Description | markdownLinks }}
 
-### Options
+{{- if and (or .AllFlags .DeprecatedFlags .InheritedFlagsFrom) (includes . "OPTIONS") }}
+
+### {{ heading $ "Options" }}
 
 The following options are supported:
+{{- if .FlagGroups }}
+{{ range .FlagGroups }}
+{{- with .Title }}
+#### {{ . }}
+
+{{ else }}
+{{ end }}
+{{- range .Flags }}{{ template "option" . }}{{ end }}
+{{- end }}
+{{- else }}
+
+{{ range .AllFlags }}{{ template "option" . }}{{ end }}
+{{- end }}
+{{- with .InheritedFlagsFrom }}
+Options inherited from parent commands are described in [{{ . }}]({{ pageFile $ . "md" }}).
+{{- end }}
+{{- if .DeprecatedFlags }}
+
+#### {{ heading $ "Deprecated options" }}
 
-{{ range .AllFlags -}}
-* {{ if .Shorthand }}{{ print "-" .Shorthand }}, {{ end -}}{{ print "--" .Name }}
-{{- if not .NoOptDefVal }}{{if .ArgHint }}=<{{ .ArgHint }}>{{ else }}=<{{ .DefValue }}>{{ end }}{{ end }}
-{{- print " - " .Usage }}
+{{ range .DeprecatedFlags -}}
+* {{ flagSynopsis . "md" }}
+{{- print " - " .Deprecated }}{{ with .ReplacedBy }} (superseded by {{ print "--" . }}){{ end }}
 {{ end }}
 {{- end }}
+{{- end }}
+
+{{- if and .CommandGroups (includes . "COMMANDS") }}
+
+### {{ heading $ "Commands" }}
+{{- range .CommandGroups }}
+{{- if .Title }}
+
+#### {{ .Title }}
+{{- end }}
+{{ range .Commands }}
+* [{{ .CommandPath }}]({{ pageFile $ .CommandPath "md" }}){{ with .Short }} - {{ . }}{{ end }}
+{{- end }}
+{{- end }}
+{{- end }}
 
-{{- if .Environment }}
+{{- if and .ExitStatus (includes . "EXIT STATUS") }}
 
-### Environment
+### {{ heading $ "Exit Status" }}
 
-{{ .Environment }}
+{{ .ExitStatus | markdownLinks }}
 {{- end }}
-{{- if .Files }}
+{{- if and (or .Environment .EnvironmentEntries .EnvFlags) (includes . "ENVIRONMENT") }}
 
-### Files
+### {{ heading $ "Environment" }}
+{{- with .Environment }}
 
-{{ .Files }}
+{{ . | markdownLinks }}
 {{- end }}
-{{- if .Bugs }}
+{{- if or .EnvironmentEntries .EnvFlags }}
+{{ range .EnvironmentEntries }}
+* {{ .Name }} - {{ .Description }}
+{{- end }}
+{{- range .EnvFlags }}
+* {{ .Env }} - sets {{ if .Name }}{{ print "--" .Name }}{{ else }}{{ print "-" .Shorthand }}{{ end }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if and (or .Files .FilesEntries) (includes . "FILES") }}
+
+### {{ heading $ "Files" }}
+{{- with .Files }}
+
+{{ . | markdownLinks }}
+{{- end }}
+{{- if .FilesEntries }}
+{{ range .FilesEntries }}
+* {{ .Name }} - {{ .Description }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if and .Bugs (includes . "BUGS") }}
+
+### {{ heading $ "Bugs" }}
 
-### Bugs
+{{ .Bugs | markdownLinks }}
+{{- end }}
+{{- if and .Examples (includes . "EXAMPLES") }}
+
+### {{ heading $ "Examples" }}
+{{- if .ExampleGroups }}
+{{- range .ExampleGroups }}
+
+#### {{ .Title }}
 
-{{ .Bugs }}
+{{ examplesToMarkdown .Examples $.CommandPath }}
 {{- end }}
-{{- if .Examples }}
+{{- else }}
 
-### Examples
+{{ examplesToMarkdown .Examples .CommandPath }}
+{{- end }}
+{{- end }}
+{{- if and .History (includes . "HISTORY") }}
 
-{{ .Examples }}
+### {{ heading $ "History" }}
+{{ range .History }}
+* {{ .Version }}{{ with .Date }} ({{ . }}){{ end }}{{ print " - " .Note }}
+{{- end }}
 {{- end }}
+{{- if includes . "AUTHOR" }}
 
-### Author
+### {{ heading $ "Author" }}
 {{- if .Author }}
 
-{{ .Author }}
+{{ .Author | markdownLinks }}
+{{- end }}
+{{- end }}
+{{- range .CustomSections }}
+{{- if includes $ .Title }}
+
+### {{ heading $ .Title }}
+
+{{ .Content | markdownLinks }}
+{{- end }}
 {{- end }}
 
-{{- if .SeeAlsos }}
+{{- if and .SeeAlsos (includes . "SEE ALSO") }}
 
-### See Also
+### {{ heading $ "See Also" }}
 
 {{- range $index, $element := .SeeAlsos}}
-* [{{ $element.CmdPath }}]({{ $element.CmdPath | underscoreify }}.md)
+* [{{ $element.CmdPath }}]({{ pageFile $ $element.CmdPath "md" }})
 {{- end }}
 {{- end }}
 
 [//]: # ( This file auto-generated by github.com/carlwr/cobraman  )
-`
+{{ define "option" -}}
+* {{ flagSynopsis . "md" }}
+{{- print " - " .Usage }}{{ if .Repeatable }} (may be repeated){{ end }}{{ with .Default }} (default: {{ . }}){{ end }}{{ with .Env }} (env: {{ . }}){{ end }}
+{{ end }}`
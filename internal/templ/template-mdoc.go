@@ -14,77 +14,207 @@
 package templ
 
 func init() {
-	RegisterTemplate("mdoc", "-", "use_section", mdocManTemplate)
+	registerBuiltin("mdoc", "-", "use_section", "Man page using the mdoc macro package", mdocManTemplate)
 }
 
 // mdocManTemplate is a template what will use the mdoc macro package.
 // TODO: The Dt macro can take one additonal arg - what does it do?
 const mdocManTemplate = `.\" Man page for {{.CommandPath}}
-.Dd {{ .Date.Format "January 2006"}}
-.Dt {{.CommandPath | dashify | backslashify | upper}} {{ .Section }}
-.Sh NAME
-.Nm {{ .CommandPath | dashify | backslashify }}
+.Dd {{ .FormattedDate }}
+.Dt {{.CommandPath | dashify | upper | roffArg}} {{ .Section | roffArg }}
+.Os{{ if and .LeftFooter (not .BSDStyle) }} {{ .LeftFooter | roffArg }}{{ end }}
+.Sh {{ heading $ "NAME" }}
+.Nm {{ .CommandPath | dashify | roffArg }}
 {{- if .ShortDescription }}
-.Nd {{ .ShortDescription }}
+.Nd {{ .ShortDescription | roffArg }}
 {{- end }}
-.Sh SYNOPSIS
-{{- if .SubCommands }}
+.Sh {{ heading $ "SYNOPSIS" }}
+{{- if .UseLineSynopsis }}
 {{- range .SubCommands }}
-.Nm {{ .CommandPath }} Op Fl flags Op args
+.Nm {{ .CommandPath | mdocCommand }}
+{{- with useArgs .UseLine .CommandPath }}
+{{ argsToMdoc . }}
+{{- end }}
+{{- end }}
+{{- if not .SubCommands }}
+.Nm {{ .CommandPath | mdocCommand }}
+{{- with useArgs .UseLine .CommandPath }}
+{{ argsToMdoc . }}
+{{- end }}
+{{- end }}
+{{- else if .SubCommands }}
+{{- range .SubCommands }}
+.Nm {{ .CommandPath | mdocCommand }}
+.Op Ar options
+{{- if not .NoArgs }}
+.Op Ar args
+{{- end }}
 {{- end }}
 {{- else }}
-.Nm {{ .CommandPath }}
+.Nm {{ .CommandPath | mdocCommand }}
 {{- range .AllFlags }}
-.Op Fl {{ if .Shorthand }}{{ .Shorthand | backslashify }} | {{ end -}}
-{{ print "-" .Name | backslashify }}
+.Op Fl {{ if .Shorthand }}{{ .Shorthand | mdocArg }}{{ if .Name }} | Fl {{ end }}{{ end -}}
+{{ if .Name }}-{{ .Name | mdocArg }}{{ end }}
+{{- if not .NoOptDefVal }} Ar {{ .ValueName | mdocArg }}{{ end }}
 {{- end }}
-{{ if not .NoArgs }}.Op Fl <args>
+{{- if not .NoArgs }}
+{{ with positionalArgs .UseLine .CommandPath }}{{ argsToMdoc . }}{{ else }}.Op Ar args{{ end }}
 {{- end }}
 {{- end }}
 .Ek
-.Sh DESCRIPTION
+.Sh {{ heading $ "DESCRIPTION" }}
 {{ .Description | simpleToMdoc }}
+{{- if includes . "OPTIONS" }}
 {{- if .AllFlags }}
 .Pp
 The options are as follows:
+{{- if .FlagGroups }}
+{{- range .FlagGroups }}
+{{- with .Title }}
+.Ss {{ . | mdocArg }}
+{{- end }}
+.Pp
+{{ template "options" .Flags }}
+{{- end }}
+{{- else }}
 .Pp
+{{ template "options" .AllFlags }}
+{{- end }}
+{{- end }}
+{{- with .InheritedFlagsFrom }}
+.Pp
+Options inherited from parent commands are described in
+.Xr {{ . | dashify | roffArg }} {{ $.InheritedFlagsSection | roffArg }} .
+{{- end }}
+{{- if .DeprecatedFlags }}
+.Ss {{ heading $ "Deprecated options" }}
 .Bl -tag -width Ds -compact
-{{ range .AllFlags -}}
+{{ range .DeprecatedFlags -}}
 .Pp
-.It {{ if .Shorthand }}Fl {{ .Shorthand | backslashify }}, {{ end -}}
-Fl {{ print "-" .Name | backslashify }}
-{{- if not .NoOptDefVal }} Ar {{if .ArgHint }} {{ .ArgHint }}{{ else }} {{ .DefValue }}{{ end }}{{ end }}
-{{ .Usage | backslashify }}
+.It {{ flagSynopsis . "mdoc" }}
+{{ .Deprecated | roffText }}{{ with .ReplacedBy }} (superseded by
+.Fl -{{ . | mdocArg }} ){{ end }}
 {{ end }}
 .El
 {{- end }}
-{{- if .Environment }}
-.Sh ENVIRONMENT
-{{ .Environment | simpleToMdoc }}
 {{- end }}
-{{- if .Files }}
-.Sh FILES
-{{ .Files | simpleToMdoc }}
+{{- if and .CommandGroups (includes . "COMMANDS") }}
+.Sh {{ heading $ "COMMANDS" }}
+{{- range .CommandGroups }}
+{{- if .Title }}
+.Ss {{ .Title | mdocArg }}
+{{- end }}
+.Bl -tag -width Ds
+{{- range .Commands }}
+.It Cm {{ .Name | mdocArg }}
+{{ .Short | roffText }}
+{{- end }}
+.El
+{{- end }}
+{{- end }}
+{{- if not .BSDStyle }}{{ template "exit status" . }}{{ end }}
+{{- if and (or .Environment .EnvironmentEntries .EnvFlags) (includes . "ENVIRONMENT") }}
+.Sh {{ heading $ "ENVIRONMENT" }}
+{{- with .Environment }}
+{{ . | simpleToMdoc }}
+{{- end }}
+{{- if or .EnvironmentEntries .EnvFlags }}
+.Bl -tag -width Ds
+{{- range .EnvironmentEntries }}
+.It Ev {{ .Name | mdocArg }}
+{{ .Description | roffText }}
+{{- end }}
+{{- range .EnvFlags }}
+.It Ev {{ .Env | mdocArg }}
+Sets
+.Fl {{ if .Name }}-{{ .Name | mdocArg }}{{ else }}{{ .Shorthand | mdocArg }}{{ end }} .
+{{- end }}
+.El
+{{- end }}
+{{- end }}
+{{- if and (or .Files .FilesEntries) (includes . "FILES") }}
+.Sh {{ heading $ "FILES" }}
+{{- with .Files }}
+{{ . | simpleToMdoc }}
+{{- end }}
+{{- if .FilesEntries }}
+.Bl -tag -width Ds
+{{- range .FilesEntries }}
+.It Pa {{ .Name | mdocArg }}
+{{ .Description | roffText }}
+{{- end }}
+.El
+{{- end }}
+{{- end }}
+{{- if .BSDStyle }}
+{{- template "exit status" . }}
+{{- template "examples" . }}
+{{- template "custom sections" . }}
+{{- template "see also" . }}
+{{- template "history" . }}
+{{- template "author" . }}
+{{- template "bugs" . }}
+{{- else }}
+{{- template "bugs" . }}
+{{- template "examples" . }}
+{{- template "history" . }}
+{{- template "author" . }}
+{{- template "custom sections" . }}
+{{- template "see also" . }}
+{{- end }}
+{{ define "options" -}}
+.Bl -tag -width Ds -compact
+{{ range . -}}
+.Pp
+.It {{ flagSynopsis . "mdoc" }}
+{{ .Usage | roffText }}{{ if .Repeatable }} (may be repeated){{ end }}{{ with .Default }} (default: {{ . | roffText }}){{ end }}{{ with .Env }} (env: {{ . | roffText }}){{ end }}
+{{ end }}
+.El
 {{- end }}
-{{- if .Bugs }}
-.Sh BUGS
+{{- define "exit status" }}{{- if and .ExitStatus (includes . "EXIT STATUS") }}
+.Sh {{ heading $ .ExitStatusHeading }}
+{{ .ExitStatus | simpleToMdoc }}
+{{- end }}{{ end }}
+{{- define "bugs" }}{{- if and .Bugs (includes . "BUGS") }}
+.Sh {{ heading $ "BUGS" }}
 {{ .Bugs | simpleToMdoc }}
+{{- end }}{{ end }}
+{{- define "examples" }}{{- if and .Examples (includes . "EXAMPLES") }}
+.Sh {{ heading $ "EXAMPLES" }}
+{{- if .ExampleGroups }}
+{{- range .ExampleGroups }}
+.Ss {{ .Title | mdocArg }}
+{{ examplesToMdoc .Examples $.CommandPath }}
 {{- end }}
-{{- if .Examples }}
-.Sh EXAMPLES
-{{ .Examples | simpleToMdoc }}
+{{- else }}
+{{ examplesToMdoc .Examples .CommandPath }}
 {{- end }}
-{{- if .Author }}
-.Sh AUTHOR
-{{ .Author }}
+{{- end }}{{ end }}
+{{- define "history" }}{{- if and .History (includes . "HISTORY") }}
+.Sh {{ heading $ "HISTORY" }}
+.Bl -tag -width Ds
+{{- range .History }}
+.It {{ .Version | mdocArg }}{{ with .Date }} Pq {{ . | mdocArg }}{{ end }}
+{{ .Note | roffText }}
 {{- end }}
-{{- if .SeeAlsos }}
-.Sh SEE ALSO
+.El
+{{- end }}{{ end }}
+{{- define "author" }}{{- if and .Author (includes . "AUTHOR") }}
+.Sh {{ heading $ "AUTHOR" }}
+{{ .Author | simpleToMdoc }}
+{{- end }}{{ end }}
+{{- define "custom sections" }}{{- range .CustomSections }}
+{{- if includes $ .Title }}
+.Sh {{ heading $ .Title | mdocArg }}
+{{ .Content | simpleToMdoc }}
+{{- end }}
+{{- end }}{{ end }}
+{{- define "see also" }}{{- if and .SeeAlsos (includes . "SEE ALSO") }}
+.Sh {{ heading $ "SEE ALSO" }}
 {{- range $index, $element := .SeeAlsos}}
 {{- if $index}} ,{{end}}
-.Xr {{ .CmdPath | dashify | backslashify }} {{ .Section }}
-{{- end }}
+.Xr {{ .CmdPath | dashify | roffArg }} {{ .Section | roffArg }}
 {{- end }}
-`
+{{- end }}{{ end }}`
 
 // .Xr {{$element.CmdPath}} {{$element.Section}}
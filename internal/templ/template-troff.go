@@ -14,73 +14,176 @@
 package templ
 
 func init() {
-	RegisterTemplate("troff", "-", "use_section", troffManTemplate)
+	registerBuiltin("troff", "-", "use_section", "Man page with basic troff macros", troffManTemplate)
 }
 
 // troffManTemplate generates a man page with only basic troff macros.
 // nolint:lll // this is a template
-const troffManTemplate = `.TH "{{.CommandPath | dashify | backslashify | upper}}" "{{ .Section }}" "{{.CenterFooter}}" "{{.LeftFooter}}" "{{.CenterHeader}}" 
+const troffManTemplate = `.TH "{{.CommandPath | dashify | upper | roffArg}}" "{{ .Section | roffArg }}" "{{.CenterFooter | roffArg}}" "{{.LeftFooter | roffArg}}" "{{.CenterHeader | roffArg}}" 
 .nh    {{/* disable hyphenation */}}
 .ad l  {{/* disable justification (adjust text to left margin only) */}}
-.SH NAME
-{{ .CommandPath | dashify | backslashify }}
-{{- if .ShortDescription }} - {{ .ShortDescription }}
+.SH {{ heading $ "NAME" }}
+{{ .CommandPath | dashify | roffArg }}
+{{- if .ShortDescription }} - {{ .ShortDescription | roffText }}
  {{- end }}
-.SH SYNOPSIS
+.SH {{ heading $ "SYNOPSIS" }}
 .sp
-{{- if .SubCommands }}
+{{- if .UseLineSynopsis }}
 {{- range .SubCommands }}
-\fB{{ .CommandPath }}\fR [ flags ]
+\fB{{ .CommandPath | roffArg }}\fR{{ with useArgs .UseLine .CommandPath }} {{ argsToTroff . }}{{ end }}
+.br{{ end }}
+{{- if not .SubCommands }}
+\fB{{ .CommandPath | roffArg }}\fR{{ with useArgs .UseLine .CommandPath }} {{ argsToTroff . }}{{ end }}
+{{- end }}
+{{- else if .SubCommands }}
+{{- range .SubCommands }}
+\fB{{ .CommandPath | roffArg }}\fR [ flags ]
 .br{{ end }}
 {{- else }}
-\fB{{ .CommandPath }} \fR
+\fB{{ .CommandPath | roffArg }} \fR
 {{- range .AllFlags -}}
-[{{ if .Shorthand }}\fI{{ print "-" .Shorthand | backslashify }}\fP|{{ end -}}
-\fI{{ print "--" .Name | backslashify }}\fP] {{ end }}
-{{- if not .NoArgs }}[<args>]{{ end }}
+[{{ if .Shorthand }}\fI{{ print "-" .Shorthand | roffText }}\fP{{ if .Name }}|{{ end }}{{ end -}}
+{{ if .Name }}\fI{{ print "--" .Name | roffText }}\fP{{ end }}] {{ end }}
+{{- if not .NoArgs }}{{ with positionalArgs .UseLine .CommandPath }}{{ argsToTroff . }}{{ else }}[<args>]{{ end }}{{ end }}
 {{- end }}
-.SH DESCRIPTION
+.SH {{ heading $ "DESCRIPTION" }}
 .PP
 {{ .Description | simpleToTroff }}
-{{- if .AllFlags }}
-.SH OPTIONS
-{{ range .AllFlags -}}
+{{- if and (or .AllFlags .DeprecatedFlags .InheritedFlagsFrom) (includes . "OPTIONS") }}
+.SH {{ heading $ "OPTIONS" }}
+{{ if .FlagGroups -}}
+{{ range .FlagGroups -}}
+{{ with .Title }}.SS "{{ . | roffArg }}"
+{{ end -}}
+{{ range .Flags }}{{ template "option" . }}{{ end -}}
+{{ end -}}
+{{ else -}}
+{{ range .AllFlags }}{{ template "option" . }}{{ end -}}
+{{ end -}}
+{{- with .InheritedFlagsFrom -}}
+.PP
+Options inherited from parent commands are described in
+.BR {{ . | dashify | roffArg }} ({{ $.InheritedFlagsSection | roffArg }}).
+{{ end }}
+{{- if .DeprecatedFlags }}
+.SS "{{ heading $ "Deprecated options" }}"
+{{ range .DeprecatedFlags -}}
 .TP
-{{ if .Shorthand }}\fB{{ print "-" .Shorthand | backslashify }}\fP, {{ end -}}
-\fB{{ print "--" .Name | backslashify }}\fP{{ if not .NoOptDefVal }} =
-{{- if .ArgHint }} <{{ .ArgHint }}>{{ else }} {{ .DefValue }}{{ end }}{{ end }}
-{{ .Usage | backslashify }}
+{{ flagSynopsis . "troff" }}
+{{ .Deprecated | roffText }}{{ with .ReplacedBy }} (superseded by \fB{{ print "--" . | roffText }}\fP){{ end }}
 {{ end }}
+{{- end }}
 {{- end -}}
-{{- if .Environment }}
-.SH ENVIRONMENT
+{{- if and .CommandGroups (includes . "COMMANDS") }}
+.SH {{ heading $ "COMMANDS" }}
+{{- range .CommandGroups }}
+{{- if .Title }}
+.SS "{{ .Title | roffArg }}"
+{{- end }}
+{{- range .Commands }}
+.TP
+\fB{{ .CommandPath | roffText }}\fP
+{{ .Short | roffText }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if not .BSDStyle }}{{ template "exit status" . }}{{ end }}
+{{- if and (or .Environment .EnvironmentEntries .EnvFlags) (includes . "ENVIRONMENT") }}
+.SH {{ heading $ "ENVIRONMENT" }}
+{{- with .Environment }}
 .PP
-{{ .Environment | simpleToTroff }}
+{{ . | simpleToTroff }}
+{{- end }}
+{{- range .EnvironmentEntries }}
+.TP
+\fB{{ .Name | roffText }}\fP
+{{ .Description | roffText }}
+{{- end }}
+{{- range .EnvFlags }}
+.TP
+\fB{{ .Env | roffText }}\fP
+Sets \fB{{ if .Name }}{{ print "--" .Name | roffText }}{{ else }}{{ print "-" .Shorthand | roffText }}{{ end }}\fP.
+{{- end }}
 {{- end }}
-{{- if .Files }}
-.SH FILES
+{{- if and (or .Files .FilesEntries) (includes . "FILES") }}
+.SH {{ heading $ "FILES" }}
+{{- with .Files }}
 .PP
-{{ .Files | simpleToTroff }}
+{{ . | simpleToTroff }}
 {{- end }}
-{{- if .Bugs }}
-.SH BUGS
+{{- range .FilesEntries }}
+.TP
+\fI{{ .Name | roffText }}\fP
+{{ .Description | roffText }}
+{{- end }}
+{{- end }}
+{{- if .BSDStyle }}
+{{- template "exit status" . }}
+{{- template "examples" . }}
+{{- template "custom sections" . }}
+{{- template "see also" . }}
+{{- template "history" . }}
+{{- template "author" . }}
+{{- template "bugs" . }}
+{{- else }}
+{{- template "bugs" . }}
+{{- template "examples" . }}
+{{- template "history" . }}
+{{- template "author" . }}
+{{- template "custom sections" . }}
+{{- template "see also" . }}
+{{- end }}
+{{ define "option" -}}
+.TP
+{{ flagSynopsis . "troff" }}
+{{ .Usage | roffText }}{{ if .Repeatable }} (may be repeated){{ end }}{{ with .Default }} (default: {{ . | roffText }}){{ end }}{{ with .Env }} (env: {{ . | roffText }}){{ end }}
+{{ end }}
+{{- define "exit status" }}{{- if and .ExitStatus (includes . "EXIT STATUS") }}
+.SH {{ heading $ .ExitStatusHeading }}
+.PP
+{{ .ExitStatus | simpleToTroff }}
+{{- end }}{{ end }}
+{{- define "bugs" }}{{- if and .Bugs (includes . "BUGS") }}
+.SH {{ heading $ "BUGS" }}
 .PP
 {{ .Bugs | simpleToTroff }}
+{{- end }}{{ end }}
+{{- define "examples" }}{{- if and .Examples (includes . "EXAMPLES") }}
+.SH {{ heading $ "EXAMPLES" }}
+{{- if .ExampleGroups }}
+{{- range .ExampleGroups }}
+.SS "{{ .Title | roffArg }}"
+{{ examplesToTroff .Examples $.CommandPath }}
 {{- end }}
-{{- if .Examples }}
-.SH EXAMPLES
-.PP
-{{ .Examples | simpleToTroff }}
+{{- else }}
+{{ examplesToTroff .Examples .CommandPath }}
+{{- end }}
+{{- end }}{{ end }}
+{{- define "history" }}{{- if and .History (includes . "HISTORY") }}
+.SH {{ heading $ "HISTORY" }}
+{{- range .History }}
+.TP
+\fB{{ .Version | roffText }}\fP{{ with .Date }} ({{ . | roffText }}){{ end }}
+{{ .Note | roffText }}
 {{- end }}
-.SH AUTHOR
+{{- end }}{{ end }}
+{{- define "author" }}{{- if includes . "AUTHOR" }}
+.SH {{ heading $ "AUTHOR" }}
 {{- if .Author }}
-{{ .Author }}
+{{ .Author | simpleToTroff }}
 {{- end }}
 .PP
-{{- if .SeeAlsos }}
-.SH SEE ALSO
-{{- range .SeeAlsos }}
-.BR {{ .CmdPath | dashify | backslashify }} ({{ .Section }})
+{{- end }}{{ end }}
+{{- define "custom sections" }}{{- range .CustomSections }}
+{{- if includes $ .Title }}
+.SH {{ heading $ .Title | roffArg }}
+.PP
+{{ .Content | simpleToTroff }}
 {{- end }}
+{{- end }}{{ end }}
+{{- define "see also" }}{{- if and .SeeAlsos (includes . "SEE ALSO") }}
+.SH {{ heading $ "SEE ALSO" }}
+{{- range .SeeAlsos }}
+.BR {{ .CmdPath | dashify | roffArg }} ({{ .Section | roffArg }})
 {{- end }}
-`
+{{- end }}{{ end }}`
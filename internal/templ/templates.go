@@ -14,59 +14,266 @@
 package templ
 
 import (
+	"os"
+	"slices"
 	"strings"
+	"sync"
 	"text/template"
 )
 
+// manTemplate is a registered template.  It is parsed on first use, and
+// parsed again if template functions were added since.
 type manTemplate struct {
-	separator string
-	extension string
-	template  *template.Template
+	separator   string
+	extension   string
+	description string
+	builtIn     bool
+	source      string
+	template    *template.Template
+	revision    int // of the template functions template was parsed with
 }
 
-var templateMap = make(map[string]manTemplate)
+// TemplateInfo describes a registered template, see GetTemplateInfo.
+type TemplateInfo struct {
+	Name string
+	// Separator joins the words of the command path in file names
+	Separator string
+	// Extension is the extension of the files, "use_section" for the man
+	// page section
+	Extension   string
+	Description string
+	// BuiltIn is set for the templates that come with cobraman
+	BuiltIn bool
+}
+
+var (
+	// templateMu guards templateMap, templateFuncs and funcsRevision
+	templateMu  sync.Mutex
+	templateMap = make(map[string]*manTemplate)
+	// funcsRevision is incremented whenever templateFuncs changes
+	funcsRevision int
+)
 
 var templateFuncs = template.FuncMap{
-	"upper":          strings.ToUpper,
-	"backslashify":   Backslashify,
-	"dashify":        Dashify,
-	"underscoreify":  Underscoreify,
-	"simpleToTroff":  SimpleToTroff,
-	"simpleToMdoc":   SimpleToMdoc,
-	"makeline":       Makeline,
-	"trim":           strings.TrimSpace,
-	"trimRightSpace": TrimRightSpace,
-	"rpad":           PadR,
+	"upper":              strings.ToUpper,
+	"backslashify":       Backslashify,
+	"escapeControlLines": EscapeControlLines,
+	"roffText":           RoffText,
+	"roffArg":            RoffArg,
+	"roffLiteral":        RoffLiteral,
+	"markdownLinks":      MarkdownLinks,
+	"formatExamples":     FormatExamples,
+	"examplesToTroff":    ExamplesToTroff,
+	"examplesToMdoc":     ExamplesToMdoc,
+	"examplesToMarkdown": ExamplesToMarkdown,
+	"useArgs":            UseArgs,
+	"positionalArgs":     PositionalArgs,
+	"parseArgs":          ParseArgs,
+	"argsToTroff":        ArgsToTroff,
+	"argsToMdoc":         ArgsToMdoc,
+	"argsToMarkdown":     ArgsToMarkdown,
+	"mdocArg":            MdocArg,
+	"mdocCommand":        MdocCommand,
+	"dashify":            Dashify,
+	"underscoreify":      Underscoreify,
+	"simpleToTroff":      SimpleToTroff,
+	"simpleToMdoc":       SimpleToMdoc,
+	"simpleToHTML":       SimpleToHTML,
+	"makeline":           Makeline,
+	"trim":               strings.TrimSpace,
+	"trimRightSpace":     TrimRightSpace,
+	"rpad":               PadR,
+	"displayWidth":       StringWidth,
+	"flagSynopsis":       FlagSynopsis,
+	"markdownToText":     MarkdownToText,
+	"includes":           Includes,
+	"heading":            Heading,
+	"pageFile":           PageFile,
+	"frontMatter":        FrontMatter,
 }
 
 // AddTemplateFunc adds a template function that's available to doc templates.
 func AddTemplateFunc(name string, tmplFunc interface{}) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
 	templateFuncs[name] = tmplFunc
+	funcsRevision++
 }
 
 // AddTemplateFuncs adds multiple template functions that are available to doc templates.
 func AddTemplateFuncs(tmplFuncs template.FuncMap) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
 	for k, v := range tmplFuncs {
 		templateFuncs[k] = v
 	}
+	funcsRevision++
 }
 
 // RegisterTemplate takes a template string creates a template for use with CobraMan.  It
 // also takes a separator and file extension to be used when generating the file names for
-// the generated files.
+// the generated files.  The template is parsed when it is first used, and GetTemplate
+// returns the error if it is not valid.
 func RegisterTemplate(name string, separator string, extension string, templateString string) {
-	// Build the template
-	parsedTemplate := template.Must(template.New(name).Funcs(templateFuncs).Parse(templateString))
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	templateMap[name] = &manTemplate{
+		separator: separator,
+		extension: extension,
+		source:    templateString,
+	}
+}
+
+// registerBuiltin registers one of the templates that come with cobraman.
+func registerBuiltin(name string, separator string, extension string, description string, templateString string) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	templateMap[name] = &manTemplate{
+		separator:   separator,
+		extension:   extension,
+		description: description,
+		builtIn:     true,
+		source:      templateString,
+	}
+}
+
+// DescribeTemplate sets the human-readable description GetTemplateInfo
+// returns for the template registered as name, e.g. "Man page for the docs
+// site".  It does nothing if there is no such template.
+func DescribeTemplate(name string, description string) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	if t := templateMap[name]; t != nil {
+		t.description = description
+	}
+}
+
+// GetTemplateInfo returns the description of the template registered as
+// name, for building help texts, validation or format pickers from the
+// registered templates.  ok is false if there is no such template.
+func GetTemplateInfo(name string) (info TemplateInfo, ok bool) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	t := templateMap[name]
+	if t == nil {
+		return TemplateInfo{}, false
+	}
+	return t.info(name), true
+}
+
+// TemplateInfos returns the descriptions of all registered templates, sorted
+// by name.
+func TemplateInfos() []TemplateInfo {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	infos := make([]TemplateInfo, 0, len(templateMap))
+	for name, t := range templateMap {
+		infos = append(infos, t.info(name))
+	}
+	slices.SortFunc(infos, func(a, b TemplateInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
 
-	t := manTemplate{
+func (t *manTemplate) info(name string) TemplateInfo {
+	return TemplateInfo{
+		Name:        name,
+		Separator:   t.separator,
+		Extension:   t.extension,
+		Description: t.description,
+		BuiltIn:     t.builtIn,
+	}
+}
+
+// RegisterTemplateFile is like RegisterTemplate but reads the template from
+// the file at path.  Since the template typically comes from a user rather
+// than from code, it is parsed right away and errors are returned instead of
+// causing a panic.
+func RegisterTemplateFile(name string, separator string, extension string, path string) error {
+	content, err := os.ReadFile(path) //nolint:gosec // reading the template is the point
+	if err != nil {
+		return err
+	}
+
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	parsedTemplate, err := template.New(name).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return err
+	}
+	templateMap[name] = &manTemplate{
 		separator: separator,
 		extension: extension,
+		source:    string(content),
 		template:  parsedTemplate,
+		revision:  funcsRevision,
 	}
-	templateMap[name] = t
+	return nil
 }
 
-func GetTemplate(name string) (sep string, ext string, tmpl *template.Template) {
+// WithHyphensAsMinus returns a copy of tmpl in which roffText, simpleToTroff
+// and simpleToMdoc escape every '-' as a minus sign, as they did before
+// hyphens within words were kept.
+func WithHyphensAsMinus(tmpl *template.Template) (*template.Template, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	const mode = EscapeProse | EscapeHyphensAsMinus
+	return clone.Funcs(template.FuncMap{
+		"roffText":      func(str string) string { return RoffEscape(str, mode) },
+		"simpleToTroff": func(str string) string { return simpleToRoff(str, ".PP", mode, troffLinks) },
+		"simpleToMdoc":  func(str string) string { return simpleToRoff(str, ".Pp", mode, mdocLinks) },
+	}), nil
+}
+
+// WithLayout returns a copy of tmpl in which the "layout" template, which
+// wraps the "body" template of the page content in the HTML template, is
+// replaced by the template text layout.
+func WithLayout(tmpl *template.Template, layout string) (*template.Template, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := clone.New("layout").Parse(layout); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// GetTemplate returns the separator, extension and parsed template of the
+// template registered as name, parsing it if it has not been parsed with the
+// current template functions yet.  tmpl is nil if there is no such template,
+// or if it is not valid, in which case err is the parse error.
+func GetTemplate(name string) (sep string, ext string, tmpl *template.Template, err error) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
 	t := templateMap[name]
-	return t.separator, t.extension, t.template
+	if t == nil {
+		return "", "", nil, nil
+	}
+	if t.template == nil || t.revision != funcsRevision {
+		parsed, err := template.New(name).Funcs(templateFuncs).Parse(t.source)
+		if err != nil {
+			return t.separator, t.extension, nil, err
+		}
+		t.template = parsed
+		t.revision = funcsRevision
+	}
+	return t.separator, t.extension, t.template, nil
+}
+
+// ExpandText executes text, such as the value of an annotation, as a template
+// with data and the template functions available to doc templates.
+func ExpandText(name string, text string, data interface{}) (string, error) {
+	templateMu.Lock()
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	templateMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
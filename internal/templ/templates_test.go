@@ -14,6 +14,9 @@
 package templ_test
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/carlwr/cobraman/internal/templ"
@@ -21,6 +24,90 @@ import (
 )
 
 func TestRegisterTemplate(t *testing.T) {
-	assert.Panics(t, func() { templ.RegisterTemplate("bad", "-", "txt", "what {{ ") }, "The code did not panic")
+	// templates are parsed on first use
+	assert.NotPanics(t, func() { templ.RegisterTemplate("bad", "-", "txt", "what {{ ") }, "The code should not panic")
+	sep, ext, tmpl, err := templ.GetTemplate("bad")
+	assert.Error(t, err)
+	assert.Nil(t, tmpl)
+	assert.Equal(t, "-", sep)
+	assert.Equal(t, "txt", ext)
 	assert.NotPanics(t, func() { templ.RegisterTemplate("good", "-", "txt", "Hello {{ \"world\" }} ") }, "The code should not panic")
+	_, _, tmpl, err = templ.GetTemplate("good")
+	assert.NoError(t, err)
+	assert.NotNil(t, tmpl)
+}
+
+func TestTemplateCache(t *testing.T) {
+	templ.RegisterTemplate("cached", "-", "txt", `{{ "x" | upper }}`)
+	_, _, first, _ := templ.GetTemplate("cached")
+	_, _, again, _ := templ.GetTemplate("cached")
+	assert.Same(t, first, again)
+
+	// adding a function parses the template again, so it can be used
+	templ.RegisterTemplate("later", "-", "txt", `{{ shout "x" }}`)
+	templ.AddTemplateFunc("shout", strings.ToUpper)
+	_, _, tmpl, err := templ.GetTemplate("later")
+	assert.NoError(t, err)
+	assert.NotNil(t, tmpl)
+	_, _, again, _ = templ.GetTemplate("cached")
+	assert.NotSame(t, first, again)
+
+	sep, ext, tmpl, err := templ.GetTemplate("unknown")
+	assert.NoError(t, err)
+	assert.Equal(t, "", sep)
+	assert.Equal(t, "", ext)
+	assert.Nil(t, tmpl)
+}
+
+func TestRegisterTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.tmpl")
+	bad := filepath.Join(dir, "bad.tmpl")
+	assert.NoError(t, os.WriteFile(good, []byte(`{{ "x" | upper }}`), 0o600))
+	assert.NoError(t, os.WriteFile(bad, []byte(`what {{ `), 0o600))
+
+	assert.NoError(t, templ.RegisterTemplateFile("fromfile", "_", "txt", good))
+	sep, ext, tmpl, _ := templ.GetTemplate("fromfile")
+	assert.Equal(t, "_", sep)
+	assert.Equal(t, "txt", ext)
+	assert.NotNil(t, tmpl)
+
+	assert.Error(t, templ.RegisterTemplateFile("badfile", "_", "txt", bad))
+	assert.Error(t, templ.RegisterTemplateFile("nofile", "_", "txt", filepath.Join(dir, "missing")))
+	_, _, tmpl, _ = templ.GetTemplate("badfile")
+	assert.Nil(t, tmpl)
+}
+
+func TestGetTemplateInfo(t *testing.T) {
+	info, ok := templ.GetTemplateInfo("troff")
+	assert.True(t, ok)
+	assert.Equal(t, templ.TemplateInfo{
+		Name:        "troff",
+		Separator:   "-",
+		Extension:   "use_section",
+		Description: "Man page with basic troff macros",
+		BuiltIn:     true,
+	}, info)
+
+	templ.RegisterTemplate("site", "_", "txt", "{{ .CommandPath }}")
+	templ.DescribeTemplate("site", "Plain text page for the docs site")
+	info, ok = templ.GetTemplateInfo("site")
+	assert.True(t, ok)
+	assert.Equal(t, templ.TemplateInfo{
+		Name:        "site",
+		Separator:   "_",
+		Extension:   "txt",
+		Description: "Plain text page for the docs site",
+	}, info)
+
+	_, ok = templ.GetTemplateInfo("unknown")
+	assert.False(t, ok)
+
+	var builtIn []string
+	for _, info := range templ.TemplateInfos() {
+		if info.BuiltIn {
+			builtIn = append(builtIn, info.Name)
+		}
+	}
+	assert.Equal(t, []string{"html", "markdown", "mdoc", "troff"}, builtIn)
 }
@@ -0,0 +1,174 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"strings"
+)
+
+// UseArgs returns what follows the command path in the usage line useLine,
+// e.g. "RESOURCE [NAME] [flags]" for "kubectl get RESOURCE [NAME] [flags]".
+// The whole usage line is returned if it does not start with commandPath.
+func UseArgs(useLine string, commandPath string) string {
+	rest := strings.TrimPrefix(useLine, commandPath)
+	if rest != useLine && rest != "" && rest[0] != ' ' {
+		return useLine
+	}
+	return strings.TrimSpace(rest)
+}
+
+// Arg is a positional argument in the usage of a command.
+type Arg struct {
+	// Name is the name of the argument without the brackets and ellipsis
+	// around it, e.g. "file" for "[<file>...]"
+	Name string
+	// Optional is set if the argument is in square brackets
+	Optional bool
+	// Repeated is set if the argument is followed by an ellipsis
+	Repeated bool
+}
+
+// ParseArgs parses the arguments part of a usage line, see UseArgs, by the
+// conventions of cobra and docopt: "<file>" and "FILE" are required
+// arguments, "[file]" is optional and "file..." may be repeated.  Words are
+// split at spaces outside of brackets, so "[NAME [VALUE]]" is a single
+// optional argument named "NAME [VALUE]".  The flags placeholder "[flags]"
+// of cobra is the optional argument "options", as in the synopsis of
+// commands without a usage line.
+func ParseArgs(args string) []Arg {
+	var parsed []Arg
+	for _, word := range splitArgs(args) {
+		if word == flagsPlaceholder {
+			parsed = append(parsed, Arg{Name: "options", Optional: true})
+			continue
+		}
+		var arg Arg
+		word, arg.Repeated = trimEllipsis(word)
+		if strings.HasPrefix(word, "[") && strings.HasSuffix(word, "]") {
+			word, arg.Optional = word[1:len(word)-1], true
+			var repeated bool
+			word, repeated = trimEllipsis(word)
+			arg.Repeated = arg.Repeated || repeated
+		}
+		if strings.HasPrefix(word, "<") && strings.HasSuffix(word, ">") {
+			word = word[1 : len(word)-1]
+		}
+		arg.Name = word
+		parsed = append(parsed, arg)
+	}
+	return parsed
+}
+
+// splitArgs splits args at the spaces outside of brackets.
+func splitArgs(args string) []string {
+	var words []string
+	depth, start := 0, -1
+	for i, r := range args {
+		switch {
+		case strings.ContainsRune("[<({", r):
+			depth++
+		case strings.ContainsRune("]>)}", r) && depth > 0:
+			depth--
+		case r == ' ' || r == '\t':
+			if depth == 0 {
+				if start >= 0 {
+					words = append(words, args[start:i])
+				}
+				start = -1
+				continue
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, args[start:])
+	}
+	return words
+}
+
+// trimEllipsis removes a trailing ellipsis from word and reports whether
+// there was one.
+func trimEllipsis(word string) (string, bool) {
+	for _, ellipsis := range []string{"...", "…"} {
+		if trimmed := strings.TrimSuffix(word, ellipsis); trimmed != word && trimmed != "" {
+			return trimmed, true
+		}
+	}
+	return word, false
+}
+
+// flagsPlaceholder is the summary of the flags that cobra adds to usage
+// lines.
+const flagsPlaceholder = "[flags]"
+
+// PositionalArgs is UseArgs without the flags placeholder that cobra appends
+// to the usage line of commands with flags, for templates that list the flags
+// themselves.
+func PositionalArgs(useLine string, commandPath string) string {
+	return strings.TrimSpace(strings.TrimSuffix(UseArgs(useLine, commandPath), flagsPlaceholder))
+}
+
+// ArgsToTroff renders the arguments args, see ParseArgs, for a troff
+// SYNOPSIS: the names are in italics, optional arguments in brackets and
+// repeated ones followed by an ellipsis.
+func ArgsToTroff(args string) string {
+	var words []string
+	for _, arg := range ParseArgs(args) {
+		word := `\fI` + RoffText(arg.Name) + `\fP`
+		if arg.Optional {
+			word = "[" + word + "]"
+		}
+		if arg.Repeated {
+			word += " ..."
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// ArgsToMdoc is ArgsToTroff for mdoc, rendering every argument on a line of
+// its own with Ar, within Op if it is optional.
+func ArgsToMdoc(args string) string {
+	var lines []string
+	for _, arg := range ParseArgs(args) {
+		line := "Ar " + MdocArg(arg.Name)
+		if arg.Repeated {
+			line += " ..."
+		}
+		if arg.Optional {
+			line = "Op " + line
+		}
+		lines = append(lines, "."+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ArgsToMarkdown is ArgsToTroff for markdown code blocks, rendering the
+// arguments as plain text.
+func ArgsToMarkdown(args string) string {
+	var words []string
+	for _, arg := range ParseArgs(args) {
+		word := arg.Name
+		if arg.Optional {
+			word = "[" + word + "]"
+		}
+		if arg.Repeated {
+			word += "..."
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,51 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseArgs(t *testing.T) {
+	assert.Equal(t, "RESOURCE [NAME] [flags]", templ.UseArgs("kubectl get RESOURCE [NAME] [flags]", "kubectl get"))
+	assert.Equal(t, "", templ.UseArgs("kubectl get", "kubectl get"))
+	assert.Equal(t, "kubectl getall", templ.UseArgs("kubectl getall", "kubectl get"))
+	assert.Equal(t, "other [flags]", templ.UseArgs("other [flags]", "kubectl get"))
+}
+
+func TestParseArgs(t *testing.T) {
+	assert.Equal(t, []templ.Arg{
+		{Name: "file"},
+		{Name: "NAME"},
+		{Name: "opt", Optional: true},
+		{Name: "src", Repeated: true},
+		{Name: "dir", Optional: true, Repeated: true},
+		{Name: "x", Optional: true, Repeated: true},
+		{Name: "KEY [VALUE]", Optional: true},
+		{Name: "..."},
+	}, templ.ParseArgs("<file> NAME  [opt] <src>... [dir...] [x]… [KEY [VALUE]] ..."))
+	assert.Nil(t, templ.ParseArgs(""))
+
+	assert.Equal(t, "RESOURCE [NAME]", templ.PositionalArgs("kubectl get RESOURCE [NAME] [flags]", "kubectl get"))
+	assert.Equal(t, `\fIsrc\fP ... [\fIdest-dir\fP]`, templ.ArgsToTroff("<src>... [dest-dir]"))
+	assert.Equal(t, ".Ar src ...\n.Op Ar dest-dir", templ.ArgsToMdoc("<src>... [dest-dir]"))
+	assert.Equal(t, "src... [dest-dir]", templ.ArgsToMarkdown("<src>... [dest-dir]"))
+
+	assert.Equal(t, `\fIfile\fP [\fIoptions\fP]`, templ.ArgsToTroff("<file> [flags]"))
+	assert.Equal(t, ".Ar file\n.Op Ar options", templ.ArgsToMdoc("<file> [flags]"))
+	assert.Equal(t, "file [options]", templ.ArgsToMarkdown("<file> [flags]"))
+}
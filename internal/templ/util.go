@@ -14,7 +14,6 @@
 package templ
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 	"unicode"
@@ -23,34 +22,37 @@ import (
 var multiNewlineRegex = regexp.MustCompile(`\n+\n`)
 
 func SimpleToMdoc(str string) string {
-	// Guessing this is already troff - so let it pass through
-	if len(str) > 1 && str[0] == '.' {
-		return str
-	}
-
-	// TODO: this could certainly be more sophisticated.  Pull requests welcome!
-	// Right now it is good enough for the most simple cases.
-	return Backslashify(multiNewlineRegex.ReplaceAllString(str, "\n.Pp\n"))
+	return simpleToRoff(str, ".Pp", EscapeProse, mdocLinks)
 }
 
 func SimpleToTroff(str string) string {
-	// Guessing this is already troff - so let it pass through
-	if len(str) > 1 && str[0] == '.' {
-		return str
-	}
+	return simpleToRoff(str, ".PP", EscapeProse, troffLinks)
+}
 
+// simpleToRoff escapes str with RoffEscape in the given mode, marks up its
+// links with the links macros and separates its paragraphs with the
+// paragraph macro para.
+func simpleToRoff(str string, para string, mode EscapeMode, links roffLinkMacros) string {
 	// TODO: this could certainly be more sophisticated.  Pull requests welcome!
 	// Right now it is good enough for the most simple cases.
-	return Backslashify(multiNewlineRegex.ReplaceAllString(str, "\n.PP\n"))
+	return multiNewlineRegex.ReplaceAllString(roffWithLinks(str, mode, links), "\n"+para+"\n")
 }
 
-var backslashReplacer *strings.Replacer
+var backslashReplacer = strings.NewReplacer("-", "\\-", "_", "\\_", "&", "\\&", "\\", "\\\\", "~", "\\~")
 
+// Backslashify escapes the characters - _ & \ ~ with a backslash.  It is kept
+// for existing templates; RoffEscape handles more cases correctly.
 func Backslashify(str string) string {
-	if backslashReplacer == nil {
-		backslashReplacer = strings.NewReplacer("-", "\\-", "_", "\\_", "&", "\\&", "\\", "\\\\", "~", "\\~")
-	}
-	return backslashReplacer.Replace(str)
+	return EscapeControlLines(backslashReplacer.Replace(str))
+}
+
+var controlLineRegex = regexp.MustCompile(`(?m)^[.']`)
+
+// EscapeControlLines prefixes lines starting with a roff control character,
+// a '.' or an apostrophe, with the zero-width \& so they are printed as text
+// instead of being interpreted as requests or macros.
+func EscapeControlLines(str string) string {
+	return controlLineRegex.ReplaceAllString(str, `\&$0`)
 }
 
 func Dashify(str string) string {
@@ -65,16 +67,18 @@ func TrimRightSpace(s string) string {
 	return strings.TrimRightFunc(s, unicode.IsSpace)
 }
 
-// PadR adds padding to the right of a string.
+// PadR adds padding to the right of a string, so that it takes up padding
+// columns on a terminal.  Wide characters, such as CJK ideographs, count as
+// two columns, see StringWidth.
 func PadR(s string, padding int) string {
-	template := fmt.Sprintf("%%-%ds", padding)
-	return fmt.Sprintf(template, s)
+	if width := StringWidth(s); width < padding {
+		return s + strings.Repeat(" ", padding-width)
+	}
+	return s
 }
 
+// Makeline returns a line of char as wide as str is on a terminal, e.g. to
+// underline it.
 func Makeline(str string, char byte) string {
-	b := make([]byte, len(str))
-	for i := range b {
-		b[i] = char
-	}
-	return string(b)
+	return strings.Repeat(string(char), StringWidth(str))
 }
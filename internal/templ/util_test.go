@@ -29,6 +29,7 @@ func TestBackslashify(t *testing.T) {
 		{`foo\bar`, `foo\\bar`},
 		{`foo~bar`, `foo\~bar`},
 		{`-_&\~`, `\-\_\&\\\~`},
+		{".foo\n'bar\n x.y", "\\&.foo\n\\&'bar\n x.y"},
 	}
 
 	for i := 0; i < len(cases); i++ {
@@ -38,6 +39,20 @@ func TestBackslashify(t *testing.T) {
 	}
 }
 
+func TestEscapeControlLines(t *testing.T) {
+	cases := [][]string{
+		{"foo", "foo"},
+		{".foo", `\&.foo`},
+		{"'foo", `\&'foo`},
+		{"a\n.b\n c\n'd", "a\n\\&.b\n c\n\\&'d"},
+		{"a.b'c", "a.b'c"},
+	}
+
+	for i := 0; i < len(cases); i++ {
+		assert.Equal(t, cases[i][1], templ.EscapeControlLines(cases[i][0]))
+	}
+}
+
 func TestDashify(t *testing.T) {
 	cases := [][]string{
 		{`foo bar`, `foo-bar`},
@@ -70,9 +85,10 @@ func TestSimpleToTroff(t *testing.T) {
 	cases := [][]string{
 		{"Some test\none a line", "Some test\none a line"},
 		{"Some test\n\nwith empty line", "Some test\n.PP\nwith empty line"},
-		{".ignore me\n\none a line", ".ignore me\n\none a line"},
+		{".not a macro\n\none a line", "\\&.not a macro\n.PP\none a line"},
 		{"Some test\n\n\nwith empty line", "Some test\n.PP\nwith empty line"},
 		{"Some test\n\n\n\nwith empty line", "Some test\n.PP\nwith empty line"},
+		{"Some test\n.injected\n\n'also", "Some test\n\\&.injected\n.PP\n\\&'also"},
 	}
 
 	for i := 0; i < len(cases); i++ {
@@ -86,9 +102,10 @@ func TestSimpleToMdoc(t *testing.T) {
 	cases := [][]string{
 		{"Some test\none a line", "Some test\none a line"},
 		{"Some test\n\nwith empty line", "Some test\n.Pp\nwith empty line"},
-		{".ignore me\n\none a line", ".ignore me\n\none a line"},
+		{".not a macro\n\none a line", "\\&.not a macro\n.Pp\none a line"},
 		{"Some test\n\n\nwith empty line", "Some test\n.Pp\nwith empty line"},
 		{"Some test\n\n\n\nwith empty line", "Some test\n.Pp\nwith empty line"},
+		{"Some test\n.injected\n\n'also", "Some test\n\\&.injected\n.Pp\n\\&'also"},
 	}
 
 	for i := 0; i < len(cases); i++ {
@@ -103,6 +120,9 @@ func TestRpad(t *testing.T) {
 		{"foo", "10", "foo       x"},
 		{"foo bar", "10", "foo bar   x"},
 		{"foo bar cat", "10", "foo bar catx"},
+		{"日本語", "10", "日本語    x"},
+		{"한국어 ok", "10", "한국어 ok x"},
+		{"cafe\u0301", "6", "cafe\u0301  x"},
 	}
 
 	for i := 0; i < len(cases); i++ {
@@ -113,6 +133,15 @@ func TestRpad(t *testing.T) {
 	}
 }
 
+func TestStringWidth(t *testing.T) {
+	assert.Equal(t, 3, templ.StringWidth("foo"))
+	assert.Equal(t, 4, templ.StringWidth("naïf"))
+	assert.Equal(t, 6, templ.StringWidth("日本語"))
+	assert.Equal(t, 4, templ.StringWidth("ｆｕ"))
+	assert.Equal(t, 2, templ.StringWidth("e\u0301e\u0301"))
+	assert.Equal(t, 2, templ.StringWidth("🙂"))
+}
+
 func TestTrimRightSpace(t *testing.T) {
 	cases := [][]string{
 		{"foo   ", "foox"},
@@ -130,6 +159,7 @@ func TestMakeline(t *testing.T) {
 	cases := [][]string{
 		{"foo", "-", "---"},
 		{"foo bar", "*", "*******"},
+		{"中文", "=", "===="},
 	}
 
 	for i := 0; i < len(cases); i++ {
@@ -0,0 +1,155 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WrapRoff wraps the text lines of the man page source page: lines longer
+// than width runes are broken at spaces if width is positive, and every
+// sentence is put on a line of its own if sentences is set.  Since roff
+// fills text lines, this does not change the rendered page.  Control lines,
+// lines starting with whitespace, the tag lines of .TP, the NAME line and
+// no-fill blocks are left as they are.
+func WrapRoff(page string, width int, sentences bool) string {
+	lines := strings.Split(page, "\n")
+	out := make([]string, 0, len(lines))
+	noFill := false
+	keepNext := false
+	for _, line := range lines {
+		keep := keepNext
+		keepNext = false
+		switch {
+		case strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'"):
+			macro, _, _ := strings.Cut(line[1:], " ")
+			switch macro {
+			case "nf", "EX", "Bd", "TS":
+				noFill = true
+			case "fi", "EE", "Ed", "TE":
+				noFill = false
+			case "TP":
+				keepNext = true
+			case "SH":
+				keepNext = strings.TrimSpace(line[3:]) == "NAME"
+			}
+			out = append(out, line)
+		case keep || noFill || line == "" || line[0] == ' ' || line[0] == '\t':
+			out = append(out, line)
+		default:
+			for _, l := range wrapLine(line, width, sentences, "", canStartRoffLine) {
+				if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+					l = `\&` + l
+				}
+				out = append(out, l)
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// markdownListItem matches the marker of a markdown list item.
+var markdownListItem = regexp.MustCompile(`^([*+-]|\d+[.)]) `)
+
+// markdownBlockStart matches words that start a markdown block, such as a
+// list item or a heading, if they are at the start of a line.
+var markdownBlockStart = regexp.MustCompile(`^([*+>-]|#+|\d+[.)]|=+|-+|\|.*|` + "```.*|~~~.*" + `)$`)
+
+// WrapMarkdown is WrapRoff for markdown.  Paragraphs and list items are
+// wrapped, with the continuation lines of list items indented; headings,
+// tables, block quotes, indented lines, fenced code blocks and front matter
+// are left as they are.  Lines are not broken before words that would start
+// a block.
+func WrapMarkdown(page string, width int, sentences bool) string {
+	lines := strings.Split(page, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	if lines[0] == "---" {
+		// front matter ends like a fenced code block
+		fence = "---"
+		out = append(out, lines[0])
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		switch {
+		case fence != "":
+			if strings.HasPrefix(line, fence) {
+				fence = ""
+			}
+			out = append(out, line)
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			fence = line[:len(line)-len(strings.TrimLeft(line, line[:1]))]
+			out = append(out, line)
+		case line == "" || strings.ContainsRune("#|>< \t[", rune(line[0])):
+			out = append(out, line)
+		default:
+			indent := ""
+			if marker := markdownListItem.FindString(line); marker != "" {
+				indent = strings.Repeat(" ", len(marker))
+			}
+			out = append(out, wrapLine(line, width, sentences, indent, canStartMarkdownLine)...)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func canStartRoffLine(string) bool { return true }
+
+func canStartMarkdownLine(word string) bool { return !markdownBlockStart.MatchString(word) }
+
+// wrapLine breaks line at spaces as described for WrapRoff, and indents the
+// continuation lines with indent.  A line is never broken before a word for
+// which canStart returns false or after a word ending with a backslash, and
+// words longer than width are not broken.
+func wrapLine(line string, width int, sentences bool, indent string, canStart func(word string) bool) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return []string{line}
+	}
+
+	var lines []string
+	current := words[0]
+	currentLen := utf8.RuneCountInString(current)
+	for i, word := range words[1:] {
+		wordLen := utf8.RuneCountInString(word)
+		newSentence := sentences && endsSentence(words[i]) && startsSentence(word)
+		tooLong := width > 0 && currentLen+1+wordLen > width
+		// a backslash at the end of a line would escape the newline
+		if (newSentence || tooLong) && canStart(word) && !strings.HasSuffix(words[i], `\`) {
+			lines = append(lines, current)
+			current, currentLen = indent+word, len(indent)+wordLen
+			continue
+		}
+		current += " " + word
+		currentLen += 1 + wordLen
+	}
+	return append(lines, current)
+}
+
+// endsSentence reports whether word ends a sentence, e.g. "done." or "it?)".
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, `)"'`+"’”")
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "?") || strings.HasSuffix(word, "!")
+}
+
+// startsSentence reports whether word can start a sentence, i.e. starts with
+// an upper case letter, possibly after an opening quote or parenthesis.
+func startsSentence(word string) bool {
+	word = strings.TrimLeft(word, `("'`+"‘“")
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r)
+}
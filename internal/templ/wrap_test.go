@@ -0,0 +1,88 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templ_test
+
+import (
+	"testing"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapRoff(t *testing.T) {
+	page := `.SH NAME
+tool \- a tool with a rather long name line
+.SH DESCRIPTION
+One two three four five six . seven eight. Nine ten.
+.TP
+\fB\-\-flag\fP =<a long argument hint>
+usage of the flag
+.nf
+  keep this long literal line as it is
+.fi
+  indented lines are kept
+escaped\ space`
+
+	assert.Equal(t, `.SH NAME
+tool \- a tool with a rather long name line
+.SH DESCRIPTION
+One two three
+four five six
+\&. seven
+eight. Nine
+ten.
+.TP
+\fB\-\-flag\fP =<a long argument hint>
+usage of the
+flag
+.nf
+  keep this long literal line as it is
+.fi
+  indented lines are kept
+escaped\ space`, templ.WrapRoff(page, 13, false))
+
+	assert.Equal(t, `.SH DESCRIPTION
+One two three four five six . seven eight.
+Nine ten.`, templ.WrapRoff(".SH DESCRIPTION\nOne two three four five six . seven eight. Nine ten.", 0, true))
+}
+
+func TestWrapMarkdown(t *testing.T) {
+	page := "## tool sub\n\n" +
+		"A paragraph that is long. It has - dashes and # hashes.\n\n" +
+		"* --flag=<x> - a list item with a long description\n" +
+		"```sh\n" +
+		"tool sub --a very long example line that is kept\n" +
+		"```\n" +
+		"| a | table row that is not wrapped |"
+
+	assert.Equal(t, "## tool sub\n\n"+
+		"A paragraph that\n"+
+		"is long. It has -\n"+
+		"dashes and #\n"+
+		"hashes.\n\n"+
+		"* --flag=<x> - a\n"+
+		"  list item with\n"+
+		"  a long\n"+
+		"  description\n"+
+		"```sh\n"+
+		"tool sub --a very long example line that is kept\n"+
+		"```\n"+
+		"| a | table row that is not wrapped |", templ.WrapMarkdown(page, 17, false))
+
+	assert.Equal(t, "Long.\nIt is.\nOne more. e.g.\nthis.",
+		templ.WrapMarkdown("Long. It is. One more. e.g. this.", 14, true))
+
+	frontMatter := "---\ndescription: a value longer than the width\n---\n\n"
+	assert.Equal(t, frontMatter+"Wrapped\ntext.", templ.WrapMarkdown(frontMatter+"Wrapped text.", 10, false))
+}
@@ -0,0 +1,151 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrUnknownCommand is returned by FindCommand and the man command for a
+// path that does not name a command.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// AddManCommand adds a "man [command]..." subcommand to root that shows the
+// man page of root or of one of its subcommands at runtime, so that users get
+// the full documentation even when the pages are not installed.
+//
+// When the output is a terminal and man(1) is available, the troff page is
+// piped to "man -l -"; without man the markdown page is shown instead.  When
+// the output is not a terminal, the troff source is written, e.g. for
+// "tool man sub | man -l -".  opts may be nil, and is otherwise used like in
+// GenerateOnePage.  The added command is returned so it can be customized.
+func AddManCommand(root *cobra.Command, opts *Options) *cobra.Command {
+	if opts == nil {
+		opts = &Options{}
+	}
+	return addManCommand(root, func(cmd *cobra.Command, w io.Writer) error {
+		page := new(bytes.Buffer)
+		if err := GenerateOnePage(cmd, opts, "troff", page); err != nil {
+			return err
+		}
+		return showManPage(w, page.Bytes(), func() ([]byte, error) {
+			page.Reset()
+			err := GenerateOnePage(cmd, opts, "markdown", page)
+			return page.Bytes(), err
+		})
+	})
+}
+
+// addManCommand adds the man command to root, which calls show to write the
+// page of the command named by its arguments.
+func addManCommand(root *cobra.Command, show func(cmd *cobra.Command, w io.Writer) error) *cobra.Command {
+	manCmd := &cobra.Command{
+		Use:   "man [command]...",
+		Short: "Show the manual page of a command",
+		Long: `Show the manual page of the given command, or of ` + root.Name() + ` itself.
+
+The page is displayed with man(1) when writing to a terminal, and written
+as troff source otherwise.`,
+		Args: cobra.ArbitraryArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			c, err := FindCommand(root, args)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var names []string
+			for _, sub := range NewCobraModel(c).Subcommands() {
+				names = append(names, sub.Name())
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := FindCommand(root, args)
+			if err != nil {
+				return err
+			}
+			return show(c, cmd.OutOrStdout())
+		},
+	}
+	root.AddCommand(manCmd)
+	return manCmd
+}
+
+// FindCommand returns the command below root with the given path of command
+// names or aliases, which may or may not start with the name of root.  An
+// empty path names root.  ErrUnknownCommand is returned if there is no such
+// command.
+func FindCommand(root *cobra.Command, path []string) (*cobra.Command, error) {
+	if len(path) > 0 && path[0] == root.Name() {
+		path = path[1:]
+	}
+	cmd := root
+	for _, name := range path {
+		var next *cobra.Command
+		for _, c := range cmd.Commands() {
+			if c.Name() == name || c.HasAlias(name) {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCommand, strings.Join(path, " "))
+		}
+		cmd = next
+	}
+	return cmd, nil
+}
+
+// showManPage writes the troff page to w as described by AddManCommand.
+// fallback returns the page to write instead when man(1) is not available;
+// if it is nil, the troff page is written.
+func showManPage(w io.Writer, page []byte, fallback func() ([]byte, error)) error {
+	if !isTerminal(w) {
+		_, err := w.Write(page)
+		return err
+	}
+	manPath, err := exec.LookPath("man")
+	if err != nil {
+		if fallback != nil {
+			if page, err = fallback(); err != nil {
+				return err
+			}
+		}
+		_, err = w.Write(page)
+		return err
+	}
+
+	man := exec.Command(manPath, "-l", "-")
+	man.Stdin = bytes.NewReader(page)
+	man.Stdout = w
+	man.Stderr = os.Stderr
+	return man.Run()
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,56 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/carlwr/cobraman"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddManCommand(t *testing.T) {
+	root := mkCobraCmd("tool", false)
+	sub := mkCobraCmd("sub", true)
+	sub.Aliases = []string{"s"}
+	root.AddCommand(sub)
+	manCmd := cobraman.AddManCommand(root, &cobraman.Options{Section: "8"})
+	assert.Equal(t, "man", manCmd.Name())
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		root.SetOut(buf)
+		root.SetErr(new(bytes.Buffer))
+		root.SetArgs(append([]string{"man"}, args...))
+		err := root.Execute()
+		return buf.String(), err
+	}
+
+	out, err := run()
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\.TH "TOOL" "8"`, out)
+
+	out, err = run("s")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\.TH "TOOL\\-SUB" "8"`, out)
+
+	_, err = run("nope")
+	assert.ErrorIs(t, err, cobraman.ErrUnknownCommand)
+
+	names, _ := manCmd.ValidArgsFunction(manCmd, nil, "")
+	assert.Equal(t, []string{"completion", "man", "sub"}, names)
+	names, _ = manCmd.ValidArgsFunction(manCmd, []string{"sub"}, "")
+	assert.Empty(t, names)
+}
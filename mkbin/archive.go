@@ -0,0 +1,152 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// writeArchive writes files, which are located in dir, into a gzipped tar
+// archive at path.  The entries are sorted by name and all get the same
+// mtime, owner and permissions, so the archive is reproducible.
+func writeArchive(path string, dir string, files []string, mtime time.Time) (err error) {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(name))
+	}
+	sort.Strings(names)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // archives are world readable
+		return err
+	}
+	f, err := os.Create(path) //nolint:gosec // path is provided by the user
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	zw := gzip.NewWriter(f)
+	zw.ModTime = mtime
+	tw := tar.NewWriter(zw)
+
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			ModTime:  mtime,
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// gzipFiles replaces each of files by a gzip compressed copy with a .gz
+// suffix, and returns the paths of the compressed files.
+func gzipFiles(files []string) ([]string, error) {
+	gzipped := make([]string, 0, len(files))
+	for _, file := range files {
+		if err := gzipFile(file, file+".gz"); err != nil {
+			return nil, err
+		}
+		if err := os.Remove(file); err != nil {
+			return nil, err
+		}
+		gzipped = append(gzipped, file+".gz")
+	}
+	return gzipped, nil
+}
+
+// gzipFile writes a compressed copy of src to dst.  The gzip header holds no
+// name or mtime, so the output only depends on the content of src.
+func gzipFile(src string, dst string) (err error) {
+	content, err := os.ReadFile(src) //nolint:gosec // src was generated by us
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dst) //nolint:gosec // dst was generated by us
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	zw, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(content); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// checksumsFileName is the name of the file written by writeChecksums.
+const checksumsFileName = "SHA256SUMS"
+
+// writeChecksums writes the SHA-256 checksums of files, which are located in
+// dir, into a SHA256SUMS file in dir, in the format of sha256sum(1).  It
+// returns the path of the written file.
+func writeChecksums(dir string, files []string) (string, error) {
+	lines := make([]string, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file) //nolint:gosec // file was generated by us
+		if err != nil {
+			return "", err
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fmt.Sprintf("%x  %s\n", sha256.Sum256(content), filepath.ToSlash(name)))
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][sha256.Size*2:] < lines[j][sha256.Size*2:] })
+
+	path := filepath.Join(dir, checksumsFileName)
+	return path, os.WriteFile(path, []byte(strings.Join(lines, "")), 0o644) //nolint:gosec // checksums are public
+}
@@ -0,0 +1,177 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrUnknownPackager is returned when --packager names a packager that is
+// not supported.
+var ErrUnknownPackager = errors.New("unknown packager")
+
+func (dg *DocGenTool) newContentsCmd() *cobra.Command {
+	var (
+		prefix     string
+		layoutName string
+		packager   string
+	)
+
+	contentsCmd := &cobra.Command{
+		Use:   "contents",
+		Args:  cobra.NoArgs,
+		Short: "Generate man pages and completions and write their package contents",
+		Long: `Generate man pages and completion scripts into --output-dir, like install does,
+and write what a package needs to install them to stdout:
+
+  nfpm      the contents section of an nfpm configuration, mapping every file to
+            the path it is installed to below --prefix; it can also be used in
+            the nfpms of a goreleaser configuration
+  homebrew  the lines of the install method of a Homebrew formula, e.g.
+            man1.install "docs/foo.1"
+
+Generating the mapping along with the files keeps the packaging configuration
+in sync with what is actually generated.  See install for the layouts.`,
+		Example: `  docsgen contents --output-dir dist/docs --layout debian > dist/docs.yaml
+  docsgen contents --output-dir docs --packager homebrew`,
+		RunE: func(myCmd *cobra.Command, args []string) error {
+			layout, err := selectLayout(myCmd, layoutName, &prefix)
+			if err != nil {
+				return err
+			}
+			dir, err := dg.outputDir()
+			if err != nil {
+				return err
+			}
+			if packager == "homebrew" && !myCmd.Flags().Changed("layout") {
+				layout = installLayouts["homebrew"]
+			}
+			entries, links, err := dg.installEntries(dir, layout)
+			if err != nil {
+				return err
+			}
+			if packager == "homebrew" {
+				return writeHomebrewInstall(myCmd.OutOrStdout(), entries)
+			}
+			return writeNFPMContents(myCmd.OutOrStdout(), prefix, entries, links)
+		},
+		PreRunE: func(myCmd *cobra.Command, args []string) error {
+			if packager != "nfpm" && packager != "homebrew" {
+				return fmt.Errorf("%w: %q", ErrUnknownPackager, packager)
+			}
+			return nil
+		},
+	}
+	contentsCmd.Flags().StringVar(&prefix, "prefix", "/usr", "Installation prefix of the package")
+	contentsCmd.Flags().StringVar(&layoutName, "layout", "default", "Layout of the installed files: default, debian or homebrew")
+	contentsCmd.Flags().StringVar(&packager, "packager", "nfpm", "What to write the contents for: nfpm or homebrew")
+	contentsCmd.Flags().StringSliceVar(&dg.formats, "formats", nil, "Comma separated generators to include, e.g. troff (default all)")
+
+	return contentsCmd
+}
+
+// writeNFPMContents writes the contents section of an nfpm configuration
+// installing entries and links below prefix to w.
+func writeNFPMContents(w io.Writer, prefix string, entries []installEntry, links []installEntry) error {
+	dst := func(dest string) string {
+		return strconv.Quote(path.Join("/", filepath.ToSlash(prefix), filepath.ToSlash(dest)))
+	}
+
+	if _, err := fmt.Fprintln(w, "contents:"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		_, err := fmt.Fprintf(w, "  - src: %s\n    dst: %s\n    file_info:\n      mode: 0644\n",
+			strconv.Quote(filepath.ToSlash(entry.src)), dst(entry.dest))
+		if err != nil {
+			return err
+		}
+	}
+	for _, link := range links {
+		_, err := fmt.Fprintf(w, "  - src: %s\n    dst: %s\n    type: symlink\n", strconv.Quote(link.src), dst(link.dest))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// homebrewDirs are the methods of a Homebrew formula returning the
+// directories below its prefix that files are installed to, in the order
+// they are written by writeHomebrewInstall.
+var homebrewDirs = []struct{ method, dir string }{
+	{"bash_completion", filepath.Join("etc", "bash_completion.d")},
+	{"bash_completion", filepath.Join("share", "bash-completion", "completions")},
+	{"zsh_completion", filepath.Join("share", "zsh", "site-functions")},
+	{"fish_completion", filepath.Join("share", "fish", "vendor_completions.d")},
+}
+
+// writeHomebrewInstall writes the lines of the install method of a Homebrew
+// formula installing entries to w: man pages with one line per section, e.g.
+// man1.install "docs/foo.1", "docs/foo-sub.1", and completions, which are
+// renamed as needed, with one line each.
+func writeHomebrewInstall(w io.Writer, entries []installEntry) error {
+	quote := func(s string) string {
+		return strings.ReplaceAll(strconv.Quote(filepath.ToSlash(s)), "#{", `\#{`)
+	}
+
+	var (
+		manSections []string
+		manPages    = make(map[string][]string)
+		lines       []string
+	)
+	for _, entry := range entries {
+		dir, base := filepath.Split(entry.dest)
+		dir = filepath.Clean(dir)
+		if section, ok := strings.CutPrefix(dir, filepath.Join("share", "man", "man")); ok {
+			if manPages[section] == nil {
+				manSections = append(manSections, section)
+			}
+			manPages[section] = append(manPages[section], quote(entry.src))
+			continue
+		}
+		method := "doc"
+		for _, d := range homebrewDirs {
+			if d.dir == dir {
+				method = d.method
+				break
+			}
+		}
+		line := method + ".install " + quote(entry.src)
+		if base != filepath.Base(entry.src) {
+			line += " => " + quote(base)
+		}
+		lines = append(lines, line)
+	}
+
+	for _, section := range manSections {
+		if _, err := fmt.Fprintf(w, "man%s.install %s\n", section, strings.Join(manPages[section], ", ")); err != nil {
+			return err
+		}
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"fmt"
+
+	"github.com/carlwr/cobraman"
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/spf13/cobra"
+)
+
+func (dg *DocGenTool) newDocsCmd() *cobra.Command {
+	var format string
+
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Args:  cobra.NoArgs,
+		Short: "Generate the man pages of this documentation tool itself",
+		Long: `Generate the pages of this documentation tool itself, rather than of the
+application, into --output-dir.
+
+This documents the doc pipeline with the same machinery it provides, e.g. to
+ship the pages of the tool alongside those of the application.  --section and
+--date apply as for the application's pages.`,
+		Example: `  docsgen docs --output-dir man/man1
+  docsgen docs --format markdown --output-dir docs/docsgen`,
+		RunE: func(myCmd *cobra.Command, args []string) error {
+			return dg.docs(format)
+		},
+	}
+	docsCmd.Flags().StringVar(&format, "format", "troff", "Template to render the pages with, e.g. troff, mdoc or markdown")
+	_ = docsCmd.Flags().SetAnnotation("format", "man-arg-hints", []string{"template"})
+
+	return docsCmd
+}
+
+// docs generates the pages of the tool itself with the template format.
+func (dg *DocGenTool) docs(format string) error {
+	if _, ok := templ.GetTemplateInfo(format); !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	opts := &cobraman.Options{
+		Section:  dg.section,
+		Parallel: dg.parallel,
+		FormatDefault: func(flag cobraman.Flag) string {
+			if flag.Name == "parallel" {
+				// rather than the number of CPUs of the machine generating the docs
+				return "the number of CPUs"
+			}
+			return flag.Default
+		},
+	}
+	date, err := dg.parseDate()
+	if err != nil {
+		return err
+	}
+	opts.Date = date
+
+	dir, err := dg.outputDir()
+	if err != nil {
+		return err
+	}
+	files, err := cobraman.GenerateDocsFiles(dg.docCmd, opts, dir, format)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		dg.logger.Debug("wrote file", "path", file)
+	}
+	dg.logger.Info("generated", "generator", "docs", "dir", dir, "files", len(files))
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/carlwr/cobraman"
+)
+
+// writeMarkdownIndex writes the --markdown-index file into dir.  It links
+// every page in files, which were written by a markdown generator using the
+// file name separator sep, in command tree order.  Subcommands are indented
+// below their parents and every link is followed by the short description of
+// the command.  The path of the index is returned.
+func (dg *DocGenTool) writeMarkdownIndex(dir string, sep string, files []string) (string, error) {
+	written := make(map[string]bool, len(files))
+	for _, file := range files {
+		written[filepath.Base(file)] = true
+	}
+
+	root := cobraman.NewCobraModel(dg.appCmd)
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# %s\n\n", root.Name())
+	rootDepth := strings.Count(root.CommandPath(), " ")
+	for _, m := range allCommands(root, nil) {
+		base := strings.ReplaceAll(m.CommandPath(), " ", sep) + ".md"
+		if !written[base] {
+			continue
+		}
+		indent := strings.Repeat("  ", strings.Count(m.CommandPath(), " ")-rootDepth)
+		fmt.Fprintf(buf, "%s* [%s](%s)", indent, m.CommandPath(), base)
+		if m.Short() != "" {
+			fmt.Fprintf(buf, " - %s", m.Short())
+		}
+		buf.WriteString("\n")
+	}
+
+	indexFile := filepath.Join(dir, dg.markdownIndex)
+	return indexFile, os.WriteFile(indexFile, buf.Bytes(), 0o644) //nolint:gosec // docs are world readable
+}
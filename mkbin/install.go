@@ -0,0 +1,348 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/carlwr/cobraman"
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/spf13/cobra"
+)
+
+// ErrIndexFailed is returned by install --update-index when the command
+// updating the man page index fails.
+var ErrIndexFailed = errors.New("updating the man page index failed")
+
+// manIndexers are the commands updating the database searched by apropos(1)
+// and whatis(1), in order of preference, with their arguments before the man
+// directory: mandb of man-db, and makewhatis of mandoc and the BSDs.
+var manIndexers = [][]string{{"mandb", "--quiet"}, {"makewhatis"}}
+
+// ErrUnknownLayout is returned when --layout names a layout that does not
+// exist.
+var ErrUnknownLayout = errors.New("unknown layout")
+
+// installEntry maps a generated file to the path it is installed to,
+// relative to the installation prefix.
+type installEntry struct {
+	src  string
+	dest string
+}
+
+// installLayout is a convention for how install puts the files below the
+// prefix, selected with --layout.
+type installLayout struct {
+	// prefix replaces the default of --prefix if set
+	prefix string
+	// packaged prepares the files for a distribution package: man pages
+	// are compressed, the pages of command aliases are symlinks to the pages
+	// of the commands, and files and directories get the permissions 0644
+	// and 0755 regardless of the umask
+	packaged bool
+	// bashCompletionDir replaces share/bash-completion/completions if set
+	bashCompletionDir string
+}
+
+var installLayouts = map[string]installLayout{
+	"default": {},
+	// the data tree of a .deb, as checked by lintian
+	"debian": {prefix: "/usr", packaged: true},
+	// the prefix of a Homebrew formula, see the Formula Cookbook
+	"homebrew": {bashCompletionDir: filepath.Join("etc", "bash_completion.d")},
+}
+
+func (dg *DocGenTool) newInstallCmd() *cobra.Command {
+	var (
+		prefix      string
+		layoutName  string
+		updateIndex bool
+	)
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Args:  cobra.NoArgs,
+		Short: "Generate and install man pages and completions below --prefix",
+		Long: `Generate man pages and completion scripts and install them below the
+installation prefix: man pages into share/man/man<section>, and bash, zsh and
+fish completions into share/bash-completion/completions, share/zsh/site-functions
+and share/fish/vendor_completions.d.
+
+If the DESTDIR environment variable is set, it is prepended to the prefix, as
+is customary when staging an installation for packaging.
+
+--layout debian produces the data tree of a .deb package that passes lintian's
+man page checks: the prefix defaults to /usr, man pages are compressed with
+gzip -9n, the pages of command aliases are symlinks to the pages of the
+commands, and files and directories are made world readable regardless of the
+umask.  --layout homebrew puts bash completions into etc/bash_completion.d, as
+in the prefix of a Homebrew formula.
+
+With --update-index, the index of the man pages below the prefix is updated
+afterwards with mandb or, if that is missing, makewhatis, so the new pages are
+found by apropos and whatis right away.  The index is not updated if DESTDIR
+is set, as the pages are then staged rather than installed.`,
+		RunE: func(myCmd *cobra.Command, args []string) error {
+			layout, err := selectLayout(myCmd, layoutName, &prefix)
+			if err != nil {
+				return err
+			}
+			destDir := os.Getenv("DESTDIR")
+			root := filepath.Join(destDir, prefix)
+			if err := dg.install(root, layout); err != nil || !updateIndex {
+				return err
+			}
+			if destDir != "" {
+				// the index would be written into the staging tree, or
+				// list pages that are not installed on this host
+				dg.logger.Warn("man page index not updated: DESTDIR is set", "destdir", destDir)
+				return nil
+			}
+			return dg.updateManIndex(filepath.Join(root, "share", "man"))
+		},
+	}
+	installCmd.Flags().StringVar(&prefix, "prefix", "/usr/local", "Installation prefix")
+	installCmd.Flags().StringVar(&layoutName, "layout", "default", "Layout of the installed files: default, debian or homebrew")
+	installCmd.Flags().BoolVar(&updateIndex, "update-index", false, "Update the man page index (mandb or makewhatis) after installing")
+	installCmd.Flags().StringSliceVar(&dg.formats, "formats", nil, "Comma separated generators to install, e.g. troff (default all)")
+
+	return installCmd
+}
+
+// selectLayout returns the install layout named by the --layout flag of cmd,
+// and sets prefix to the prefix of the layout unless --prefix was given.
+func selectLayout(cmd *cobra.Command, name string, prefix *string) (installLayout, error) {
+	layout, ok := installLayouts[name]
+	if !ok {
+		return layout, fmt.Errorf("%w: %q", ErrUnknownLayout, name)
+	}
+	if layout.prefix != "" && !cmd.Flags().Changed("prefix") {
+		*prefix = layout.prefix
+	}
+	return layout, nil
+}
+
+// install generates the files of the selected man page and completion
+// generators and copies them to their conventional locations below root.
+func (dg *DocGenTool) install(root string, layout installLayout) error {
+	dir, err := os.MkdirTemp("", "docsgen-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	entries, links, err := dg.installEntries(dir, layout)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		dest := filepath.Join(root, entry.dest)
+		if err := copyFile(entry.src, dest); err != nil {
+			return err
+		}
+		if layout.packaged {
+			if err := chmodInstalled(root, entry.dest); err != nil {
+				return err
+			}
+		}
+		dg.logger.Debug("installed file", "path", dest)
+	}
+	for _, link := range links {
+		dest := filepath.Join(root, link.dest)
+		if err := os.Remove(dest); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Symlink(link.src, dest); err != nil {
+			return err
+		}
+		dg.logger.Debug("installed link", "path", dest, "target", link.src)
+	}
+	dg.logger.Info("installed", "root", root, "files", len(entries), "links", len(links))
+
+	return nil
+}
+
+// installEntries generates the files of the selected man page and completion
+// generators into dir and returns where they are installed to, as well as
+// the symlinks of the layout.
+func (dg *DocGenTool) installEntries(dir string, layout installLayout) (entries []installEntry, links []installEntry, err error) {
+	gzip := dg.gzip || layout.packaged
+	gens, err := dg.selectedGenerators()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var errs []error
+	for _, gen := range gens {
+		if gen.kind == kindDocs || gen.kind == kindMarkdown {
+			continue
+		}
+		files, err := dg.runGenerator(gen, dir, gzip)
+		if err != nil {
+			errs = append(errs, splitErrors(err)...)
+			continue
+		}
+		for _, file := range files {
+			entries = append(entries, installEntry{src: file, dest: dg.installPath(gen.kind, file, layout)})
+		}
+		if layout.packaged && gen.kind == kindManPages {
+			links = append(links, dg.aliasLinks(gen.name, entries[len(entries)-len(files):])...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, generationFailed(errs)
+	}
+	return entries, links, nil
+}
+
+// aliasLinks returns the symlinks making the aliases of commands, e.g.
+// "foo rm" for "foo remove", name the man pages of the commands installed as
+// entries by the doc generator for the template templateName.  The src of a
+// link is its target, relative to the link.  Only the pages of the commands
+// themselves are linked, not those of their children.
+func (dg *DocGenTool) aliasLinks(templateName string, entries []installEntry) []installEntry {
+	sep, _, t, _ := templ.GetTemplate(templateName)
+	if t == nil {
+		return nil
+	}
+	cmds := make(map[string]*cobra.Command)
+	for _, m := range allCommands(cobraman.NewCobraModel(dg.appCmd), nil) {
+		cmds[strings.ReplaceAll(m.CommandPath(), " ", sep)] = m.(*cobraman.CobraModel).Command() //nolint:forcetypeassert // built from a cobra.Command
+	}
+
+	var links []installEntry
+	for _, entry := range entries {
+		base := filepath.Base(entry.dest)
+		stem := strings.TrimSuffix(base, ".gz")
+		dot := strings.LastIndex(stem, ".")
+		cmd := cmds[stem[:max(dot, 0)]]
+		if dot < 0 || cmd == nil || !cmd.HasParent() {
+			continue
+		}
+		parent := strings.ReplaceAll(cmd.Parent().CommandPath(), " ", sep)
+		for _, alias := range cmd.Aliases {
+			if cmds[parent+sep+alias] != nil {
+				continue // a command of that name has a page of its own
+			}
+			links = append(links, installEntry{
+				src:  base,
+				dest: filepath.Join(filepath.Dir(entry.dest), parent+sep+alias+base[dot:]),
+			})
+		}
+	}
+	return links
+}
+
+// chmodInstalled makes the file dest, relative to root, and the directories
+// below root leading to it world readable.
+func chmodInstalled(root string, dest string) error {
+	if err := os.Chmod(filepath.Join(root, dest), 0o644); err != nil { //nolint:gosec // installed docs are world readable
+		return err
+	}
+	for dir := filepath.Dir(dest); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if err := os.Chmod(filepath.Join(root, dir), 0o755); err != nil { //nolint:gosec // installed docs are world readable
+			return err
+		}
+	}
+	return nil
+}
+
+// updateManIndex runs the first of manIndexers that is installed for the man
+// directory manDir.  It is not an error if none is installed.
+func (dg *DocGenTool) updateManIndex(manDir string) error {
+	if _, err := os.Stat(manDir); err != nil {
+		return nil //nolint:nilerr // no man pages were installed
+	}
+	for _, indexer := range manIndexers {
+		path, err := exec.LookPath(indexer[0])
+		if err != nil {
+			continue
+		}
+		out, err := exec.Command(path, append(indexer[1:], manDir)...).CombinedOutput() //nolint:gosec // the indexers are fixed
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w: %s", ErrIndexFailed, indexer[0], err, strings.TrimSpace(string(out)))
+		}
+		dg.logger.Info("updated man page index", "indexer", indexer[0], "dir", manDir)
+		return nil
+	}
+	dg.logger.Warn("man page index not updated: neither mandb nor makewhatis is installed")
+	return nil
+}
+
+// installPath returns where a file generated by a generator of the given kind
+// is installed with layout, relative to the prefix.
+func (dg *DocGenTool) installPath(kind generatorKind, file string, layout installLayout) string {
+	base := filepath.Base(file)
+	switch kind {
+	case kindManPages:
+		return filepath.Join("share", "man", "man"+manDirSection(base), base)
+	case kindBashCompletion:
+		if layout.bashCompletionDir != "" {
+			return filepath.Join(layout.bashCompletionDir, dg.appCmd.Name())
+		}
+		return filepath.Join("share", "bash-completion", "completions", dg.appCmd.Name())
+	case kindZshCompletion:
+		return filepath.Join("share", "zsh", "site-functions", "_"+dg.appCmd.Name())
+	case kindFishCompletion:
+		return filepath.Join("share", "fish", "vendor_completions.d", dg.appCmd.Name()+".fish")
+	default:
+		return filepath.Join("share", "doc", dg.appCmd.Name(), base)
+	}
+}
+
+// manDirSection returns the section part of the man directory for a man page
+// file name such as "foo.8.gz" or "foo.3pm": the leading digits of the
+// section, as in man8 and man3.
+func manDirSection(base string) string {
+	base = strings.TrimSuffix(base, ".gz")
+	section := base[strings.LastIndex(base, ".")+1:]
+	end := 0
+	for end < len(section) && section[end] >= '0' && section[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return section
+	}
+	return section[:end]
+}
+
+func copyFile(src string, dest string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil { //nolint:gosec // installed docs are world readable
+		return err
+	}
+	in, err := os.Open(src) //nolint:gosec // src was generated by us
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644) //nolint:gosec // installed docs are world readable
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	_, err = io.Copy(out, in)
+	return err
+}
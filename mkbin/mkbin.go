@@ -15,20 +15,83 @@
 package mkbin
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/carlwr/cobraman"
 	"github.com/carlwr/cobraman/internal/templ"
 	"github.com/spf13/cobra"
 )
 
+// ErrUnknownFormat is returned when --formats names a generator that has not
+// been added to the tool.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// ErrGenerationFailed is returned when any page or generator failed.  The
+// remaining pages and generators are still run, so all failures are reported.
+var ErrGenerationFailed = errors.New("generation failed")
+
 // DocGenTool is an opaque type created by CreateDocGenCmdLineTool.
 type DocGenTool struct {
 	installDirectory string
+	formats          []string
+	section          string
+	date             string
+	parallel         int
+	archive          string
+	gzip             bool
+	checksums        bool
+	markdownIndex    string
+	templateFiles    []string
+	templateSpecs    map[string]string
+	only             []string
+	exclude          []string
+	verbose          bool
+	quiet            bool
+	logJSON          bool
+	logger           *slog.Logger
 	docCmd           *cobra.Command
 	appCmd           *cobra.Command
+	generators       []generator
+	docGenerators    []docGenerator
+}
+
+// docGenerator records the arguments of an AddDocGenerator call.
+type docGenerator struct {
+	opts         *cobraman.Options
+	templateName string
 }
 
+// generator is one of the generators added to a DocGenTool.  Its name is
+// used in the generate-<name> subcommand and by the --formats flag.  run
+// returns the paths of the files it wrote.
+type generator struct {
+	name string
+	run  func(dir string) ([]string, error)
+	kind generatorKind
+}
+
+// generatorKind tells what a generator produces, which decides whether its
+// files are compressed by --gzip and where the install subcommand puts them.
+type generatorKind int
+
+const (
+	kindDocs generatorKind = iota
+	kindManPages
+	kindBashCompletion
+	kindMarkdown
+	kindZshCompletion
+	kindFishCompletion
+)
+
 // CreateDocGenCmdLineTool creates a command line parser that can be used
 // in a utility tool to generate documentation for a companion application.
 func CreateDocGenCmdLineTool(appCmd *cobra.Command) *DocGenTool {
@@ -40,55 +103,433 @@ func CreateDocGenCmdLineTool(appCmd *cobra.Command) *DocGenTool {
 		Use:   "docsgen",
 		Args:  cobra.NoArgs,
 		Short: "Generate documentation, etc.",
+		Long: `Generate the documentation of the application: man pages, markdown and
+other pages rendered from templates, and shell completion scripts.
+
+Every generator of the tool has a generate-<name> subcommand, and generate runs
+all of them.  install, contents and serve build on the same generators, and
+docs documents this tool itself.`,
+		Example: `  docsgen generate --output-dir dist/docs
+  docsgen page sub --format troff | man -l -
+  docsgen install --prefix /usr/local`,
+		PersistentPreRunE: func(myCmd *cobra.Command, args []string) error {
+			dg.logger = dg.newLogger(myCmd.ErrOrStderr())
+			for _, pattern := range append(dg.only, dg.exclude...) {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("invalid glob %q: %w", pattern, err)
+				}
+			}
+			return dg.addTemplateFiles()
+		},
+	}
+	flags := dg.docCmd.PersistentFlags()
+	flags.StringVarP(&dg.installDirectory, "output-dir", "o", ".", "Directory to write generated files to (created if missing)")
+	flags.StringVar(&dg.installDirectory, "directory", ".", "Directory to install generated files")
+	_ = flags.MarkDeprecated("directory", "use --output-dir instead")
+	flags.StringVar(&dg.section, "section", "", "Override the man section of the generated pages (e.g. 8)")
+	flags.StringVar(&dg.date, "date", "", "Override the date of the generated pages, as YYYY-MM-DD")
+	flags.IntVar(&dg.parallel, "parallel", runtime.GOMAXPROCS(0), "Number of pages to generate concurrently")
+	flags.StringVar(&dg.archive, "archive", "", "Write all generated files into this .tar.gz archive instead of --output-dir")
+	flags.BoolVar(&dg.gzip, "gzip", false, "Compress generated man pages, e.g. foo.1 becomes foo.1.gz")
+	flags.BoolVar(&dg.checksums, "checksums", false, "Also write a SHA256SUMS file listing the generated files")
+	flags.StringVar(&dg.markdownIndex, "markdown-index", "",
+		"Also write an index linking all markdown pages to this file, e.g. index.md or README.md")
+	flags.StringArrayVar(&dg.templateFiles, "template", nil,
+		"Add a generator for a template file, as name=path.tmpl[:ext[:sep]] (repeatable)")
+	flags.StringArrayVar(&dg.only, "only", nil, "Only document commands whose path matches this glob, e.g. 'tool sub*' (repeatable)")
+	flags.StringArrayVar(&dg.exclude, "exclude", nil, "Don't document commands whose path matches this glob (repeatable)")
+	flags.BoolVarP(&dg.verbose, "verbose", "v", false, "Log every file written")
+	flags.BoolVarP(&dg.quiet, "quiet", "q", false, "Only log warnings and errors")
+	flags.BoolVar(&dg.logJSON, "log-json", false, "Log in JSON format, one object per line")
+	dg.docCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	for name, hint := range map[string]string{
+		"output-dir":     "dir",
+		"section":        "section",
+		"date":           "YYYY-MM-DD",
+		"parallel":       "n",
+		"archive":        "file",
+		"markdown-index": "file",
+		"template":       "name=path",
+		"only":           "glob",
+		"exclude":        "glob",
+	} {
+		_ = flags.SetAnnotation(name, "man-arg-hints", []string{hint})
+	}
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Args:  cobra.NoArgs,
+		Short: "Run all generators, or those selected with --formats",
+		RunE: func(myCmd *cobra.Command, args []string) error {
+			gens, err := dg.selectedGenerators()
+			if err != nil {
+				return err
+			}
+			return dg.runGenerators(gens)
+		},
 	}
-	dg.docCmd.PersistentFlags().StringVar(&dg.installDirectory, "directory", ".", "Directory to install generated files")
+	generateCmd.Flags().StringSliceVar(&dg.formats, "formats", nil, "Comma separated generators to run, e.g. troff,markdown (default all)")
+	_ = generateCmd.Flags().SetAnnotation("formats", "man-arg-hints", []string{"names"})
+	dg.docCmd.AddCommand(generateCmd, dg.newInstallCmd(), dg.newValidateCmd(), dg.newPageCmd(), dg.newServeCmd(),
+		dg.newContentsCmd(), dg.newDocsCmd())
 
 	return dg
 }
 
-// AddBashCompletionGenerator will create a subcommand for the utility tool
-// that will generate a Bash Completion file for the companion app.  It will
-// support a --directory flag and use the fileName passed into this function.
-func (dg *DocGenTool) AddBashCompletionGenerator(fileName string) *DocGenTool {
-	completeCmd := &cobra.Command{
-		Use:   "generate-auto-complete",
+// addGenerator registers gen and creates its generate-<name> subcommand.
+func (dg *DocGenTool) addGenerator(gen generator, short string) {
+	dg.generators = append(dg.generators, gen)
+	dg.docCmd.AddCommand(&cobra.Command{
+		Use:   "generate-" + gen.name,
 		Args:  cobra.NoArgs,
-		Short: "Generate bash auto complete script",
+		Short: short,
 		RunE: func(myCmd *cobra.Command, args []string) error {
-			path := filepath.Join(dg.installDirectory, fileName)
-			return dg.appCmd.GenBashCompletionFile(path)
+			return dg.runGenerators([]generator{gen})
 		},
+	})
+}
+
+// newLogger creates the logger for the progress output, as configured by the
+// --verbose, --quiet and --log-json flags.
+func (dg *DocGenTool) newLogger(w io.Writer) *slog.Logger {
+	level := slog.LevelInfo
+	switch {
+	case dg.verbose:
+		level = slog.LevelDebug
+	case dg.quiet:
+		level = slog.LevelWarn
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if dg.logJSON {
+		return slog.New(slog.NewJSONHandler(w, handlerOpts))
 	}
+	return slog.New(slog.NewTextHandler(w, handlerOpts))
+}
 
-	dg.docCmd.AddCommand(completeCmd)
+// runGenerators runs gens, writing their files to the output directory, or,
+// if --archive is given, into the archive.
+func (dg *DocGenTool) runGenerators(gens []generator) (err error) {
+	var dir string
+	if dg.archive == "" {
+		if dir, err = dg.outputDir(); err != nil {
+			return err
+		}
+	} else {
+		if dir, err = os.MkdirTemp("", "docsgen-"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+	}
+
+	var (
+		files []string
+		errs  []error
+	)
+	for _, gen := range gens {
+		genFiles, err := dg.runGenerator(gen, dir, dg.gzip)
+		if err != nil {
+			errs = append(errs, splitErrors(err)...)
+			continue
+		}
+		files = append(files, genFiles...)
+	}
+	if len(errs) > 0 {
+		return generationFailed(errs)
+	}
+
+	if dg.checksums {
+		sumsFile, err := writeChecksums(dir, files)
+		if err != nil {
+			return err
+		}
+		files = append(files, sumsFile)
+		dg.logger.Debug("wrote file", "path", sumsFile)
+	}
+
+	if dg.archive == "" {
+		return nil
+	}
+	mtime := time.Unix(0, 0)
+	if date, err := dg.parseDate(); err != nil {
+		return err
+	} else if date != nil {
+		mtime = *date
+	}
+	if err := writeArchive(dg.archive, dir, files, mtime); err != nil {
+		return err
+	}
+	dg.logger.Info("wrote archive", "path", dg.archive, "files", len(files))
+	return nil
+}
+
+// runGenerator runs gen, writing its files to dir, and returns the files.
+// gzip tells if the man pages it generates are compressed, see --gzip.
+func (dg *DocGenTool) runGenerator(gen generator, dir string, gzip bool) ([]string, error) {
+	start := time.Now()
+	files, err := gen.run(dir)
+	if err == nil && gzip && gen.kind == kindManPages {
+		files, err = gzipFiles(files)
+	}
+	if err != nil {
+		errs := splitErrors(err)
+		for i, err := range errs {
+			dg.logger.Error("failed", "generator", gen.name, "error", err)
+			errs[i] = fmt.Errorf("%s: %w", gen.name, err)
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	var total int64
+	for _, file := range files {
+		var size int64
+		if info, err := os.Stat(file); err == nil {
+			size = info.Size()
+		}
+		total += size
+		dg.logger.Debug("wrote file", "generator", gen.name, "path", file, "bytes", size)
+	}
+	dg.logger.Info("generated", "generator", gen.name, "dir", dir,
+		"files", len(files), "bytes", total, "duration", time.Since(start))
+
+	return files, nil
+}
+
+// generationFailed returns the ErrGenerationFailed summary of errs.
+func generationFailed(errs []error) error {
+	return fmt.Errorf("%w: %d errors:\n%w", ErrGenerationFailed, len(errs), errors.Join(errs...))
+}
+
+// splitErrors returns the errors joined in err, such as the PageErrors of a
+// doc generator, or just err if it does not join several errors.
+func splitErrors(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // only the top level is split
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+func (dg *DocGenTool) hasGenerator(name string) bool {
+	for _, gen := range dg.generators {
+		if gen.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedGenerators returns the generators named by --formats, in the order
+// they were added to the tool, or all generators if the flag was not given.
+func (dg *DocGenTool) selectedGenerators() ([]generator, error) {
+	if len(dg.formats) == 0 {
+		return dg.generators, nil
+	}
+	wanted := make(map[string]bool, len(dg.formats))
+	for _, name := range dg.formats {
+		name = strings.TrimSpace(name)
+		if !dg.hasGenerator(name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+		}
+		wanted[name] = true
+	}
+	var gens []generator
+	for _, gen := range dg.generators {
+		if wanted[gen.name] {
+			gens = append(gens, gen)
+		}
+	}
+	return gens, nil
+}
+
+// outputDir returns the directory to write generated files to, creating it
+// if it does not exist.
+func (dg *DocGenTool) outputDir() (string, error) {
+	if err := os.MkdirAll(dg.installDirectory, 0o755); err != nil { //nolint:gosec // man pages are world readable
+		return "", err
+	}
+	return dg.installDirectory, nil
+}
+
+// AddBashCompletionGenerator will create a subcommand for the utility tool
+// that will generate a Bash Completion file for the companion app.  It will
+// support a --output-dir flag and use the fileName passed into this function.
+func (dg *DocGenTool) AddBashCompletionGenerator(fileName string) *DocGenTool {
+	dg.addGenerator(generator{
+		name: "auto-complete",
+		run: func(dir string) ([]string, error) {
+			path := filepath.Join(dir, fileName)
+			return []string{path}, dg.appCmd.GenBashCompletionFile(path)
+		},
+		kind: kindBashCompletion,
+	}, "Generate bash auto complete script")
+
+	return dg
+}
+
+// AddZshCompletionGenerator is like AddBashCompletionGenerator, for a zsh
+// completion file.
+func (dg *DocGenTool) AddZshCompletionGenerator(fileName string) *DocGenTool {
+	dg.addGenerator(generator{
+		name: "zsh-complete",
+		run: func(dir string) ([]string, error) {
+			path := filepath.Join(dir, fileName)
+			return []string{path}, dg.appCmd.GenZshCompletionFile(path)
+		},
+		kind: kindZshCompletion,
+	}, "Generate zsh completion script")
+
+	return dg
+}
+
+// AddFishCompletionGenerator is like AddBashCompletionGenerator, for a fish
+// completion file.
+func (dg *DocGenTool) AddFishCompletionGenerator(fileName string) *DocGenTool {
+	dg.addGenerator(generator{
+		name: "fish-complete",
+		run: func(dir string) ([]string, error) {
+			path := filepath.Join(dir, fileName)
+			return []string{path}, dg.appCmd.GenFishCompletionFile(path, true)
+		},
+		kind: kindFishCompletion,
+	}, "Generate fish completion script")
+
+	return dg
+}
+
+// AddCustomGenerator will create a subcommand for the utility tool that
+// will run genFunc to produce arbitrary files, e.g. a JSON description of the
+// command line, for the companion app.  The subcommand will be named
+// generate-<name>, and the generator is also run by the generate subcommand
+// when name is selected by its --formats flag.  genFunc is passed the root
+// command of the app and the directory to write its files to, and returns the
+// paths of the files it wrote, e.g. filepath.Join(dir, "cli.json").
+func (dg *DocGenTool) AddCustomGenerator(name string, genFunc func(root *cobra.Command, dir string) ([]string, error)) *DocGenTool {
+	dg.addGenerator(generator{
+		name: name,
+		run: func(dir string) ([]string, error) {
+			return genFunc(dg.appCmd, dir)
+		},
+		kind: kindDocs,
+	}, "Generate "+name)
 
 	return dg
 }
 
 // AddDocGenerator will create a subcommand for the utility tool that will
 // generate documentation with the passed in Options and templateName.
-// It supports a --directory flag for where to place the generated files.  The
+// It supports a --output-dir flag for where to place the generated files.  The
 // subcommand will be named generate-<templateName> where templateName is the
-// same as the template used to generate the documentation.
+// same as the template used to generate the documentation.  The generator is
+// also run by the generate subcommand when templateName is selected by its
+// --formats flag.  As --parallel defaults to the number of CPUs, the hooks of
+// opts must be safe to call concurrently, see Options.Parallel.
 func (dg *DocGenTool) AddDocGenerator(opts *cobraman.Options, templateName string) *DocGenTool {
+	return dg.AddDocGeneratorTo(opts, templateName, "")
+}
+
+// AddDocGeneratorTo is like AddDocGenerator, but the generated files are
+// placed in subDir, relative to the directory given by --output-dir or to the
+// root of the --archive.  The subdirectory is created if missing.
+func (dg *DocGenTool) AddDocGeneratorTo(opts *cobraman.Options, templateName string, subDir string) *DocGenTool {
 	// should panic already in this function if  attempting to add a non-existing template:
-	_, _, t := templ.GetTemplate(templateName)
-	if t == nil {
+	info, ok := templ.GetTemplateInfo(templateName)
+	if !ok {
 		panic("template could not be found: " + templateName)
 	}
 
-	genCmd := &cobra.Command{
-		Use:   "generate-" + templateName,
-		Args:  cobra.NoArgs,
-		Short: "Generate docs with the " + templateName + " template",
-		RunE: func(myCmd *cobra.Command, args []string) error {
-			return cobraman.GenerateDocs(dg.appCmd, opts, dg.installDirectory, templateName)
+	dg.addGenerator(dg.newDocGenerator(opts, templateName, info.Extension, subDir), "Generate docs with the "+templateName+" template")
+
+	return dg
+}
+
+func (dg *DocGenTool) newDocGenerator(opts *cobraman.Options, templateName string, ext string, subDir string) generator {
+	dg.docGenerators = append(dg.docGenerators, docGenerator{opts: opts, templateName: templateName})
+	return generator{
+		name: templateName,
+		run: func(dir string) ([]string, error) {
+			o, err := dg.withOverrides(opts)
+			if err != nil {
+				return nil, err
+			}
+			if subDir != "" {
+				dir = filepath.Join(dir, subDir)
+				if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // docs are world readable
+					return nil, err
+				}
+			}
+			files, err := cobraman.GenerateDocsFiles(dg.appCmd, o, dir, templateName)
+			if err != nil || dg.markdownIndex == "" || genKindFor(ext) != kindMarkdown {
+				return files, err
+			}
+			sep, _, _, _ := templ.GetTemplate(templateName)
+			indexFile, err := dg.writeMarkdownIndex(dir, sep, files)
+			return append(files, indexFile), err
 		},
+		kind: genKindFor(ext),
 	}
+}
 
-	dg.docCmd.AddCommand(genCmd)
+// genKindFor returns the kind of a generator using a template with the file
+// extension ext.  Templates that name their files after the man section
+// generate man pages.
+func genKindFor(ext string) generatorKind {
+	switch ext {
+	case "use_section":
+		return kindManPages
+	case "md":
+		return kindMarkdown
+	}
+	return kindDocs
+}
 
-	return dg
+// withOverrides returns a copy of opts with the values of the --section,
+// --date, --parallel, --only and --exclude flags applied.  Pages are
+// generated with ContinueOnError, so one failing page does not hide others.
+func (dg *DocGenTool) withOverrides(opts *cobraman.Options) (*cobraman.Options, error) {
+	o := *opts
+	o.Parallel = dg.parallel
+	o.ContinueOnError = true
+	if len(dg.only) > 0 || len(dg.exclude) > 0 {
+		o.Filter = func(cmdPath string) bool {
+			if opts.Filter != nil && !opts.Filter(cmdPath) {
+				return false
+			}
+			return (len(dg.only) == 0 || matchesAny(dg.only, cmdPath)) && !matchesAny(dg.exclude, cmdPath)
+		}
+	}
+	if dg.section != "" {
+		o.Section = dg.section
+	}
+	date, err := dg.parseDate()
+	if err != nil {
+		return nil, err
+	}
+	if date != nil {
+		o.Date = date
+	}
+	return &o, nil
+}
+
+// matchesAny reports whether cmdPath matches any of the glob patterns, which
+// have been checked to be valid.
+func matchesAny(patterns []string, cmdPath string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, cmdPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDate returns the value of the --date flag, or nil if it was not given.
+func (dg *DocGenTool) parseDate() (*time.Time, error) {
+	if dg.date == "" {
+		return nil, nil //nolint:nilnil // no date is not an error
+	}
+	date, err := time.Parse(time.DateOnly, dg.date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --date: %w", err)
+	}
+	return &date, nil
 }
 
 // Execute will parse args and execute the command line.
@@ -14,13 +14,28 @@
 package mkbin
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/carlwr/cobraman"
+	"github.com/carlwr/cobraman/internal/templ"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateDocGenCmdLineTool(t *testing.T) {
@@ -93,3 +108,691 @@ func TestExecute(t *testing.T) {
 	assert.NoError(t, dg.Execute())
 	checkForFile(t, "foo.txt")
 }
+
+func TestOutputDir(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	appCmd.AddCommand(&cobra.Command{Use: "child", Run: func(cmd *cobra.Command, args []string) {}})
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.AddBashCompletionGenerator("foo.bash")
+
+	outDir := filepath.Join(t.TempDir(), "not", "yet", "there")
+
+	dg.docCmd.SetArgs([]string{"generate-troff", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+	assert.FileExists(t, filepath.Join(outDir, "foo.1"))
+	assert.FileExists(t, filepath.Join(outDir, "foo-child.1"))
+
+	dg.docCmd.SetArgs([]string{"generate-auto-complete", "--output-dir", outDir})
+	assert.NoError(t, dg.Execute())
+	assert.FileExists(t, filepath.Join(outDir, "foo.bash"))
+}
+
+func TestFormats(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.AddDocGenerator(&cobraman.Options{}, "markdown")
+	dg.AddBashCompletionGenerator("foo.bash")
+
+	t.Run("subset", func(t *testing.T) {
+		outDir := t.TempDir()
+		dg.docCmd.SetArgs([]string{"generate", "--formats", "troff,auto-complete", "-o", outDir})
+		assert.NoError(t, dg.Execute())
+		assert.FileExists(t, filepath.Join(outDir, "foo.1"))
+		assert.FileExists(t, filepath.Join(outDir, "foo.bash"))
+		assert.NoFileExists(t, filepath.Join(outDir, "foo.md"))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		dg.docCmd.SetArgs([]string{"generate", "--formats", "html", "-o", t.TempDir()})
+		assert.ErrorIs(t, dg.Execute(), ErrUnknownFormat)
+	})
+}
+
+func TestSectionAndDate(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	opts := &cobraman.Options{Section: "1"}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(opts, "troff")
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate-troff", "--section", "8", "--date", "2021-03-04", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+
+	content, err := os.ReadFile(filepath.Join(outDir, "foo.8"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `\.TH "FOO" "8" "Mar 2021"`, string(content))
+	assert.Equal(t, "1", opts.Section, "options passed to AddDocGenerator must not be modified")
+
+	dg.docCmd.SetArgs([]string{"generate-troff", "--date", "yesterday", "-o", outDir})
+	assert.ErrorContains(t, dg.Execute(), "invalid --date")
+}
+
+func TestLogging(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	appCmd.AddCommand(&cobra.Command{Use: "child", Run: func(cmd *cobra.Command, args []string) {}})
+
+	run := func(t *testing.T, args ...string) string {
+		dg := CreateDocGenCmdLineTool(appCmd)
+		dg.AddDocGenerator(&cobraman.Options{}, "markdown")
+		buf := new(bytes.Buffer)
+		dg.docCmd.SetErr(buf)
+		dg.docCmd.SetArgs(append([]string{"generate-markdown", "-o", t.TempDir()}, args...))
+		assert.NoError(t, dg.Execute())
+		return buf.String()
+	}
+
+	t.Run("default", func(t *testing.T) {
+		out := run(t)
+		assert.Regexp(t, `msg=generated generator=markdown .* files=2`, out)
+		assert.NotContains(t, out, "wrote file")
+	})
+
+	t.Run("quiet", func(t *testing.T) {
+		assert.Empty(t, run(t, "-q"))
+	})
+
+	t.Run("verbose-json", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(run(t, "-v", "--log-json")), "\n")
+		assert.Len(t, lines, 3)
+		for _, line := range lines {
+			var entry map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		}
+		assert.Contains(t, lines[0], `"path":`)
+		assert.Contains(t, lines[2], `"files":2`)
+	})
+}
+
+func TestParallel(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	for _, name := range []string{"a", "b", "c"} {
+		appCmd.AddCommand(&cobra.Command{Use: name, Run: func(cmd *cobra.Command, args []string) {}})
+	}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate-troff", "--parallel", "3", "-q", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+	for _, name := range []string{"foo.1", "foo-a.1", "foo-b.1", "foo-c.1"} {
+		assert.FileExists(t, filepath.Join(outDir, name))
+	}
+
+	// by default, pages are generated by as many goroutines as CPUs
+	templ.RegisterTemplate("parallel", "_", "txt", `{{ .CommandPath | backslashify }}`)
+	dg = CreateDocGenCmdLineTool(appCmd)
+	assert.Equal(t, runtime.GOMAXPROCS(0), dg.parallel)
+	dg.AddDocGenerator(&cobraman.Options{}, "parallel")
+	outDir = t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate-parallel", "-q", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+	content, err := os.ReadFile(filepath.Join(outDir, "foo_a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo a", string(content))
+}
+
+func TestArchive(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	appCmd.AddCommand(&cobra.Command{Use: "child", Run: func(cmd *cobra.Command, args []string) {}})
+
+	generate := func(archive string) []byte {
+		dg := CreateDocGenCmdLineTool(appCmd)
+		dg.AddDocGenerator(&cobraman.Options{}, "troff")
+		dg.AddDocGenerator(&cobraman.Options{}, "markdown")
+		dg.docCmd.SetArgs([]string{"generate", "-q", "--date", "2020-01-02", "--archive", archive})
+		assert.NoError(t, dg.Execute())
+		content, err := os.ReadFile(archive)
+		assert.NoError(t, err)
+		return content
+	}
+
+	dir := t.TempDir()
+	first := generate(filepath.Join(dir, "dist", "manpages.tar.gz"))
+	second := generate(filepath.Join(dir, "again.tar.gz"))
+	assert.Equal(t, first, second, "archives should be reproducible")
+
+	zr, err := gzip.NewReader(bytes.NewReader(first))
+	assert.NoError(t, err)
+	tr := tar.NewReader(zr)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		assert.Equal(t, "2020-01-02", hdr.ModTime.UTC().Format(time.DateOnly))
+	}
+	assert.Equal(t, []string{"foo-child.1", "foo.1", "foo.md", "foo_child.md"}, names)
+}
+
+func TestGzip(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "mdoc")
+	dg.AddDocGenerator(&cobraman.Options{}, "markdown")
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate", "--gzip", "-q", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+
+	assert.NoFileExists(t, filepath.Join(outDir, "foo.1"))
+	assert.FileExists(t, filepath.Join(outDir, "foo.md"), "markdown is not compressed")
+
+	f, err := os.Open(filepath.Join(outDir, "foo.1.gz"))
+	assert.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	content := new(bytes.Buffer)
+	_, err = content.ReadFrom(zr)
+	assert.NoError(t, err)
+	assert.Regexp(t, `\.Dt FOO 1`, content.String())
+}
+
+func TestInstall(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	appCmd.AddCommand(&cobra.Command{Use: "admin", Run: func(cmd *cobra.Command, args []string) {}})
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{Section: "8"}, "troff")
+	dg.AddDocGenerator(&cobraman.Options{}, "markdown")
+	dg.AddBashCompletionGenerator("foo.bash")
+
+	destDir := t.TempDir()
+	t.Setenv("DESTDIR", destDir)
+	dg.docCmd.SetArgs([]string{"install", "--prefix", "/usr", "--gzip", "-q"})
+	assert.NoError(t, dg.Execute())
+
+	root := filepath.Join(destDir, "usr", "share")
+	assert.FileExists(t, filepath.Join(root, "man", "man8", "foo.8.gz"))
+	assert.FileExists(t, filepath.Join(root, "man", "man8", "foo-admin.8.gz"))
+	assert.FileExists(t, filepath.Join(root, "bash-completion", "completions", "foo"))
+	assert.NoDirExists(t, filepath.Join(root, "doc"), "markdown is not installed")
+}
+
+func TestInstallDebian(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	admin := &cobra.Command{Use: "admin", Aliases: []string{"adm", "sub"}, Run: func(cmd *cobra.Command, args []string) {}}
+	admin.AddCommand(&cobra.Command{Use: "users", Aliases: []string{"u"}, Run: func(cmd *cobra.Command, args []string) {}})
+	appCmd.AddCommand(admin, &cobra.Command{Use: "sub", Run: func(cmd *cobra.Command, args []string) {}})
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.AddBashCompletionGenerator("foo.bash")
+
+	destDir := t.TempDir()
+	t.Setenv("DESTDIR", destDir)
+	dg.docCmd.SetArgs([]string{"install", "--layout", "debian", "-q"})
+	assert.NoError(t, dg.Execute())
+
+	man1 := filepath.Join(destDir, "usr", "share", "man", "man1")
+	assert.FileExists(t, filepath.Join(man1, "foo-admin.1.gz"))
+	target, err := os.Readlink(filepath.Join(man1, "foo-adm.1.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo-admin.1.gz", target)
+	target, err = os.Readlink(filepath.Join(man1, "foo-admin-u.1.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo-admin-users.1.gz", target)
+	info, err := os.Lstat(filepath.Join(man1, "foo-sub.1.gz"))
+	assert.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular(), "the page of a command is not replaced by an alias")
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+	info, err = os.Stat(man1)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	assert.FileExists(t, filepath.Join(destDir, "usr", "share", "bash-completion", "completions", "foo"))
+
+	// the layout does not compress the pages of later steps
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate-troff", "-o", outDir, "-q"})
+	assert.NoError(t, dg.Execute())
+	assert.FileExists(t, filepath.Join(outDir, "foo.1"))
+
+	dg.docCmd.SetArgs([]string{"install", "--layout", "nope", "-q"})
+	dg.docCmd.SetErr(new(bytes.Buffer))
+	assert.ErrorIs(t, dg.Execute(), ErrUnknownLayout)
+}
+
+func TestContents(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	appCmd.AddCommand(&cobra.Command{Use: "admin", Aliases: []string{"adm"}, Run: func(cmd *cobra.Command, args []string) {}})
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{Section: "8"}, "troff")
+	dg.AddDocGenerator(&cobraman.Options{}, "markdown")
+	dg.AddBashCompletionGenerator("foo.bash")
+
+	outDir := t.TempDir()
+	buf := new(bytes.Buffer)
+	dg.docCmd.SetOut(buf)
+	dg.docCmd.SetArgs([]string{"contents", "--output-dir", outDir, "--layout", "debian", "-q"})
+	assert.NoError(t, dg.Execute())
+	assert.FileExists(t, filepath.Join(outDir, "foo-admin.8.gz"))
+	assert.Equal(t, "contents:\n"+
+		"  - src: \""+filepath.Join(outDir, "foo-admin.8.gz")+"\"\n    dst: \"/usr/share/man/man8/foo-admin.8.gz\"\n    file_info:\n      mode: 0644\n"+
+		"  - src: \""+filepath.Join(outDir, "foo.8.gz")+"\"\n    dst: \"/usr/share/man/man8/foo.8.gz\"\n    file_info:\n      mode: 0644\n"+
+		"  - src: \""+filepath.Join(outDir, "foo.bash")+"\"\n    dst: \"/usr/share/bash-completion/completions/foo\"\n    file_info:\n      mode: 0644\n"+
+		"  - src: \"foo-admin.8.gz\"\n    dst: \"/usr/share/man/man8/foo-adm.8.gz\"\n    type: symlink\n",
+		buf.String())
+}
+
+func TestContentsHomebrew(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	appCmd.AddCommand(&cobra.Command{Use: "admin", Run: func(cmd *cobra.Command, args []string) {}})
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.AddBashCompletionGenerator("foo.bash")
+	dg.AddZshCompletionGenerator("foo.zsh")
+	dg.AddFishCompletionGenerator("foo.fish")
+
+	buf := new(bytes.Buffer)
+	dg.docCmd.SetOut(buf)
+	docs := filepath.ToSlash(t.TempDir())
+	dg.docCmd.SetArgs([]string{"contents", "--output-dir", docs, "--packager", "homebrew", "-q"})
+	assert.NoError(t, dg.Execute())
+	assert.Equal(t, strings.ReplaceAll(`man1.install "docs/foo-admin.1", "docs/foo.1"
+bash_completion.install "docs/foo.bash" => "foo"
+zsh_completion.install "docs/foo.zsh" => "_foo"
+fish_completion.install "docs/foo.fish"
+`, "docs", docs), buf.String())
+
+	destDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"install", "--prefix", destDir, "--layout", "homebrew", "-q"})
+	assert.NoError(t, dg.Execute())
+	assert.FileExists(t, filepath.Join(destDir, "share", "man", "man1", "foo.1"))
+	assert.FileExists(t, filepath.Join(destDir, "etc", "bash_completion.d", "foo"))
+	assert.FileExists(t, filepath.Join(destDir, "share", "zsh", "site-functions", "_foo"))
+	assert.FileExists(t, filepath.Join(destDir, "share", "fish", "vendor_completions.d", "foo.fish"))
+
+	dg.docCmd.SetArgs([]string{"contents", "--packager", "rpm", "-q"})
+	dg.docCmd.SetErr(new(bytes.Buffer))
+	assert.ErrorIs(t, dg.Execute(), ErrUnknownPackager)
+}
+
+func TestInstallUpdateIndex(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+
+	// a fake makewhatis recording its arguments; mandb is not on the PATH
+	bin := t.TempDir()
+	argsFile := filepath.Join(bin, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(bin, "makewhatis"), []byte(script), 0o755)) //nolint:gosec // executable
+	t.Setenv("PATH", bin)
+
+	prefix := t.TempDir()
+	dg.docCmd.SetArgs([]string{"install", "--prefix", prefix, "--update-index", "-q"})
+	assert.NoError(t, dg.Execute())
+	args, err := os.ReadFile(argsFile)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(prefix, "share", "man")+"\n", string(args))
+
+	script = "#!/bin/sh\necho broken >&2\nexit 1\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(bin, "makewhatis"), []byte(script), 0o755)) //nolint:gosec // executable
+	dg.docCmd.SetArgs([]string{"install", "--prefix", prefix, "--update-index", "-q"})
+	dg.docCmd.SetErr(new(bytes.Buffer))
+	err = dg.Execute()
+	assert.ErrorIs(t, err, ErrIndexFailed)
+	assert.ErrorContains(t, err, "broken")
+
+	// a staged installation is not indexed
+	destDir := t.TempDir()
+	t.Setenv("DESTDIR", destDir)
+	dg.docCmd.SetArgs([]string{"install", "--prefix", "/usr", "--update-index", "-q"})
+	assert.NoError(t, dg.Execute())
+	assert.FileExists(t, filepath.Join(destDir, "usr", "share", "man", "man1", "foo.1"))
+}
+
+func TestManDirSection(t *testing.T) {
+	for base, want := range map[string]string{
+		"foo.1":        "1",
+		"foo-bar.8":    "8",
+		"foo.1.gz":     "1",
+		"Foo::Bar.3pm": "3",
+		"foo.n":        "n",
+	} {
+		assert.Equal(t, want, manDirSection(base), base)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	mkTool := func(appCmd *cobra.Command) (*DocGenTool, *bytes.Buffer) {
+		dg := CreateDocGenCmdLineTool(appCmd)
+		dg.AddDocGenerator(&cobraman.Options{}, "troff")
+		buf := new(bytes.Buffer)
+		dg.docCmd.SetOut(buf)
+		dg.docCmd.SetErr(buf)
+		dg.docCmd.SetArgs([]string{"validate", "-q", "-o", filepath.Join(t.TempDir(), "unused")})
+		return dg, buf
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		appCmd := &cobra.Command{Use: "foo", Long: "Does foo.", Example: "foo"}
+		dg, _ := mkTool(appCmd)
+		assert.NoError(t, dg.Execute())
+	})
+
+	t.Run("findings", func(t *testing.T) {
+		appCmd := &cobra.Command{Use: "foo"}
+		appCmd.Flags().String("name", "", "")
+		dg, buf := mkTool(appCmd)
+		assert.ErrorIs(t, dg.Execute(), ErrValidationFailed)
+		assert.Regexp(t, `\| foo \| \*\*no\*\* \|`, buf.String())
+		assert.NoDirExists(t, filepath.Join(dg.installDirectory), "validate must not write files")
+	})
+}
+
+func TestChecksums(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo"}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.AddBashCompletionGenerator("foo.bash")
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate", "--checksums", "-q", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+
+	sums, err := os.ReadFile(filepath.Join(outDir, "SHA256SUMS"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(sums)), "\n")
+	assert.Len(t, lines, 2)
+
+	page, err := os.ReadFile(filepath.Join(outDir, "foo.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%x  foo.1", sha256.Sum256(page)), lines[0])
+	assert.Regexp(t, `^[0-9a-f]{64}  foo\.bash$`, lines[1])
+}
+
+func TestParseTemplateSpec(t *testing.T) {
+	for value, want := range map[string]templateSpec{
+		"man=my.tmpl":                {name: "man", path: "my.tmpl", extension: "man", separator: "-"},
+		"rst=t/rst.tmpl:rst":         {name: "rst", path: "t/rst.tmpl", extension: "rst", separator: "-"},
+		"rst=t/rst.tmpl:rst:_":       {name: "rst", path: "t/rst.tmpl", extension: "rst", separator: "_"},
+		"man=t/man.tmpl:use_section": {name: "man", path: "t/man.tmpl", extension: "use_section", separator: "-"},
+		`w=C:\t\w.tmpl:txt`:          {name: "w", path: `C:\t\w.tmpl`, extension: "txt", separator: "-"},
+	} {
+		spec, err := parseTemplateSpec(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, spec, value)
+	}
+	for _, value := range []string{"", "name", "=path", "name=", "n=p:a:b:c"} {
+		_, err := parseTemplateSpec(value)
+		assert.ErrorIs(t, err, ErrInvalidTemplateSpec, value)
+	}
+}
+
+func TestTemplateFlag(t *testing.T) {
+	appCmd := &cobra.Command{Use: "foo", Short: "does foo"}
+	appCmd.AddCommand(&cobra.Command{Use: "bar", Short: "does bar", Run: func(cmd *cobra.Command, args []string) {}})
+
+	tmplFile := filepath.Join(t.TempDir(), "plain.tmpl")
+	assert.NoError(t, os.WriteFile(tmplFile, []byte(`{{ .CommandPath }}: {{ .ShortDescription }}`), 0o600))
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate", "--formats", "plain", "--template", "plain=" + tmplFile + ":txt:_", "-q", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+
+	content, err := os.ReadFile(filepath.Join(outDir, "foo_bar.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo bar: does bar", string(content))
+	assert.NoFileExists(t, filepath.Join(outDir, "foo.1"))
+
+	// running the tool again registers the same template again
+	assert.NoError(t, dg.Execute())
+}
+
+func TestOnlyExclude(t *testing.T) {
+	appCmd := &cobra.Command{Use: "tool"}
+	for _, name := range []string{"sub", "subway", "other"} {
+		appCmd.AddCommand(&cobra.Command{Use: name, Run: func(cmd *cobra.Command, args []string) {}})
+	}
+
+	run := func(t *testing.T, args ...string) []string {
+		dg := CreateDocGenCmdLineTool(appCmd)
+		dg.AddDocGenerator(&cobraman.Options{}, "troff")
+		outDir := t.TempDir()
+		dg.docCmd.SetArgs(append([]string{"generate-troff", "-q", "-o", outDir}, args...))
+		assert.NoError(t, dg.Execute())
+		entries, err := os.ReadDir(outDir)
+		assert.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"tool-sub.1", "tool-subway.1"}, run(t, "--only", "tool sub*"))
+	assert.Equal(t, []string{"tool-other.1", "tool.1"}, run(t, "--exclude", "tool sub*"))
+	assert.Equal(t, []string{"tool-subway.1"}, run(t, "--only", "tool sub*", "--exclude", "tool sub"))
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.docCmd.SetArgs([]string{"generate-troff", "--only", "[", "-o", t.TempDir()})
+	assert.ErrorContains(t, dg.Execute(), "invalid glob")
+}
+
+func TestPage(t *testing.T) {
+	appCmd := &cobra.Command{Use: "tool"}
+	sub := &cobra.Command{Use: "sub", Short: "a sub command", Run: func(cmd *cobra.Command, args []string) {}}
+	sub.AddCommand(&cobra.Command{Use: "leaf", Aliases: []string{"lf"}, Run: func(cmd *cobra.Command, args []string) {}})
+	appCmd.AddCommand(sub)
+
+	run := func(args ...string) (string, error) {
+		dg := CreateDocGenCmdLineTool(appCmd)
+		dg.AddDocGenerator(&cobraman.Options{Section: "8"}, "mdoc")
+		buf := new(bytes.Buffer)
+		dg.docCmd.SetOut(buf)
+		dg.docCmd.SetArgs(append([]string{"page"}, args...))
+		err := dg.Execute()
+		return buf.String(), err
+	}
+
+	out, err := run("tool sub", "--format", "troff", "-")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\.TH "TOOL\\-SUB" "8"`, out)
+
+	out, err = run("sub", "lf")
+	assert.NoError(t, err)
+	assert.Regexp(t, `\.Dt TOOL\\-SUB\\-LEAF 8`, out)
+
+	out, err = run()
+	assert.NoError(t, err)
+	assert.Regexp(t, `\.Dt TOOL 8`, out)
+
+	_, err = run("nope")
+	assert.ErrorIs(t, err, ErrUnknownCommand)
+	_, err = run("sub", "--format", "nope")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestServe(t *testing.T) {
+	appCmd := &cobra.Command{Use: "tool", Short: "the <tool>"}
+	sub := &cobra.Command{Use: "sub", Short: "a sub command", Run: func(cmd *cobra.Command, args []string) {}}
+	sub.AddCommand(&cobra.Command{Use: "leaf", Run: func(cmd *cobra.Command, args []string) {}})
+	appCmd.AddCommand(sub, &cobra.Command{Use: "hidden", Run: func(cmd *cobra.Command, args []string) {}})
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{Author: "Jane Doe"}, "markdown")
+	dg.exclude = []string{"tool hidden"}
+	handler, err := dg.serveHandler()
+	require.NoError(t, err)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `<li><a href="tool.html">tool</a> - the &lt;tool&gt;`)
+	assert.Contains(t, rec.Body.String(), "\n<ul>\n"+`<li><a href="tool_sub_leaf.html">tool sub leaf</a></li>`+"\n</ul>\n</li>\n")
+	assert.NotContains(t, rec.Body.String(), "hidden")
+
+	rec = get("/tool_sub.html")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<h1>tool sub</h1>")
+	assert.Contains(t, rec.Body.String(), "Jane Doe", "the options of the first doc generator are used")
+	assert.Contains(t, rec.Body.String(), `<a href="tool_sub_leaf.html">`)
+
+	sub.Short = "changed"
+	assert.Contains(t, get("/tool_sub.html").Body.String(), "<p>changed</p>", "pages are generated on every request")
+
+	assert.Equal(t, http.StatusNotFound, get("/tool_hidden.html").Code)
+	assert.Equal(t, http.StatusNotFound, get("/nope.html").Code)
+}
+
+func TestDocs(t *testing.T) {
+	dg := CreateDocGenCmdLineTool(&cobra.Command{Use: "tool"})
+	dir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"docs", "--output-dir", dir, "--section", "8", "--quiet"})
+	require.NoError(t, dg.Execute())
+
+	for _, name := range []string{"docsgen.8", "docsgen-docs.8", "docsgen-generate.8", "docsgen-install.8"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "docsgen.8"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `\fB\-\-output\-dir\fP = <dir>`)
+	assert.Contains(t, string(content), "docsgen generate \\-\\-output\\-dir dist/docs")
+	assert.Contains(t, string(content), "(default: the number of CPUs)")
+
+	dg.docCmd.SetArgs([]string{"docs", "--output-dir", dir, "--format", "nope", "--quiet"})
+	dg.docCmd.SetOutput(io.Discard)
+	assert.ErrorIs(t, dg.Execute(), ErrUnknownFormat)
+}
+
+func TestMarkdownIndex(t *testing.T) {
+	appCmd := &cobra.Command{Use: "tool", Short: "the tool"}
+	sub := &cobra.Command{Use: "sub", Short: "a sub command", Run: func(cmd *cobra.Command, args []string) {}}
+	sub.AddCommand(&cobra.Command{Use: "leaf", Run: func(cmd *cobra.Command, args []string) {}})
+	appCmd.AddCommand(sub, &cobra.Command{Use: "other", Short: "another", Run: func(cmd *cobra.Command, args []string) {}})
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.AddDocGenerator(&cobraman.Options{}, "markdown")
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate", "-q", "--markdown-index", "README.md", "--exclude", "tool other", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+
+	content, err := os.ReadFile(filepath.Join(outDir, "README.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "# tool\n\n"+
+		"* [tool](tool.md) - the tool\n"+
+		"  * [tool sub](tool_sub.md) - a sub command\n"+
+		"    * [tool sub leaf](tool_sub_leaf.md)\n", string(content))
+}
+
+func TestAddCustomGenerator(t *testing.T) {
+	appCmd := &cobra.Command{Use: "tool", Short: "the tool"}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(&cobraman.Options{}, "troff")
+	dg.AddCustomGenerator("json", func(root *cobra.Command, dir string) ([]string, error) {
+		path := filepath.Join(dir, "cli.json")
+		content := fmt.Sprintf(`{"name": %q}`, root.Name())
+		return []string{path}, os.WriteFile(path, []byte(content), 0o600)
+	})
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate", "-q", "--formats", "json", "--checksums", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+	assert.NoFileExists(t, filepath.Join(outDir, "tool.1"))
+	content, err := os.ReadFile(filepath.Join(outDir, "cli.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "tool"}`, string(content))
+	sums, err := os.ReadFile(filepath.Join(outDir, checksumsFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(sums), "  cli.json\n")
+
+	dg.AddCustomGenerator("broken", func(root *cobra.Command, dir string) ([]string, error) {
+		return nil, os.ErrPermission
+	})
+	dg.docCmd.SetArgs([]string{"generate-broken", "-q", "-o", outDir})
+	assert.ErrorIs(t, dg.Execute(), os.ErrPermission)
+}
+
+func TestAddDocGeneratorTo(t *testing.T) {
+	appCmd := &cobra.Command{Use: "tool"}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGeneratorTo(&cobraman.Options{}, "troff", filepath.Join("man", "man1"))
+	dg.AddDocGeneratorTo(&cobraman.Options{}, "markdown", "markdown")
+	assert.Panics(t, func() { dg.AddDocGeneratorTo(&cobraman.Options{}, "foo", "foo") })
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate", "-q", "-o", outDir})
+	assert.NoError(t, dg.Execute())
+	assert.FileExists(t, filepath.Join(outDir, "man", "man1", "tool.1"))
+	assert.FileExists(t, filepath.Join(outDir, "markdown", "tool.md"))
+
+	archive := filepath.Join(t.TempDir(), "docs.tar.gz")
+	dg.docCmd.SetArgs([]string{"generate", "-q", "--archive", archive})
+	assert.NoError(t, dg.Execute())
+	f, err := os.Open(archive)
+	assert.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	tr := tar.NewReader(zr)
+	var names []string
+	for hdr, err := tr.Next(); err == nil; hdr, err = tr.Next() {
+		names = append(names, hdr.Name)
+	}
+	assert.Equal(t, []string{"man/man1/tool.1", "markdown/tool.md"}, names)
+}
+
+func TestGenerationErrors(t *testing.T) {
+	appCmd := &cobra.Command{Use: "tool"}
+	for _, name := range []string{"bad1", "good", "bad2"} {
+		appCmd.AddCommand(&cobra.Command{Use: name, Run: func(cmd *cobra.Command, args []string) {}})
+	}
+	errBad := errors.New("bad annotation")
+	opts := &cobraman.Options{
+		PrepareData: func(cmd *cobra.Command, data *cobraman.DocData) error {
+			if strings.HasPrefix(cmd.Name(), "bad") {
+				return errBad
+			}
+			return nil
+		},
+	}
+
+	dg := CreateDocGenCmdLineTool(appCmd)
+	dg.AddDocGenerator(opts, "troff")
+	dg.AddDocGenerator(opts, "markdown")
+	logs := new(bytes.Buffer)
+	dg.docCmd.SetErr(logs)
+
+	outDir := t.TempDir()
+	dg.docCmd.SetArgs([]string{"generate", "--parallel", "1", "-o", outDir})
+	err := dg.Execute()
+	assert.ErrorIs(t, err, ErrGenerationFailed)
+	assert.ErrorIs(t, err, errBad)
+	assert.ErrorContains(t, err, "4 errors")
+	assert.ErrorContains(t, err, "markdown: tool bad2: bad annotation")
+
+	var pageErr *cobraman.PageError
+	assert.ErrorAs(t, err, &pageErr)
+	assert.Equal(t, "tool bad1", pageErr.CommandPath)
+
+	assert.FileExists(t, filepath.Join(outDir, "tool-good.1"))
+	assert.FileExists(t, filepath.Join(outDir, "tool_good.md"))
+	assert.FileExists(t, filepath.Join(outDir, "tool.md"))
+	assert.Equal(t, 4, strings.Count(logs.String(), "level=ERROR msg=failed"))
+}
@@ -0,0 +1,93 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/carlwr/cobraman"
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/spf13/cobra"
+)
+
+// ErrUnknownCommand is returned by the page subcommand for a command path
+// that does not name a command of the application.  It is the same error as
+// cobraman.ErrUnknownCommand.
+var ErrUnknownCommand = cobraman.ErrUnknownCommand
+
+func (dg *DocGenTool) newPageCmd() *cobra.Command {
+	var format string
+
+	pageCmd := &cobra.Command{
+		Use:   "page [command path] [-]",
+		Short: "Write the page for one command to stdout",
+		Long: `Write the page for one command of the application to stdout.
+
+The command path can be given as separate words or as one quoted argument, with
+or without the name of the application (e.g. "page tool sub" or "page sub").
+No path means the application's root command.  A trailing "-" is accepted to
+make the use of stdout explicit, e.g. for "page sub --format troff - | man -l -".`,
+		RunE: func(myCmd *cobra.Command, args []string) error {
+			return dg.page(myCmd.OutOrStdout(), format, args)
+		},
+	}
+	pageCmd.Flags().StringVar(&format, "format", "", "Template to render the page with (default the first doc generator's)")
+
+	return pageCmd
+}
+
+// page writes the page for the command named by args to w.
+func (dg *DocGenTool) page(w io.Writer, format string, args []string) error {
+	if len(args) > 0 && args[len(args)-1] == "-" {
+		args = args[:len(args)-1]
+	}
+	cmd, err := cobraman.FindCommand(dg.appCmd, strings.Fields(strings.Join(args, " ")))
+	if err != nil {
+		return err
+	}
+
+	opts, format, err := dg.formatOptions(format)
+	if err != nil {
+		return err
+	}
+	return cobraman.GenerateOnePage(cmd, opts, format, w)
+}
+
+// formatOptions returns the options to render single pages with the template
+// format with, and the name of the template: the options of the doc generator
+// for format, or else of the first doc generator, with the overrides of the
+// command line applied.  No format means that of the first doc generator.
+func (dg *DocGenTool) formatOptions(format string) (*cobraman.Options, string, error) {
+	opts := &cobraman.Options{}
+	if len(dg.docGenerators) > 0 {
+		opts = dg.docGenerators[0].opts
+		if format == "" {
+			format = dg.docGenerators[0].templateName
+		}
+	}
+	for _, docGen := range dg.docGenerators {
+		if docGen.templateName == format {
+			opts = docGen.opts
+			break
+		}
+	}
+	if _, ok := templ.GetTemplateInfo(format); !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	opts, err := dg.withOverrides(opts)
+	return opts, format, err
+}
@@ -0,0 +1,146 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carlwr/cobraman"
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/spf13/cobra"
+)
+
+func (dg *DocGenTool) newServeCmd() *cobra.Command {
+	var addr string
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Args:  cobra.NoArgs,
+		Short: "Serve the pages as HTML for previewing them",
+		Long: `Serve the pages of all commands as HTML, with an index of the commands at /.
+
+Nothing is written to disk: every page is generated when it is requested, so
+reloading it in the browser shows the current state of the documentation.  The
+options of the html doc generator are used if there is one, else those of the
+first doc generator.  --only and --exclude select the pages served.`,
+		RunE: func(myCmd *cobra.Command, args []string) error {
+			handler, err := dg.serveHandler()
+			if err != nil {
+				return err
+			}
+			dg.logger.Info("serving docs", "url", "http://"+addr+"/")
+			server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+			return server.ListenAndServe()
+		},
+	}
+	serveCmd.Flags().StringVar(&addr, "addr", "localhost:6060", "Address to serve the pages on")
+
+	return serveCmd
+}
+
+// serveHandler returns the handler of the serve subcommand.
+func (dg *DocGenTool) serveHandler() (http.Handler, error) {
+	opts, format, err := dg.formatOptions("html")
+	if err != nil {
+		return nil, err
+	}
+	sep, ext, _, _ := templ.GetTemplate(format)
+
+	// cobra sets up the flags of commands lazily, so they are not documented
+	// concurrently
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var pages []cobraman.CommandModel
+		for _, m := range allCommands(cobraman.NewCobraModel(dg.appCmd), nil) {
+			if opts.Filter == nil || opts.Filter(m.CommandPath()) {
+				pages = append(pages, m)
+			}
+		}
+		fileName := func(m cobraman.CommandModel) string {
+			return strings.ReplaceAll(m.CommandPath(), " ", sep) + "." + ext
+		}
+
+		buf := new(bytes.Buffer)
+		if r.URL.Path == "/" {
+			writeHTMLIndex(buf, dg.appCmd.Name(), pages, fileName)
+		} else {
+			var page cobraman.CommandModel
+			for _, m := range pages {
+				if "/"+fileName(m) == r.URL.Path {
+					page = m
+					break
+				}
+			}
+			if page == nil {
+				http.NotFound(w, r)
+				return
+			}
+			pageOpts := *opts
+			if err := cobraman.GenerateModelPage(page, &pageOpts, format, buf); err != nil {
+				dg.logger.Error("failed", "page", page.CommandPath(), "error", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = buf.WriteTo(w)
+	}), nil
+}
+
+// writeHTMLIndex writes an HTML page linking the pages, which are in command
+// tree order starting with the root command, to buf.  Subcommands are nested below their parents and every
+// link is followed by the short description of the command.
+func writeHTMLIndex(buf *bytes.Buffer, title string, pages []cobraman.CommandModel, fileName func(cobraman.CommandModel) string) {
+	fmt.Fprintf(buf, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%[1]s</h1>\n",
+		html.EscapeString(title))
+	rootDepth := 0
+	if len(pages) > 0 {
+		rootDepth = strings.Count(pages[0].CommandPath(), " ")
+	}
+	depth := 0
+	for _, m := range pages {
+		d := strings.Count(m.CommandPath(), " ") - rootDepth + 1
+		if d > depth {
+			for ; depth < d; depth++ {
+				buf.WriteString("\n<ul>\n")
+			}
+		} else {
+			buf.WriteString("</li>\n")
+			for ; depth > d; depth-- {
+				buf.WriteString("</ul>\n</li>\n")
+			}
+		}
+		fmt.Fprintf(buf, "<li><a href=\"%s\">%s</a>", html.EscapeString(fileName(m)), html.EscapeString(m.CommandPath()))
+		if m.Short() != "" {
+			fmt.Fprintf(buf, " - %s", html.EscapeString(m.Short()))
+		}
+	}
+	if depth > 0 {
+		buf.WriteString("</li>\n")
+		for ; depth > 1; depth-- {
+			buf.WriteString("</ul>\n</li>\n")
+		}
+		buf.WriteString("</ul>\n")
+	}
+	buf.WriteString("</body>\n</html>\n")
+}
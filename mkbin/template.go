@@ -0,0 +1,93 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/carlwr/cobraman"
+	"github.com/carlwr/cobraman/internal/templ"
+)
+
+// ErrInvalidTemplateSpec is returned for a malformed --template value.
+var ErrInvalidTemplateSpec = errors.New("invalid --template value, want name=path.tmpl[:ext[:sep]]")
+
+// templateSpec is a parsed --template value.
+type templateSpec struct {
+	name      string
+	path      string
+	extension string
+	separator string
+}
+
+// parseTemplateSpec parses name=path.tmpl[:ext[:sep]].  The extension
+// defaults to the name and the separator to "-".  The path may itself contain
+// colons, so the optional fields are only split off if there are at most two
+// colons after the path's last path separator.
+func parseTemplateSpec(value string) (templateSpec, error) {
+	name, rest, ok := strings.Cut(value, "=")
+	if !ok || name == "" || rest == "" {
+		return templateSpec{}, fmt.Errorf("%w: %q", ErrInvalidTemplateSpec, value)
+	}
+	spec := templateSpec{name: name, extension: name, separator: "-"}
+
+	dirEnd := strings.LastIndexAny(rest, `/\`) + 1
+	fields := strings.Split(rest[dirEnd:], ":")
+	if len(fields) > 3 {
+		return templateSpec{}, fmt.Errorf("%w: %q", ErrInvalidTemplateSpec, value)
+	}
+	spec.path = rest[:dirEnd] + fields[0]
+	if len(fields) > 1 && fields[1] != "" {
+		spec.extension = fields[1]
+	}
+	if len(fields) > 2 && fields[2] != "" {
+		spec.separator = fields[2]
+	}
+	return spec, nil
+}
+
+// addTemplateFiles registers the templates given by --template, and adds a
+// generator for each.  The generators use the Options of the first doc
+// generator added to the tool, or empty Options if there is none.  Specs
+// registered by an earlier Execute of the tool are skipped.
+func (dg *DocGenTool) addTemplateFiles() error {
+	opts := &cobraman.Options{}
+	if len(dg.docGenerators) > 0 {
+		opts = dg.docGenerators[0].opts
+	}
+
+	for _, value := range dg.templateFiles {
+		spec, err := parseTemplateSpec(value)
+		if err != nil {
+			return err
+		}
+		if dg.templateSpecs[spec.name] == value {
+			continue
+		}
+		if dg.hasGenerator(spec.name) {
+			return fmt.Errorf("%w: generator %q already exists", ErrInvalidTemplateSpec, spec.name)
+		}
+		if err := templ.RegisterTemplateFile(spec.name, spec.separator, spec.extension, spec.path); err != nil {
+			return fmt.Errorf("--template %s: %w", spec.name, err)
+		}
+		dg.generators = append(dg.generators, dg.newDocGenerator(opts, spec.name, spec.extension, ""))
+		if dg.templateSpecs == nil {
+			dg.templateSpecs = make(map[string]string)
+		}
+		dg.templateSpecs[spec.name] = value
+	}
+	return nil
+}
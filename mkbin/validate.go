@@ -0,0 +1,88 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mkbin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+)
+
+// ErrValidationFailed is returned by the validate subcommand when it has
+// findings.
+var ErrValidationFailed = errors.New("documentation validation failed")
+
+func (dg *DocGenTool) newValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Args:  cobra.NoArgs,
+		Short: "Check the documentation without writing any files",
+		Long: `Check the documentation of the application without writing any files.
+
+Every page of every documentation generator is rendered in memory, and the
+command tree is checked for missing documentation: commands without a long
+description or examples, and flags without usage text or argument hints.
+Exits with an error if anything was found.`,
+		RunE: func(myCmd *cobra.Command, args []string) error {
+			return dg.validate(myCmd.OutOrStdout())
+		},
+	}
+}
+
+// validate writes its findings to w.
+func (dg *DocGenTool) validate(w io.Writer) error {
+	failed := false
+
+	for _, docGen := range dg.docGenerators {
+		opts, err := dg.withOverrides(docGen.opts)
+		if err != nil {
+			return err
+		}
+		for _, m := range allCommands(cobraman.NewCobraModel(dg.appCmd), nil) {
+			if opts.Filter != nil && !opts.Filter(m.CommandPath()) {
+				continue
+			}
+			if err := cobraman.GenerateModelPage(m, opts, docGen.templateName, io.Discard); err != nil {
+				failed = true
+				fmt.Fprintf(w, "%s: %s: %v\n", docGen.templateName, m.CommandPath(), err)
+			}
+		}
+	}
+
+	report := cobraman.Coverage(dg.appCmd)
+	if !report.Complete() {
+		failed = true
+		if err := report.WriteMarkdown(w); err != nil {
+			return err
+		}
+	}
+
+	if failed {
+		return ErrValidationFailed
+	}
+	dg.logger.Info("validated", "commands", len(report.Commands))
+	return nil
+}
+
+// allCommands appends m and all of its children to cmds.
+func allCommands(m cobraman.CommandModel, cmds []cobraman.CommandModel) []cobraman.CommandModel {
+	cmds = append(cmds, m)
+	for _, c := range m.Subcommands() {
+		cmds = allCommands(c, cmds)
+	}
+	return cmds
+}
@@ -0,0 +1,247 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CommandModel is the view of a command that cobraman collects its
+// documentation data from.  Implement it to document command line
+// interfaces that are not built with cobra; NewCobraModel provides the
+// implementation used for cobra commands.
+type CommandModel interface {
+	// Name is the name of the command itself (e.g. "commit")
+	Name() string
+	// CommandPath is the space separated path of the command (e.g. "git commit")
+	CommandPath() string
+	// UseLine is the one-line usage of the command
+	UseLine() string
+	// Short is the one-line description of the command
+	Short() string
+	// Long is the full description of the command
+	Long() string
+	// Example holds usage examples for the command
+	Example() string
+	// Annotations are key/value pairs, used for the man-*-section annotations
+	Annotations() map[string]string
+	// NoArgs reports whether the command accepts no positional arguments
+	NoArgs() bool
+
+	// Flags are all flags available to the command
+	Flags() *pflag.FlagSet
+	// InheritedFlags are the flags inherited from parent commands
+	InheritedFlags() *pflag.FlagSet
+	// NonInheritedFlags are the flags not inherited from parent commands
+	NonInheritedFlags() *pflag.FlagSet
+
+	// Parent is the parent command, or nil for the root command
+	Parent() CommandModel
+	// Subcommands are the child commands that should be documented
+	Subcommands() []CommandModel
+}
+
+// CobraModel is the CommandModel implementation for a cobra.Command.
+type CobraModel struct {
+	cmd *cobra.Command
+	// includeHidden makes Subcommands include hidden commands, see
+	// Options.IncludeHidden
+	includeHidden bool
+}
+
+// NewCobraModel wraps cmd as a CommandModel.
+func NewCobraModel(cmd *cobra.Command) *CobraModel {
+	return &CobraModel{cmd: cmd}
+}
+
+// Command returns the wrapped cobra.Command.
+func (m *CobraModel) Command() *cobra.Command { return m.cmd }
+
+func (m *CobraModel) Name() string                      { return m.cmd.Name() }
+func (m *CobraModel) CommandPath() string               { return m.cmd.CommandPath() }
+func (m *CobraModel) UseLine() string                   { return m.cmd.UseLine() }
+func (m *CobraModel) Short() string                     { return m.cmd.Short }
+func (m *CobraModel) Long() string                      { return m.cmd.Long }
+func (m *CobraModel) Example() string                   { return m.cmd.Example }
+func (m *CobraModel) Annotations() map[string]string    { return m.cmd.Annotations }
+func (m *CobraModel) Flags() *pflag.FlagSet             { return m.cmd.Flags() }
+func (m *CobraModel) InheritedFlags() *pflag.FlagSet    { return m.cmd.InheritedFlags() }
+func (m *CobraModel) NonInheritedFlags() *pflag.FlagSet { return m.cmd.NonInheritedFlags() }
+
+// NoArgs reports whether the command accepts no positional arguments.  The
+// man-args annotation decides if it is set, see argsAnnotation; otherwise the
+// Args validator of the command is checked.
+func (m *CobraModel) NoArgs() bool {
+	if noArgs, ok := argsAnnotation(m.cmd.Annotations); ok {
+		return noArgs
+	}
+	return rejectsArgs(m.cmd)
+}
+
+// argsAnnotation returns what the man-args annotation says about the
+// positional arguments of a command: "none" documents the command as taking
+// no arguments, any other value as taking some.  ok is false if the
+// annotation is not set.
+func argsAnnotation(annotations map[string]string) (noArgs bool, ok bool) {
+	value, ok := annotations["man-args"]
+	return ok && value == "none", ok
+}
+
+// rejectsArgs reports whether the Args validator of cmd is cobra.NoArgs.
+// Other validators that reject arguments, e.g. cobra.ExactArgs(0), are not
+// recognized, as the validator would have to be called to tell; the man-args
+// annotation or Options.NoArgsFunc documents such commands.
+func rejectsArgs(cmd *cobra.Command) bool {
+	return cmd.Args != nil && reflect.ValueOf(cmd.Args).Pointer() == reflect.ValueOf(cobra.NoArgs).Pointer()
+}
+
+func (m *CobraModel) Parent() CommandModel {
+	if !m.cmd.HasParent() {
+		return nil
+	}
+	return &CobraModel{cmd: m.cmd.Parent(), includeHidden: m.includeHidden}
+}
+
+// Subcommands skips commands that are unavailable or additional help topics.
+// Hidden commands are only skipped unless Options.IncludeHidden is set.
+func (m *CobraModel) Subcommands() []CommandModel {
+	subCmds := make([]CommandModel, 0, len(m.cmd.Commands()))
+	for _, c := range m.cmd.Commands() {
+		hidden := m.includeHidden && c.Hidden && c.Deprecated == "" && (c.Runnable() || c.HasSubCommands())
+		if !c.IsAvailableCommand() && !hidden || c.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		subCmds = append(subCmds, &CobraModel{cmd: c, includeHidden: m.includeHidden})
+	}
+	return subCmds
+}
+
+// withHidden returns m such that its subcommands include hidden commands, see
+// Options.IncludeHidden.
+func withHidden(m CommandModel) CommandModel {
+	switch m := m.(type) {
+	case *CobraModel:
+		return &CobraModel{cmd: m.cmd, includeHidden: true}
+	case *specModel:
+		return &specModel{spec: m.spec, parent: m.parent, includeHidden: true}
+	}
+	return m
+}
+
+// cobraCommand returns the cobra.Command behind m, or nil if m is not
+// backed by cobra.
+func cobraCommand(m CommandModel) *cobra.Command {
+	if cm, ok := unwrapModel(m).(*CobraModel); ok {
+		return cm.cmd
+	}
+	return nil
+}
+
+// flagSetModel is the CommandModel for a bare pflag.FlagSet.
+type flagSetModel struct {
+	name string
+	fs   *pflag.FlagSet
+}
+
+func (m *flagSetModel) Name() string                   { return m.name }
+func (m *flagSetModel) CommandPath() string            { return m.name }
+func (m *flagSetModel) UseLine() string                { return m.name + " [flags]" }
+func (m *flagSetModel) Short() string                  { return "" }
+func (m *flagSetModel) Long() string                   { return "" }
+func (m *flagSetModel) Example() string                { return "" }
+func (m *flagSetModel) Annotations() map[string]string { return nil }
+func (m *flagSetModel) NoArgs() bool                   { return false }
+func (m *flagSetModel) Flags() *pflag.FlagSet          { return m.fs }
+func (m *flagSetModel) InheritedFlags() *pflag.FlagSet {
+	return pflag.NewFlagSet(m.name, pflag.ContinueOnError)
+}
+func (m *flagSetModel) NonInheritedFlags() *pflag.FlagSet { return m.fs }
+func (m *flagSetModel) Parent() CommandModel              { return nil }
+func (m *flagSetModel) Subcommands() []CommandModel       { return nil }
+
+// commandGroups returns the subcommands of m, subCmds, grouped as by cobra's
+// command groups or the categories of CommandSpecs, or nil if they are not
+// grouped.  Groups are in the order they were added to m, followed by the
+// commands not in a group, as in cobra's help; for CommandSpecs, they are in
+// the order of their first command.
+func commandGroups(m CommandModel, subCmds []CommandModel) []CommandGroup {
+	groupOf := func(c CommandModel) string { return "" }
+	var groups []CommandGroup
+	switch m := unwrapModel(m).(type) {
+	case *CobraModel:
+		if len(m.cmd.Groups()) == 0 {
+			return nil
+		}
+		titles := make(map[string]string, len(m.cmd.Groups()))
+		for _, g := range m.cmd.Groups() {
+			title := strings.TrimSuffix(strings.TrimSpace(g.Title), ":")
+			titles[g.ID] = title
+			groups = append(groups, CommandGroup{Title: title})
+		}
+		groupOf = func(c CommandModel) string {
+			if cm, ok := unwrapModel(c).(*CobraModel); ok {
+				return titles[cm.cmd.GroupID]
+			}
+			return ""
+		}
+	case *specModel:
+		groupOf = func(c CommandModel) string {
+			if sm, ok := unwrapModel(c).(*specModel); ok {
+				return sm.spec.Category
+			}
+			return ""
+		}
+	default:
+		return nil
+	}
+
+	var ungrouped []CommandModel
+	for _, c := range subCmds {
+		title := groupOf(c)
+		i := slices.IndexFunc(groups, func(g CommandGroup) bool { return g.Title == title })
+		switch {
+		case title == "":
+			ungrouped = append(ungrouped, c)
+		case i < 0:
+			groups = append(groups, CommandGroup{Title: title, Commands: []CommandModel{c}})
+		default:
+			groups[i].Commands = append(groups[i].Commands, c)
+		}
+	}
+	groups = slices.DeleteFunc(groups, func(g CommandGroup) bool { return len(g.Commands) == 0 })
+	if len(ungrouped) > 0 {
+		if _, isSpec := unwrapModel(m).(*specModel); isSpec {
+			groups = slices.Insert(groups, 0, CommandGroup{Commands: ungrouped})
+		} else {
+			groups = append(groups, CommandGroup{Title: "Additional Commands", Commands: ungrouped})
+		}
+	}
+	if len(groups) == 0 || len(groups) == 1 && groups[0].Title == "" {
+		return nil
+	}
+	return groups
+}
+
+// unwrapModel returns the model wrapped by the noArgsModel m, or m itself.
+func unwrapModel(m CommandModel) CommandModel {
+	if w, ok := m.(noArgsModel); ok {
+		return w.CommandModel
+	}
+	return m
+}
@@ -0,0 +1,286 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel is a minimal CommandModel not backed by cobra.
+type fakeModel struct {
+	name     string
+	short    string
+	flags    *pflag.FlagSet
+	parent   *fakeModel
+	children []*fakeModel
+}
+
+func newFakeModel(name, short string, children ...*fakeModel) *fakeModel {
+	m := &fakeModel{name: name, short: short, flags: pflag.NewFlagSet(name, pflag.ContinueOnError)}
+	for _, c := range children {
+		c.parent = m
+	}
+	m.children = children
+	return m
+}
+
+func (m *fakeModel) Name() string { return m.name }
+
+func (m *fakeModel) CommandPath() string {
+	if m.parent == nil {
+		return m.name
+	}
+	return m.parent.CommandPath() + " " + m.name
+}
+
+func (m *fakeModel) UseLine() string                { return m.CommandPath() + " [flags]" }
+func (m *fakeModel) Short() string                  { return m.short }
+func (m *fakeModel) Long() string                   { return "" }
+func (m *fakeModel) Example() string                { return "" }
+func (m *fakeModel) Annotations() map[string]string { return nil }
+func (m *fakeModel) NoArgs() bool                   { return true }
+func (m *fakeModel) Flags() *pflag.FlagSet          { return m.flags }
+func (m *fakeModel) InheritedFlags() *pflag.FlagSet {
+	return pflag.NewFlagSet("", pflag.ContinueOnError)
+}
+func (m *fakeModel) NonInheritedFlags() *pflag.FlagSet { return m.flags }
+
+func (m *fakeModel) Parent() cobraman.CommandModel {
+	if m.parent == nil {
+		return nil
+	}
+	return m.parent
+}
+
+func (m *fakeModel) Subcommands() []cobraman.CommandModel {
+	subCmds := make([]cobraman.CommandModel, 0, len(m.children))
+	for _, c := range m.children {
+		subCmds = append(subCmds, c)
+	}
+	return subCmds
+}
+
+func TestCommandModel(t *testing.T) {
+	root := newFakeModel("tool", "does things", newFakeModel("run", "runs things"))
+	root.flags.String("config", "", "config file")
+
+	t.Run("page", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, cobraman.GenerateModelPage(root, &cobraman.Options{}, "troff", buf))
+		assert.Regexp(t, `\.SH NAME\ntool - does things`, buf.String())
+		assert.Regexp(t, `\\fB\\-\\-config\\fP`, buf.String())
+		assert.Regexp(t, `\.BR tool\\-run \(1\)`, buf.String())
+	})
+
+	t.Run("docs", func(t *testing.T) {
+		tmpD := tempDir(t)
+		require.NoError(t, cobraman.GenerateModelDocs(root, &cobraman.Options{}, tmpD, "markdown"))
+		assert.FileExists(t, filepath.Join(tmpD, "tool.md"))
+		assert.FileExists(t, filepath.Join(tmpD, "tool_run.md"))
+	})
+}
+
+// valueModel is a CommandModel of a value type that cannot be compared, as
+// it has slice fields.
+type valueModel struct {
+	path     []string
+	children []valueModel
+}
+
+func (m valueModel) Name() string                   { return m.path[len(m.path)-1] }
+func (m valueModel) CommandPath() string            { return strings.Join(m.path, " ") }
+func (m valueModel) UseLine() string                { return m.CommandPath() }
+func (m valueModel) Short() string                  { return "the " + m.Name() }
+func (m valueModel) Long() string                   { return "" }
+func (m valueModel) Example() string                { return "" }
+func (m valueModel) Annotations() map[string]string { return nil }
+func (m valueModel) NoArgs() bool                   { return true }
+func (m valueModel) Flags() *pflag.FlagSet          { return pflag.NewFlagSet("", pflag.ContinueOnError) }
+func (m valueModel) InheritedFlags() *pflag.FlagSet {
+	return pflag.NewFlagSet("", pflag.ContinueOnError)
+}
+func (m valueModel) NonInheritedFlags() *pflag.FlagSet {
+	return pflag.NewFlagSet("", pflag.ContinueOnError)
+}
+
+func (m valueModel) Parent() cobraman.CommandModel {
+	if len(m.path) == 1 {
+		return nil
+	}
+	return valueModel{path: m.path[:len(m.path)-1]}
+}
+
+func (m valueModel) Subcommands() []cobraman.CommandModel {
+	subCmds := make([]cobraman.CommandModel, 0, len(m.children))
+	for _, c := range m.children {
+		subCmds = append(subCmds, c)
+	}
+	return subCmds
+}
+
+func TestValueModel(t *testing.T) {
+	root := valueModel{path: []string{"tool"}, children: []valueModel{{path: []string{"tool", "run"}}}}
+
+	tmpD := tempDir(t)
+	require.NoError(t, cobraman.GenerateModelDocs(root, &cobraman.Options{}, tmpD, "markdown"))
+	assert.FileExists(t, filepath.Join(tmpD, "tool.md"))
+	assert.FileExists(t, filepath.Join(tmpD, "tool_run.md"))
+
+	tmpD = tempDir(t)
+	opts := cobraman.Options{Filter: func(path string) bool { return path != "tool" }}
+	require.NoError(t, cobraman.GenerateModelDocs(root, &opts, tmpD, "markdown"))
+	assert.NoFileExists(t, filepath.Join(tmpD, "tool.md"))
+	assert.FileExists(t, filepath.Join(tmpD, "tool_run.md"))
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateModelPage(root, &cobraman.Options{}, "troff", buf))
+	assert.Contains(t, buf.String(), "tool\\-run")
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateModelCombinedPage(root, &cobraman.Options{}, "markdown", buf))
+	assert.Contains(t, buf.String(), "## tool run\n")
+}
+
+func TestNoArgs(t *testing.T) {
+	validated := func(args cobra.PositionalArgs) *cobra.Command {
+		return &cobra.Command{Use: "c", Args: args, ValidArgs: []string{"a\tthe a"}}
+	}
+	cases := []struct {
+		name string
+		cmd  *cobra.Command
+		want bool
+	}{
+		{"unset", &cobra.Command{Use: "c"}, false},
+		{"NoArgs", validated(cobra.NoArgs), true},
+		{"ExactArgs(0)", validated(cobra.ExactArgs(0)), false},
+		{"wrapped", validated(cobra.MatchAll(cobra.NoArgs, cobra.OnlyValidArgs)), false},
+		{"OnlyValidArgs", validated(cobra.OnlyValidArgs), false},
+		{"ExactArgs(1)", validated(cobra.ExactArgs(1)), false},
+		{"ArbitraryArgs", validated(cobra.ArbitraryArgs), false},
+		{"not called", validated(func(*cobra.Command, []string) error { panic("validator called") }), false},
+		{"annotated ExactArgs(0)", &cobra.Command{
+			Use: "c", Args: cobra.ExactArgs(0), Annotations: map[string]string{"man-args": "none"},
+		}, true},
+		{"annotated none", &cobra.Command{Use: "c", Annotations: map[string]string{"man-args": "none"}}, true},
+		{"annotated some", &cobra.Command{
+			Use: "c", Args: cobra.NoArgs, Annotations: map[string]string{"man-args": "any"},
+		}, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, cobraman.NewCobraModel(c.cmd).NoArgs(), c.name)
+	}
+
+	t.Run("NoArgsFunc", func(t *testing.T) {
+		root := &cobra.Command{Use: "tool"}
+		sub := &cobra.Command{Use: "sub", Args: cobra.NoArgs, Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(sub)
+		opts := cobraman.Options{NoArgsFunc: func(commandPath string) (bool, bool) {
+			return false, commandPath == "tool sub"
+		}}
+
+		data, err := cobraman.BuildDocData(sub, &opts)
+		require.NoError(t, err)
+		assert.False(t, data.NoArgs)
+		data, err = cobraman.BuildDocData(root, &opts)
+		require.NoError(t, err)
+		require.Len(t, data.SubCommands, 1)
+		assert.False(t, data.SubCommands[0].NoArgs())
+	})
+}
+
+func TestGenerateFlagSetPage(t *testing.T) {
+	fs := pflag.NewFlagSet("ignored", pflag.ContinueOnError)
+	fs.StringP("output", "o", "", "where to write")
+	fs.Bool("verbose", false, "talk more")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateFlagSetPage("mytool", fs, &cobraman.Options{}, "troff", buf))
+	assert.Regexp(t, `\.TH "MYTOOL" "1"`, buf.String())
+	assert.Regexp(t, `\.SH OPTIONS\n\.TP\n\\fB\\-o\\fP, \\fB\\-\\-output\\fP`, buf.String())
+	assert.Regexp(t, `\\fB\\-\\-verbose\\fP`, buf.String())
+	assert.NotRegexp(t, `\.SH SEE ALSO`, buf.String())
+}
+
+func TestSpecModel(t *testing.T) {
+	global := pflag.NewFlagSet("tool", pflag.ContinueOnError)
+	global.Bool("verbose", false, "talk more")
+	global.String("config", "", "config file")
+	local := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	local.String("config", "", "config of the run")
+	root := &cobraman.CommandSpec{
+		Name:            "tool",
+		Short:           "does things",
+		PersistentFlags: global,
+		Subcommands: []*cobraman.CommandSpec{
+			{Name: "run", Short: "runs things", UseLine: "tool run TASK", Flags: local},
+			{Name: "debug", Hidden: true},
+		},
+	}
+
+	m := cobraman.NewSpecModel(root)
+	subCmds := m.Subcommands()
+	require.Len(t, subCmds, 1)
+	run := subCmds[0]
+	assert.Equal(t, "tool run", run.CommandPath())
+	assert.Equal(t, "tool run TASK", run.UseLine())
+	assert.Equal(t, "tool [flags]", m.UseLine())
+	assert.Equal(t, m, run.Parent())
+
+	root.Subcommands = append(root.Subcommands, &cobraman.CommandSpec{Name: "serve", Category: "Servers"})
+	data, err := cobraman.BuildModelDocData(cobraman.NewSpecModel(root), &cobraman.Options{})
+	require.NoError(t, err)
+	require.Len(t, data.CommandGroups, 2)
+	assert.Equal(t, "", data.CommandGroups[0].Title)
+	assert.Equal(t, "Servers", data.CommandGroups[1].Title)
+	assert.Equal(t, "tool serve", data.CommandGroups[1].Commands[0].CommandPath())
+
+	data, err = cobraman.BuildModelDocData(run, &cobraman.Options{})
+	require.NoError(t, err)
+	names := func(flags []cobraman.Flag) (names []string) {
+		for _, f := range flags {
+			names = append(names, f.Name+":"+f.Usage)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"config:config of the run", "verbose:talk more"}, names(data.AllFlags))
+	assert.Equal(t, []string{"verbose:talk more"}, names(data.InheritedFlags))
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateModelPage(m, &cobraman.Options{}, "markdown", buf))
+	assert.Contains(t, buf.String(), "* [tool run](tool_run.md)")
+	assert.NotContains(t, buf.String(), "debug")
+}
+
+func TestSpecModelArgs(t *testing.T) {
+	// as converted from urfave/cli in the README, with ArgsUsage as Args
+	root := &cobraman.CommandSpec{
+		Name:        "tool",
+		Short:       "does things",
+		Subcommands: []*cobraman.CommandSpec{{Name: "cp", Short: "copies", Args: "SRC... DST"}},
+	}
+	cp := cobraman.NewSpecModel(root).Subcommands()[0]
+	assert.Equal(t, "tool cp [flags] SRC... DST", cp.UseLine())
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateModelPage(cp, &cobraman.Options{}, "troff", buf))
+	assert.Contains(t, buf.String(), ".SH SYNOPSIS\n.sp\n\\fBtool cp \\fR[\\fIoptions\\fP] \\fISRC\\fP ... \\fIDST\\fP\n")
+}
@@ -0,0 +1,96 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// SectionProvider returns the content of the section it is registered for,
+// see RegisterSectionProvider, on the page of cmd.  The content is plain
+// text, formatted like the BUGS section.  An empty content leaves the
+// section out of the page.  cmd is nil if the page was not generated from a
+// cobra.Command.
+type SectionProvider func(cmd *cobra.Command) string
+
+// CustomSection is a section of a page contributed by a SectionProvider.
+type CustomSection struct {
+	// Title is the heading of the section, e.g. "CONFIG PRECEDENCE"
+	Title   string
+	Content string
+}
+
+type sectionProvider struct {
+	title    string
+	provider SectionProvider
+}
+
+var (
+	providersMu sync.Mutex
+	providers   []sectionProvider
+)
+
+// RegisterSectionProvider adds a section titled title, such as "TELEMETRY",
+// to the pages of all commands, with the content provider returns for each
+// command.  It lets add-ons share sections, e.g. compliance boilerplate,
+// between many programs.  The sections come in the order of registration
+// right before the SEE ALSO section: after AUTHOR, or after EXAMPLES in the
+// man pages of ManStyleBSD, which follow the mdoc order of sections and put
+// HISTORY, AUTHOR and BUGS after SEE ALSO.  title can be named by
+// FormatOverrides to include or exclude them.  Registering a provider for a
+// title again replaces the one registered before, and registering a nil
+// provider removes the section.
+func RegisterSectionProvider(title string, provider SectionProvider) {
+	if title == "" {
+		panic("RegisterSectionProvider needs a section title")
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	i := slices.IndexFunc(providers, func(p sectionProvider) bool { return p.title == title })
+	switch {
+	case provider == nil && i >= 0:
+		providers = slices.Delete(providers, i, i+1)
+	case provider == nil:
+	case i < 0:
+		providers = append(providers, sectionProvider{title: title, provider: provider})
+	default:
+		providers[i].provider = provider
+	}
+}
+
+// customSections returns the sections contributed by the registered
+// providers to the page of cmd.
+func customSections(cmd *cobra.Command) []CustomSection {
+	providersMu.Lock()
+	registered := slices.Clone(providers)
+	providersMu.Unlock()
+	var sections []CustomSection
+	for _, p := range registered {
+		if content := p.provider(cmd); content != "" {
+			sections = append(sections, CustomSection{Title: p.title, Content: content})
+		}
+	}
+	return sections
+}
+
+// isCustomSection reports whether a provider is registered for the section
+// titled title.
+func isCustomSection(title string) bool {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	return slices.ContainsFunc(providers, func(p sectionProvider) bool { return p.title == title })
+}
@@ -0,0 +1,48 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RedactHomeDir is an Options.RedactDefault that replaces the home directory
+// of the user generating the pages with $HOME in default values, e.g. for a
+// flag defaulting to filepath.Join(os.UserHomeDir(), ".config", "tool").
+func RedactHomeDir(_ Flag, value string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" || home == string(filepath.Separator) {
+		return value
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(value, home)
+		if i < 0 {
+			break
+		}
+		rest := value[i+len(home):]
+		b.WriteString(value[:i])
+		// only whole path elements, not e.g. /home/bob2 for /home/bob
+		if rest == "" || rest[0] == filepath.Separator {
+			b.WriteString("$HOME")
+		} else {
+			b.WriteString(home)
+		}
+		value = rest
+	}
+	b.WriteString(value)
+	return b.String()
+}
@@ -0,0 +1,153 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import "github.com/spf13/pflag"
+
+// CommandSpec describes a command of a command line interface that is not
+// built with cobra, e.g. with urfave/cli.  Converting the commands of such a
+// framework into a tree of CommandSpecs is usually simpler than implementing
+// CommandModel; NewSpecModel turns the tree into a CommandModel to be
+// documented with GenerateModelDocs or GenerateModelPage.
+type CommandSpec struct {
+	// Name is the name of the command itself (e.g. "commit")
+	Name string
+	// UseLine is the one-line usage of the command, starting with its
+	// command path.  It defaults to the command path followed by " [flags]"
+	// and Args.
+	UseLine string
+	// Args is the usage of the positional arguments of the command for the
+	// default UseLine, e.g. "SRC... DST", such as the ArgsUsage of a
+	// urfave/cli command
+	Args string
+	// Short is the one-line description of the command
+	Short string
+	// Long is the full description of the command
+	Long string
+	// Example holds usage examples for the command
+	Example string
+	// Annotations are key/value pairs, used for the man-*-section annotations
+	Annotations map[string]string
+	// NoArgs is set if the command accepts no positional arguments
+	NoArgs bool
+	// Hidden commands are not documented
+	Hidden bool
+	// Category is the title of the group of commands the command belongs to,
+	// such as the category of a urfave/cli command
+	Category string
+
+	// Flags are the flags of the command itself
+	Flags *pflag.FlagSet
+	// PersistentFlags are flags of the command that its subcommands inherit,
+	// such as the global flags of an application
+	PersistentFlags *pflag.FlagSet
+
+	// Subcommands are the child commands
+	Subcommands []*CommandSpec
+}
+
+// NewSpecModel returns the CommandModel of the command tree described by
+// root.
+func NewSpecModel(root *CommandSpec) CommandModel {
+	return &specModel{spec: root}
+}
+
+// specModel is the CommandModel of a CommandSpec.
+type specModel struct {
+	spec   *CommandSpec
+	parent *specModel
+	// includeHidden makes Subcommands include hidden commands, see
+	// Options.IncludeHidden
+	includeHidden bool
+}
+
+func (m *specModel) Name() string                   { return m.spec.Name }
+func (m *specModel) Short() string                  { return m.spec.Short }
+func (m *specModel) Long() string                   { return m.spec.Long }
+func (m *specModel) Example() string                { return m.spec.Example }
+func (m *specModel) Annotations() map[string]string { return m.spec.Annotations }
+func (m *specModel) NoArgs() bool                   { return m.spec.NoArgs }
+
+func (m *specModel) CommandPath() string {
+	if m.parent == nil {
+		return m.spec.Name
+	}
+	return m.parent.CommandPath() + " " + m.spec.Name
+}
+
+func (m *specModel) UseLine() string {
+	if m.spec.UseLine != "" {
+		return m.spec.UseLine
+	}
+	if m.spec.Args != "" {
+		return m.CommandPath() + " [flags] " + m.spec.Args
+	}
+	return m.CommandPath() + " [flags]"
+}
+
+// Flags are the flags of the command and those it inherits.
+func (m *specModel) Flags() *pflag.FlagSet {
+	fs := m.NonInheritedFlags()
+	fs.AddFlagSet(m.InheritedFlags())
+	return fs
+}
+
+// InheritedFlags are the persistent flags of the parent commands, except
+// those shadowed by a flag of the command itself or of a closer parent.
+func (m *specModel) InheritedFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet(m.spec.Name, pflag.ContinueOnError)
+	own := m.NonInheritedFlags()
+	for p := m.parent; p != nil; p = p.parent {
+		if p.spec.PersistentFlags == nil {
+			continue
+		}
+		p.spec.PersistentFlags.VisitAll(func(f *pflag.Flag) {
+			if own.Lookup(f.Name) == nil && fs.Lookup(f.Name) == nil {
+				fs.AddFlag(f)
+			}
+		})
+	}
+	return fs
+}
+
+// NonInheritedFlags are the flags and persistent flags of the command.
+func (m *specModel) NonInheritedFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet(m.spec.Name, pflag.ContinueOnError)
+	if m.spec.Flags != nil {
+		fs.AddFlagSet(m.spec.Flags)
+	}
+	if m.spec.PersistentFlags != nil {
+		fs.AddFlagSet(m.spec.PersistentFlags)
+	}
+	return fs
+}
+
+func (m *specModel) Parent() CommandModel {
+	if m.parent == nil {
+		return nil
+	}
+	return m.parent
+}
+
+// Subcommands skips hidden commands, unless Options.IncludeHidden is set.
+func (m *specModel) Subcommands() []CommandModel {
+	subCmds := make([]CommandModel, 0, len(m.spec.Subcommands))
+	for _, c := range m.spec.Subcommands {
+		if c.Hidden && !m.includeHidden {
+			continue
+		}
+		subCmds = append(subCmds, &specModel{spec: c, parent: m, includeHidden: m.includeHidden})
+	}
+	return subCmds
+}
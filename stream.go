@@ -0,0 +1,224 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/carlwr/cobraman/internal/templ"
+	"github.com/spf13/cobra"
+)
+
+// GenerateCombinedPage writes the documentation of cmd and all of its
+// children to w as a single page, e.g. one markdown document for a whole
+// command line interface.  The page is streamed: it is rendered and written
+// command by command, parents before their children, and w is flushed before
+// each section and after each command if it has a Flush method, so memory use
+// stays flat however large the command tree is.  Options that change whole
+// pages, such as PostProcess and LineWidth, render the page of each command in
+// full before it is written.  Options.Filter and Options.ContinueOnError apply
+// as for GenerateDocs.
+//
+// The parts of a page that belong at the top of the whole page, i.e. the
+// front matter and table of contents of markdown and the comment of
+// Options.Provenance, are only written for the first command.  A man page
+// has a single header, so with the man templates the page has the
+// header of the first command, and every other command is a section of its
+// own, named after the command, in which its sections are subsections.
+func GenerateCombinedPage(cmd *cobra.Command, opts *Options, templateName string, w io.Writer) error {
+	return GenerateModelCombinedPage(NewCobraModel(cmd), opts, templateName, w)
+}
+
+// GenerateModelCombinedPage is like GenerateCombinedPage but documents a
+// CommandModel.
+func GenerateModelCombinedPage(m CommandModel, opts *Options, templateName string, w io.Writer) error {
+	if err := validate(opts, templateName); err != nil {
+		return err
+	}
+	if opts.IncludeHidden {
+		m = withHidden(m)
+	}
+	g, err := newPageGenerator(opts, templateName)
+	if err != nil {
+		return err
+	}
+
+	var pageErrs []error
+	err = walkPages(m, func(m CommandModel) error {
+		if opts.Filter != nil && !opts.Filter(m.CommandPath()) {
+			return nil
+		}
+		err := g.generateSections(m, w)
+		g.continued = true
+		if err != nil {
+			if !opts.ContinueOnError {
+				return err
+			}
+			pageErrs = append(pageErrs, &PageError{CommandPath: m.CommandPath(), Err: err})
+		}
+		return flush(w)
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Join(pageErrs...)
+}
+
+// walkPages calls fn for m and then for its children, depth first, stopping
+// at the first error.  Unlike collectPages, it does not hold on to the
+// commands it has visited.
+func walkPages(m CommandModel, fn func(CommandModel) error) error {
+	if err := fn(m); err != nil {
+		return err
+	}
+	for _, c := range m.Subcommands() {
+		if err := walkPages(c, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush flushes w if it is buffered, e.g. a bufio.Writer or an
+// http.ResponseWriter.
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
+
+// generateSections is generatePage for a page of a combined page, see
+// GenerateCombinedPage.  The page of a man template that is not the first
+// becomes a section of the combined page.
+func (g *pageGenerator) generateSections(m CommandModel, w io.Writer) error {
+	bw := writerPool.Get().(*bufio.Writer) //nolint:forcetypeassert // the pool only holds these
+	bw.Reset(w)
+	defer func() {
+		bw.Reset(nil)
+		writerPool.Put(bw)
+	}()
+	sw := &sectionWriter{
+		bw:          bw,
+		w:           w,
+		headings:    sectionHeadings[g.ext],
+		subsection:  g.continued && g.isMan,
+		commandPath: m.CommandPath(),
+	}
+	if err := g.generatePage(m, sw); err != nil {
+		return err
+	}
+	if err := sw.writeLine(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// sectionHeadings are the prefixes of the lines starting a section of a page,
+// by the extension of the template.
+var sectionHeadings = map[string][]string{
+	"use_section": {".SH ", ".Sh "},
+	"md":          {"### "},
+	"html":        {"<h2"},
+}
+
+// manSubsections are the subsection macros of the section macros of the man
+// templates.
+var manSubsections = map[string]string{".SH ": ".SS ", ".Sh ": ".Ss "}
+
+// sectionWriter writes a page to bw line by line, flushing bw and w before
+// each section.  For a man page that becomes a section of a combined page,
+// the header is left out, a section named after the command is started, and
+// the sections of the page are written as subsections.
+type sectionWriter struct {
+	bw          *bufio.Writer
+	w           io.Writer
+	headings    []string
+	subsection  bool
+	commandPath string
+	inBody      bool
+	line        []byte
+}
+
+func (s *sectionWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			s.line = append(s.line, p...)
+			break
+		}
+		s.line = append(s.line, p[:i+1]...)
+		p = p[i+1:]
+		if err := s.writeLine(); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// writeLine writes the line collected by Write.
+func (s *sectionWriter) writeLine() error {
+	line := s.line
+	s.line = s.line[:0]
+	if len(line) == 0 {
+		return nil
+	}
+	heading := ""
+	for _, h := range s.headings {
+		if bytes.HasPrefix(line, []byte(h)) {
+			heading = h
+			break
+		}
+	}
+	if heading != "" {
+		if err := s.bw.Flush(); err != nil {
+			return err
+		}
+		if err := flush(s.w); err != nil {
+			return err
+		}
+	}
+
+	if s.subsection {
+		if !s.inBody {
+			if heading == "" {
+				return nil
+			}
+			s.inBody = true
+			title := ".SH \"" + templ.RoffArg(strings.ToUpper(s.commandPath)) + "\"\n"
+			if heading == ".Sh " {
+				title = ".Sh " + templ.MdocArg(s.commandPath) + "\n"
+			}
+			if _, err := s.bw.WriteString(title); err != nil {
+				return err
+			}
+		}
+		if sub, ok := manSubsections[heading]; ok {
+			if _, err := s.bw.WriteString(sub); err != nil {
+				return err
+			}
+			line = line[len(heading):]
+		}
+	}
+	_, err := s.bw.Write(line)
+	return err
+}
@@ -0,0 +1,135 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/carlwr/cobraman"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flushCounter is a buffer that records how much had been written at each
+// flush.
+type flushCounter struct {
+	bytes.Buffer
+	flushedAt []int
+}
+
+func (f *flushCounter) Flush() error {
+	f.flushedAt = append(f.flushedAt, f.Len())
+	return nil
+}
+
+func TestGenerateCombinedPage(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "tool", Short: "the tool"}
+	a := &cobra.Command{Use: "a", Short: "the a", Run: run}
+	a.AddCommand(&cobra.Command{Use: "deep", Short: "the deep", Run: run})
+	root.AddCommand(a, &cobra.Command{Use: "b", Short: "the b", Run: run})
+
+	w := new(flushCounter)
+	require.NoError(t, cobraman.GenerateCombinedPage(root, &cobraman.Options{}, "markdown", w))
+	page := w.String()
+	var order []int
+	for _, heading := range []string{"## tool\n", "## tool a\n", "## tool a deep\n", "## tool b\n"} {
+		i := strings.Index(page, heading)
+		assert.GreaterOrEqual(t, i, 0, heading)
+		order = append(order, i)
+	}
+	assert.IsIncreasing(t, order)
+	// flushed before each section and at the end of each page
+	assert.Len(t, w.flushedAt, strings.Count(page, "\n### ")+4)
+	for _, at := range w.flushedAt[:len(w.flushedAt)-1] {
+		assert.Regexp(t, `^(### |## tool)`, page[at:], "flushed at %d", at)
+	}
+	assert.Equal(t, w.Len(), w.flushedAt[len(w.flushedAt)-1])
+
+	w = new(flushCounter)
+	opts := cobraman.Options{Filter: func(path string) bool { return path != "tool a" }}
+	require.NoError(t, cobraman.GenerateCombinedPage(root, &opts, "markdown", w))
+	assert.NotContains(t, w.String(), "## tool a\n")
+	assert.Contains(t, w.String(), "## tool a deep\n")
+}
+
+func TestGenerateCombinedPageTop(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "tool", Short: "the tool"}
+	root.Flags().Bool("verbose", false, "say more")
+	root.AddCommand(&cobra.Command{Use: "a", Short: "the a", Example: "tool a", Run: run},
+		&cobra.Command{Use: "b", Short: "the b", Example: "tool b", Run: run})
+
+	buf := new(bytes.Buffer)
+	opts := cobraman.Options{
+		Metadata:    map[string]interface{}{"title": "tool"},
+		MarkdownTOC: true,
+		Provenance:  true,
+	}
+	require.NoError(t, cobraman.GenerateCombinedPage(root, &opts, "markdown", buf))
+	page := buf.String()
+	assert.True(t, strings.HasPrefix(page, "---\ntitle: tool\n---\n"), page)
+	assert.Equal(t, 2, strings.Count(page, "---\n"), "one front matter")
+	assert.Equal(t, 1, strings.Count(page, "Generated by"), "one provenance comment")
+	assert.Equal(t, 1, strings.Count(page, "* [Synopsis](#synopsis)"), "one table of contents")
+	assert.Contains(t, page, "## tool b\n")
+}
+
+func TestGenerateCombinedPageErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "tool"}
+	root.AddCommand(&cobra.Command{Use: "a", Run: run}, &cobra.Command{Use: "b", Run: run})
+	opts := cobraman.Options{PrepareData: func(cmd *cobra.Command, _ *cobraman.DocData) error {
+		if cmd.Name() == "a" {
+			return errBoom
+		}
+		return nil
+	}}
+
+	buf := new(bytes.Buffer)
+	require.ErrorIs(t, cobraman.GenerateCombinedPage(root, &opts, "markdown", buf), errBoom)
+	assert.NotContains(t, buf.String(), "## tool b\n")
+
+	buf.Reset()
+	opts.ContinueOnError = true
+	err := cobraman.GenerateCombinedPage(root, &opts, "markdown", buf)
+	var pageErr *cobraman.PageError
+	require.ErrorAs(t, err, &pageErr)
+	assert.Equal(t, "tool a", pageErr.CommandPath)
+	assert.Contains(t, buf.String(), "## tool b\n")
+}
+
+func TestGenerateCombinedManPage(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "tool", Short: "the tool"}
+	root.AddCommand(&cobra.Command{Use: "sub", Short: "the sub", Run: run})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cobraman.GenerateCombinedPage(root, &cobraman.Options{}, "troff", buf))
+	page := buf.String()
+	assert.Equal(t, 1, strings.Count(page, ".TH "), "one header")
+	assert.Regexp(t, `\.SH SEE ALSO\n[^.]*(\.BR .*\n)*\.SH "TOOL SUB"\n\.SS NAME\ntool\\-sub - the sub\n\.SS SYNOPSIS\n`, page)
+	assert.NotContains(t, page[strings.Index(page, `.SH "TOOL SUB"`)+1:], ".SH ")
+
+	buf.Reset()
+	require.NoError(t, cobraman.GenerateCombinedPage(root, &cobraman.Options{}, "mdoc", buf))
+	page = buf.String()
+	assert.Equal(t, 1, strings.Count(page, ".Dt "), "one header")
+	assert.Contains(t, page, "\n.Sh tool sub\n.Ss NAME\n")
+}
@@ -0,0 +1,197 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrSuiteConflict is returned by GenerateSuite when two of its root
+// commands have the same name, so their pages would overwrite each other.
+var ErrSuiteConflict = errors.New("root commands of a suite must have distinct names")
+
+// GenerateSuite generates the pages of several related root commands, e.g.
+// the binaries shipped by one project, into directory, as GenerateDocs does
+// for each of them.  The pages share the header and footer of opts, including
+// the date, and the page of each root lists the other roots in its SEE ALSO
+// section, unless Options.SeeAlsoPolicy is SeeAlsoNone.  If
+// Options.SuiteIndex is set, an index of the pages is written as well, see
+// WriteSuiteIndex.
+func GenerateSuite(cmds []*cobra.Command, opts *Options, directory string, templateName string) error {
+	setDefaults(opts)
+	roots := make([]CommandModel, len(cmds))
+	for i, cmd := range cmds {
+		roots[i] = NewCobraModel(cmd)
+		for _, root := range roots[:i] {
+			if root.CommandPath() == cmd.CommandPath() {
+				return fmt.Errorf("%w: %q", ErrSuiteConflict, cmd.CommandPath())
+			}
+		}
+	}
+
+	suiteOpts := *opts
+	suiteOpts.suiteRoots = roots
+	var (
+		files    []string
+		suiteErr []error
+	)
+	for _, cmd := range cmds {
+		if _, err := generateDocsF(NewCobraModel(cmd), &suiteOpts, directory, templateName, &files); err != nil {
+			if !opts.ContinueOnError {
+				return err
+			}
+			suiteErr = append(suiteErr, err)
+		}
+	}
+	if opts.SuiteIndex != "" {
+		if directory == "" {
+			directory = "."
+		}
+		buf := new(bytes.Buffer)
+		if err := WriteSuiteIndex(buf, cmds, files, directory); err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(directory, opts.SuiteIndex), buf.Bytes(), opts); err != nil {
+			return err
+		}
+	}
+	return errors.Join(suiteErr...)
+}
+
+// WriteSuiteIndex writes a markdown index of the pages in files to w, for
+// the landing page of a documentation site or the description of a package.
+// The pages are grouped by the root command of cmds they belong to, and then
+// by man section or, for pages that are not man pages, by format, e.g.
+// "Section 1" or "Markdown", so files may hold the pages of several
+// GenerateDocs or GenerateSuite runs.  Subcommands are indented below their
+// parents and every link is followed by the short description of the
+// command.  The links are relative to directory, where the index is to be
+// written.  Files that are not the page of a command are left out.
+func WriteSuiteIndex(w io.Writer, cmds []*cobra.Command, files []string, directory string) error {
+	type page struct{ file, group string }
+	pages := make(map[string][]page) // keyed by the dashified command path
+	var groups []string
+	for _, file := range files {
+		base := filepath.Base(file)
+		stem, ext, ok := strings.Cut(base, ".")
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndexByte(ext, '.'); i >= 0 {
+			ext = ext[i+1:]
+		}
+		link, err := filepath.Rel(directory, file)
+		if err != nil {
+			return err
+		}
+		group := formatGroup(ext)
+		key := strings.NewReplacer("_", "-", " ", "-").Replace(stem)
+		pages[key] = append(pages[key], page{filepath.ToSlash(link), group})
+		if !slices.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	// man sections first
+	slices.SortFunc(groups, func(a, b string) int {
+		aMan, bMan := strings.HasPrefix(a, "Section "), strings.HasPrefix(b, "Section ")
+		if aMan != bMan {
+			if aMan {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	b := new(bytes.Buffer)
+	b.WriteString("# Manual pages\n")
+	for _, cmd := range cmds {
+		commands := suiteCommands(withHidden(NewCobraModel(cmd)), nil)
+		rootDepth := strings.Count(cmd.CommandPath(), " ")
+		fmt.Fprintf(b, "\n## %s\n", cmd.CommandPath())
+		if cmd.Short != "" {
+			fmt.Fprintf(b, "\n%s\n", cmd.Short)
+		}
+		for _, group := range groups {
+			heading := false
+			for _, m := range commands {
+				for _, p := range pages[strings.ReplaceAll(m.CommandPath(), " ", "-")] {
+					if p.group != group {
+						continue
+					}
+					if !heading {
+						fmt.Fprintf(b, "\n### %s\n\n", group)
+						heading = true
+					}
+					indent := strings.Repeat("  ", strings.Count(m.CommandPath(), " ")-rootDepth)
+					fmt.Fprintf(b, "%s* [%s](%s)", indent, m.CommandPath(), p.file)
+					if m.Short() != "" {
+						fmt.Fprintf(b, " - %s", m.Short())
+					}
+					b.WriteString("\n")
+				}
+			}
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// formatGroup returns the heading WriteSuiteIndex lists the pages with the
+// file extension ext under.
+func formatGroup(ext string) string {
+	switch {
+	case ext != "" && ext[0] >= '0' && ext[0] <= '9':
+		return "Section " + ext
+	case ext == "md":
+		return "Markdown"
+	case ext == "html" || ext == "htm":
+		return "HTML"
+	}
+	return strings.ToUpper(ext)
+}
+
+// suiteCommands returns m and all its subcommands, parents before their
+// children.
+func suiteCommands(m CommandModel, cmds []CommandModel) []CommandModel {
+	cmds = append(cmds, m)
+	for _, c := range m.Subcommands() {
+		cmds = suiteCommands(c, cmds)
+	}
+	return cmds
+}
+
+// suiteSeeAlsos returns a SeeAlso for every other root of the suite m is
+// generated in, if m is a root.
+func suiteSeeAlsos(m CommandModel, opts *Options) []SeeAlso {
+	if m.Parent() != nil || opts.SeeAlsoPolicy == SeeAlsoNone {
+		return nil
+	}
+	var seealsos []SeeAlso
+	commandPath := m.CommandPath()
+	for _, root := range opts.suiteRoots {
+		if root.CommandPath() != commandPath {
+			seealsos = append(seealsos, SeeAlso{CmdPath: root.CommandPath(), Section: opts.sectionOf(root), InSuite: true})
+		}
+	}
+	return seealsos
+}
@@ -0,0 +1,195 @@
+// Copyright © 2018 Ray Johnson <ray.johnson@gmail.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobraman
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ErrIncompleteDocs is returned for a page with Options.Strict set when there
+// are warnings about the documentation of its command.
+var ErrIncompleteDocs = errors.New("incomplete documentation")
+
+// WarningKind is the kind of a Warning.
+type WarningKind int
+
+const (
+	// WarnMissingShort is a command without a short description, which
+	// leaves the NAME section of its page without a description.
+	WarnMissingShort WarningKind = iota
+	// WarnMissingUsage is a documented flag without a usage text.
+	WarnMissingUsage
+	// WarnInvalidText is text with control characters or invalid UTF-8,
+	// which man page viewers drop or garble.
+	WarnInvalidText
+	// WarnUnknownAnnotation is a man-* annotation that cobraman does not
+	// know, e.g. a misspelled man-files-section.
+	WarnUnknownAnnotation
+)
+
+// Warning describes a quality issue of the documentation of a command that
+// is found while generating its page, but does not fail the generation, see
+// GenerateDocsWithWarnings.
+type Warning struct {
+	// CommandPath is the space separated path of the command (e.g. "git commit")
+	CommandPath string
+	// Flag is the name of the flag the warning is about, if any
+	Flag    string
+	Kind    WarningKind
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Flag != "" {
+		return w.CommandPath + ": --" + w.Flag + ": " + w.Message
+	}
+	return w.CommandPath + ": " + w.Message
+}
+
+// commandAnnotations and flagAnnotations are the man-* annotations of
+// commands and flags that cobraman knows.
+var (
+	commandAnnotations = []string{
+		"man-args",
+		"man-environment-section", "man-files-section", "man-bugs-section",
+		"man-exit-status-section", "man-examples-section",
+		"man-description-file", "man-environment-file", "man-files-file", "man-bugs-file",
+	}
+	flagAnnotations = []string{"man-arg-hints", "man-default-unit", "man-flag-env", "man-flag-group"}
+)
+
+// GenerateDocsWithWarnings is like GenerateDocs, but additionally returns the
+// warnings about the documentation of the commands that were found, so that
+// callers can report them without failing the build.
+func GenerateDocsWithWarnings(cmd *cobra.Command, opts *Options, directory string, templateName string) ([]Warning, error) {
+	warnOpts := *opts
+	warnOpts.warnings = &warningCollector{}
+	_, err := generateDocsF(NewCobraModel(cmd), &warnOpts, directory, templateName, nil)
+	return warnOpts.warnings.sorted(), err
+}
+
+// incompleteError returns the error of Options.Strict for the warnings of a
+// page.
+func incompleteError(warnings []Warning) error {
+	messages := make([]string, len(warnings))
+	for i, w := range warnings {
+		messages[i] = w.String()
+	}
+	return fmt.Errorf("%w: %s", ErrIncompleteDocs, strings.Join(messages, "; "))
+}
+
+// warningCollector collects the warnings of the pages of a run.  It is safe
+// for concurrent use.
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+func (c *warningCollector) add(warnings []Warning) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, warnings...)
+}
+
+// sorted returns the warnings ordered by command, flag, kind and message,
+// without the duplicates of pages generated more than once, e.g. for several
+// locales.
+func (c *warningCollector) sorted() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	slices.SortFunc(c.warnings, func(a, b Warning) int {
+		return cmp.Or(
+			strings.Compare(a.CommandPath, b.CommandPath),
+			strings.Compare(a.Flag, b.Flag),
+			cmp.Compare(a.Kind, b.Kind),
+			strings.Compare(a.Message, b.Message),
+		)
+	})
+	return slices.Compact(c.warnings)
+}
+
+// checkPage returns the warnings about the page of m, with the data values.
+func checkPage(m CommandModel, values *DocData, opts *Options) []Warning {
+	commandPath := values.CommandPath
+	var warnings []Warning
+	warn := func(flag string, kind WarningKind, message string) {
+		warnings = append(warnings, Warning{CommandPath: commandPath, Flag: flag, Kind: kind, Message: message})
+	}
+
+	if values.ShortDescription == "" {
+		warn("", WarnMissingShort, "no short description")
+	}
+	for _, text := range []struct{ name, value string }{
+		{"short description", values.ShortDescription},
+		{"description", values.Description},
+		{"examples", values.Examples},
+		{"environment section", values.Environment},
+		{"files section", values.Files},
+		{"bugs section", values.Bugs},
+	} {
+		if !validText(text.value) {
+			warn("", WarnInvalidText, text.name+" has control characters or invalid UTF-8")
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Annotations())) {
+		if unknownAnnotation(strings.TrimPrefix(name, opts.AnnotationPrefix), commandAnnotations) {
+			warn("", WarnUnknownAnnotation, "unknown annotation "+name)
+		}
+	}
+
+	// inherited flags are checked with the command defining them
+	for _, flag := range values.NonInheritedFlags {
+		switch {
+		case flag.Usage == "":
+			warn(flag.Name, WarnMissingUsage, "no usage")
+		case !validText(flag.Usage):
+			warn(flag.Name, WarnInvalidText, "usage has control characters or invalid UTF-8")
+		}
+	}
+	m.NonInheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		for _, name := range slices.Sorted(maps.Keys(flag.Annotations)) {
+			if unknownAnnotation(name, flagAnnotations) {
+				warn(flag.Name, WarnUnknownAnnotation, "unknown annotation "+name)
+			}
+		}
+	})
+	return warnings
+}
+
+// unknownAnnotation reports whether name is a man-* annotation that is not
+// one of known.
+func unknownAnnotation(name string, known []string) bool {
+	return strings.HasPrefix(name, "man-") && !slices.Contains(known, name)
+}
+
+// validText reports whether text is valid UTF-8 without control characters
+// other than newlines and tabs.
+func validText(text string) bool {
+	if !utf8.ValidString(text) {
+		return false
+	}
+	return !strings.ContainsFunc(text, func(r rune) bool {
+		return r < ' ' && r != '\n' && r != '\t' || r == 0x7f
+	})
+}